@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -25,21 +26,28 @@ func main() {
 		panic(err)
 	}
 
+	// Sincronizar e rotacionar as chaves de assinatura em segundo plano
+	go container.SigningKeys.Run(context.Background())
+
+	// Remover definitivamente as contas excluídas cujo prazo de carência venceu
+	go container.AccountPurger.Run(context.Background())
+
 	// Criar o router Chi
 	r := chi.NewRouter()
 
 	// Configurar middlewares globais
 	rateLimiter := middleware.NewRateLimiter(10, time.Minute) // 10 requisições por minuto para teste
 	r.Use(
-		middleware.SecurityHeaders,                       // headers de segurança primeiro
-		middleware.CORS(&container.Config.Security.CORS), // depois CORS
-		middleware.Compress,                              // depois compressão
-		middleware.Timeout(30*time.Second),               // depois timeout
-		rateLimiter.RateLimit,                            // rate limiting por último
+		middleware.RealIP(container.Config.Server.TrustedProxies), // IP do cliente antes de tudo
+		middleware.SecurityHeaders,                                // headers de segurança primeiro
+		middleware.CORS(&container.Config.Security.CORS),          // depois CORS
+		middleware.Compress,                                       // depois compressão
+		middleware.Timeout(30*time.Second),                        // depois timeout
+		rateLimiter.RateLimit,                                     // rate limiting por último
 	)
 
 	// Setup das rotas
-	routes.SetupRoutes(r, container.Logger, container.AuthHandler, container.HealthHandler)
+	routes.SetupRoutes(r, container.Logger, container.AuthHandler, container.MFAHandler, container.WebAuthnHandler, container.OIDCHandler, container.OAuthHandler, container.APIKeyHandler, container.RoleHandler, container.OrganizationHandler, container.InvitationHandler, container.UserAdminHandler, container.DataExportHandler, container.HealthHandler)
 
 	// Iniciar o servidor
 	container.Logger.Info("Servidor iniciado na porta %s", container.Config.Server.Port)
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"auth-template/internal/config"
+	"auth-template/internal/di"
+	"auth-template/internal/entity"
+	"auth-template/internal/interfaces/service"
+)
+
+// Registra um cliente no servidor de autorização OAuth. O client_secret é exibido uma única vez.
+//
+//	go run ./cmd/oauth-client -name "Grafana" -redirect-uris https://grafana.local/login/generic_oauth -scopes "openid email profile"
+func main() {
+	name := flag.String("name", "", "nome do cliente")
+	clientType := flag.String("type", string(entity.OAuthClientConfidential), "tipo do cliente: confidential ou public")
+	redirectURIs := flag.String("redirect-uris", "", "redirect URIs permitidos, separados por vírgula")
+	postLogoutRedirectURIs := flag.String("post-logout-redirect-uris", "", "destinos permitidos após o logout, separados por vírgula")
+	scopes := flag.String("scopes", "", "escopos permitidos, separados por espaço")
+	grants := flag.String("grants", "authorization_code,refresh_token", "grant types permitidos, separados por vírgula")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	container, err := di.InitializeContainer(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, secret, err := container.OAuthService.RegisterClient(context.Background(), &service.OAuthClientRegistration{
+		Name:                   *name,
+		Type:                   entity.OAuthClientType(*clientType),
+		RedirectURIs:           splitList(*redirectURIs, ","),
+		PostLogoutRedirectURIs: splitList(*postLogoutRedirectURIs, ","),
+		Scopes:                 strings.Fields(*scopes),
+		GrantTypes:             splitList(*grants, ","),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("client_id:     %s\n", client.ID)
+	if secret != "" {
+		fmt.Printf("client_secret: %s\n", secret)
+		fmt.Println("Guarde o client_secret: ele não poderá ser exibido novamente.")
+	}
+}
+
+func splitList(value, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
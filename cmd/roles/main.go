@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"auth-template/internal/config"
+	"auth-template/internal/di"
+)
+
+// Atribui ou remove papéis de um usuário; sem -assign ou -remove, lista os papéis do usuário. Usado para
+// criar o primeiro administrador, que depois pode gerenciar os papéis pela API /admin.
+//
+//	go run ./cmd/roles -email admin@example.com -assign admin
+//	go run ./cmd/roles -email admin@example.com -remove admin
+func main() {
+	email := flag.String("email", "", "email do usuário")
+	assign := flag.String("assign", "", "papel a atribuir")
+	remove := flag.String("remove", "", "papel a remover")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("informe o email do usuário com -email")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	container, err := di.InitializeContainer(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := context.Background()
+
+	user, err := container.UserRepo.FindByEmail(ctx, *email)
+	if err != nil {
+		log.Fatalf("usuário não encontrado: %s", *email)
+	}
+	userID := fmt.Sprintf("%d", user.ID)
+
+	switch {
+	case *assign != "":
+		if err := container.RoleService.AssignRole(ctx, userID, *assign); err != nil {
+			log.Fatal(err)
+		}
+	case *remove != "":
+		if err := container.RoleService.RemoveRole(ctx, userID, *remove); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	roles, err := container.RoleService.GetUserRoles(ctx, userID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, role.Name)
+	}
+	fmt.Printf("%s: %s\n", user.Email, strings.Join(names, ", "))
+	fmt.Println("As alterações valem a partir da próxima renovação dos tokens do usuário.")
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"auth-template/internal/config"
+	"auth-template/internal/di"
+	"auth-template/internal/entity"
+)
+
+// Administra as chaves que assinam os tokens. Sem -rotate ou -retire, lista as chaves cadastradas.
+// As instâncias da API aplicam as mudanças na próxima sincronização (JWT_KEY_SYNC_INTERVAL).
+//
+//	go run ./cmd/signing-keys -rotate -use access -algorithm ES256
+//	go run ./cmd/signing-keys -retire <kid>
+func main() {
+	rotate := flag.Bool("rotate", false, "cadastra uma chave nova")
+	use := flag.String("use", string(entity.SigningKeyAccess), "tipo de token da chave nova: access ou refresh")
+	algorithm := flag.String("algorithm", "", "algoritmo da chave nova: HS256, RS256, ES256 ou EdDSA (padrão: JWT_ALGORITHM para access, HS256 para refresh)")
+	activateIn := flag.Duration("activate-in", -1, "tempo até a chave nova passar a assinar (padrão: JWT_KEY_PUBLISH_DELAY)")
+	retire := flag.String("retire", "", "kid da chave a aposentar imediatamente")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	container, err := di.InitializeContainer(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := context.Background()
+
+	switch {
+	case *rotate:
+		if *algorithm == "" {
+			*algorithm = "HS256"
+			if entity.SigningKeyUse(*use) == entity.SigningKeyAccess {
+				*algorithm = cfg.Auth.JWTAlgorithm
+			}
+		}
+		if *activateIn < 0 {
+			*activateIn = cfg.Auth.KeyPublishDelay
+		}
+
+		key, err := container.SigningKeys.Rotate(ctx, entity.SigningKeyUse(*use), *algorithm, time.Now().Add(*activateIn))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("kid: %s\n", key.ID)
+		fmt.Printf("Ativação em %s\n", key.ActivatesAt.Format(time.RFC3339))
+
+	case *retire != "":
+		if err := container.SigningKeys.Retire(ctx, *retire); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Chave %s aposentada\n", *retire)
+
+	default:
+		keys, err := container.SigningKeys.List(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KID\tUSO\tALG\tATIVAÇÃO\tAPOSENTADA")
+		for _, key := range keys {
+			retiredAt := "-"
+			if key.RetiredAt != nil {
+				retiredAt = key.RetiredAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key.ID, key.Use, key.Algorithm, key.ActivatesAt.Format(time.RFC3339), retiredAt)
+		}
+		w.Flush()
+	}
+}
@@ -8,18 +8,28 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Auth     AuthConfig
-	Log      LogConfig
-	Security SecurityConfig
+	Server       ServerConfig
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	Auth         AuthConfig
+	Log          LogConfig
+	Security     SecurityConfig
+	Mail         MailConfig
+	MFA          MFAConfig
+	WebAuthn     WebAuthnConfig
+	OIDC         OIDCConfig
+	OAuth        OAuthConfig
+	Organization OrganizationConfig
 }
 
 type ServerConfig struct {
-	Port     string
-	Timeout  time.Duration
-	Compress bool
+	Port      string
+	Timeout   time.Duration
+	Compress  bool
+	PublicURL string
+	// TrustedProxies lista os IPs ou CIDRs dos proxies autorizados a informar o IP do cliente em
+	// X-Forwarded-For e X-Real-IP; vazio ignora os dois headers e usa o endereço da conexão
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -40,10 +50,69 @@ type RedisConfig struct {
 }
 
 type AuthConfig struct {
-	AccessTokenSecret  string
-	RefreshTokenSecret string
-	AccessTokenTTL     time.Duration
-	RefreshTokenTTL    time.Duration
+	// JWTAlgorithm define como os access tokens são assinados: HS256 (segredo compartilhado, modo de
+	// compatibilidade) ou RS256, ES256 e EdDSA com a chave privada em JWTPrivateKeyFile
+	JWTAlgorithm      string
+	JWTPrivateKeyFile string
+	// As chaves de assinatura ficam no banco, cifradas com KeyEncryptionKey. A cada KeyRotationInterval
+	// (zero desativa) uma chave nova é publicada e passa a assinar após KeyPublishDelay.
+	KeyEncryptionKey    string
+	KeyRotationInterval time.Duration
+	KeyPublishDelay     time.Duration
+	KeySyncInterval     time.Duration
+	AccessTokenSecret   string
+	RefreshTokenSecret  string
+	AccessTokenTTL      time.Duration
+	RefreshTokenTTL     time.Duration
+	// RevocationCacheTTL é por quanto tempo cada instância guarda o resultado da verificação de revogação
+	// de um access token; revogações feitas em outra instância valem após esse intervalo
+	RevocationCacheTTL time.Duration
+	// CookieMode permite que os navegadores recebam os tokens em cookies HttpOnly (header X-Token-Delivery:
+	// cookie), com proteção CSRF por double submit. CookieSameSite aceita Strict ou Lax.
+	CookieMode               bool
+	CookieSameSite           string
+	RequireEmailVerification bool
+	EmailVerificationTTL     time.Duration
+	// Reenvios do email de verificação: VerificationResendRateLimit por email e VerificationResendIPRateLimit
+	// por IP dentro de VerificationResendRateWindow
+	VerificationResendRateLimit   int
+	VerificationResendIPRateLimit int
+	VerificationResendRateWindow  time.Duration
+	PasswordResetTTL              time.Duration
+	// Pedidos de redefinição de senha: PasswordResetRateLimit por email e PasswordResetIPRateLimit por IP
+	// dentro de PasswordResetRateWindow
+	PasswordResetRateLimit   int
+	PasswordResetIPRateLimit int
+	PasswordResetRateWindow  time.Duration
+	MagicLinkSecret          string
+	MagicLinkTTL             time.Duration
+	// Pedidos de magic link: MagicLinkRateLimit por email e MagicLinkIPRateLimit por IP dentro de
+	// MagicLinkRateWindow
+	MagicLinkRateLimit   int
+	MagicLinkIPRateLimit int
+	MagicLinkRateWindow  time.Duration
+	// Bloqueio do login por senha: LoginMaxAttempts falhas da mesma conta e IP, ou LoginAccountMaxAttempts
+	// falhas da conta vindas de qualquer IP, dentro de LoginAttemptWindow. O bloqueio dura LoginLockout e dobra a
+	// cada reincidência, até LoginMaxLockout. LoginLockoutStore "memory" dispensa o Redis, mas serve a um único nó.
+	LoginMaxAttempts        int
+	LoginAccountMaxAttempts int
+	LoginAttemptWindow      time.Duration
+	LoginLockout            time.Duration
+	LoginMaxLockout         time.Duration
+	LoginLockoutStore       string
+	// Exclusão da conta pelo próprio usuário: a conta pode ser restaurada pelo login durante
+	// AccountDeletionGracePeriod e depois é removida definitivamente pela rotina executada a cada AccountPurgeInterval
+	AccountDeletionGracePeriod time.Duration
+	AccountPurgeInterval       time.Duration
+	// Exportação dos dados pessoais: validade do link de download e pedidos permitidos por usuário a cada 24 horas
+	DataExportTTL       time.Duration
+	DataExportRateLimit int
+	// Pedidos de troca de email permitidos por usuário dentro de EmailChangeRateWindow
+	EmailChangeRateLimit  int
+	EmailChangeRateWindow time.Duration
+	// ReauthMaxAge é o tempo desde o login dentro do qual o usuário pode gerenciar passkeys e excluir a conta
+	// sem a senha
+	ReauthMaxAge time.Duration
 }
 
 type LogConfig struct {
@@ -55,6 +124,70 @@ type SecurityConfig struct {
 	CORS CORSConfig
 }
 
+type MFAConfig struct {
+	Issuer        string
+	EncryptionKey string
+	ChallengeTTL  time.Duration
+	// MaxAttempts limita as falhas de um desafio; UserMaxAttempts, as do usuário somadas entre desafios
+	MaxAttempts     int
+	UserMaxAttempts int
+}
+
+type WebAuthnConfig struct {
+	RPID          string
+	RPDisplayName string
+	RPOrigins     []string
+	CeremonyTTL   time.Duration
+}
+
+// OIDCProviderConfig descreve um provedor de login externo (Google, Microsoft ou qualquer issuer OIDC)
+type OIDCProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type OIDCConfig struct {
+	Providers []OIDCProviderConfig
+	StateTTL  time.Duration
+}
+
+// OAuthConfig configura o servidor de autorização OAuth 2.1 e provedor OpenID Connect para clientes terceiros
+type OAuthConfig struct {
+	// Issuer é a URL pública desta API, usada como iss dos ID tokens e base dos endpoints do discovery
+	Issuer  string
+	CodeTTL time.Duration
+	// LoginURL é a página do frontend que autentica o usuário e pede o consentimento.
+	// GET /oauth/authorize redireciona para ela repassando os parâmetros da autorização.
+	LoginURL string
+	// LogoutURL é a página do frontend que encerra a sessão no logout iniciado pelo cliente (GET /oauth/logout)
+	LogoutURL string
+	// IDTokenKeyFile é a chave privada RSA (PEM) que assina os ID tokens. Sem ela, uma chave
+	// temporária é gerada na inicialização e os ID tokens deixam de ser verificáveis após reiniciar.
+	IDTokenKeyFile string
+	IDTokenTTL     time.Duration
+}
+
+// OrganizationConfig configura os convites para as organizações
+type OrganizationConfig struct {
+	// InvitationSecret assina os links de convite, que expiram após InvitationTTL
+	InvitationSecret string
+	InvitationTTL    time.Duration
+	// InvitationDefaultRole é o papel do convidado quando o convite não informa outro
+	InvitationDefaultRole string
+}
+
+type MailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
 type CORSConfig struct {
 	AllowedOrigins   []string
 	AllowedMethods   []string
@@ -80,6 +213,15 @@ func getEnvIntOrDefault(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvBoolOrDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -99,9 +241,11 @@ func getEnvStringSliceOrDefault(key string, defaultValue []string) []string {
 func Load() (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
-			Port:     getEnvOrDefault("SERVER_PORT", ":8081"),
-			Timeout:  getEnvDurationOrDefault("SERVER_TIMEOUT", 30*time.Second),
-			Compress: true,
+			Port:           getEnvOrDefault("SERVER_PORT", ":8081"),
+			Timeout:        getEnvDurationOrDefault("SERVER_TIMEOUT", 30*time.Second),
+			Compress:       true,
+			PublicURL:      getEnvOrDefault("APP_PUBLIC_URL", "http://localhost:3000"),
+			TrustedProxies: getEnvStringSliceOrDefault("SERVER_TRUSTED_PROXIES", nil),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnvOrDefault("DB_HOST", "localhost"),
@@ -119,10 +263,46 @@ func Load() (*Config, error) {
 			PoolSize: getEnvIntOrDefault("REDIS_POOL_SIZE", 10),
 		},
 		Auth: AuthConfig{
-			AccessTokenSecret:  getEnvOrDefault("JWT_ACCESS_SECRET", "dev_access_secret"),
-			RefreshTokenSecret: getEnvOrDefault("JWT_REFRESH_SECRET", "dev_refresh_secret"),
-			AccessTokenTTL:     getEnvDurationOrDefault("JWT_ACCESS_TTL", 15*time.Minute),
-			RefreshTokenTTL:    getEnvDurationOrDefault("JWT_REFRESH_TTL", 720*time.Hour),
+			JWTAlgorithm:                  getEnvOrDefault("JWT_ALGORITHM", "HS256"),
+			JWTPrivateKeyFile:             getEnvOrDefault("JWT_PRIVATE_KEY_FILE", ""),
+			KeyEncryptionKey:              getEnvOrDefault("JWT_KEY_ENCRYPTION_KEY", "dev_jwt_key_encryption_key"),
+			KeyRotationInterval:           getEnvDurationOrDefault("JWT_KEY_ROTATION_INTERVAL", 720*time.Hour),
+			KeyPublishDelay:               getEnvDurationOrDefault("JWT_KEY_PUBLISH_DELAY", 10*time.Minute),
+			KeySyncInterval:               getEnvDurationOrDefault("JWT_KEY_SYNC_INTERVAL", time.Minute),
+			AccessTokenSecret:             getEnvOrDefault("JWT_ACCESS_SECRET", "dev_access_secret"),
+			RefreshTokenSecret:            getEnvOrDefault("JWT_REFRESH_SECRET", "dev_refresh_secret"),
+			AccessTokenTTL:                getEnvDurationOrDefault("JWT_ACCESS_TTL", 15*time.Minute),
+			RefreshTokenTTL:               getEnvDurationOrDefault("JWT_REFRESH_TTL", 720*time.Hour),
+			RevocationCacheTTL:            getEnvDurationOrDefault("JWT_REVOCATION_CACHE_TTL", 5*time.Second),
+			CookieMode:                    getEnvBoolOrDefault("AUTH_COOKIE_MODE", false),
+			CookieSameSite:                getEnvOrDefault("AUTH_COOKIE_SAMESITE", "Lax"),
+			RequireEmailVerification:      getEnvBoolOrDefault("AUTH_REQUIRE_EMAIL_VERIFICATION", false),
+			EmailVerificationTTL:          getEnvDurationOrDefault("AUTH_EMAIL_VERIFICATION_TTL", 24*time.Hour),
+			VerificationResendRateLimit:   getEnvIntOrDefault("AUTH_VERIFICATION_RESEND_RATE_LIMIT", 3),
+			VerificationResendIPRateLimit: getEnvIntOrDefault("AUTH_VERIFICATION_RESEND_IP_RATE_LIMIT", 10),
+			VerificationResendRateWindow:  getEnvDurationOrDefault("AUTH_VERIFICATION_RESEND_RATE_WINDOW", time.Hour),
+			PasswordResetTTL:              getEnvDurationOrDefault("AUTH_PASSWORD_RESET_TTL", 30*time.Minute),
+			PasswordResetRateLimit:        getEnvIntOrDefault("AUTH_PASSWORD_RESET_RATE_LIMIT", 3),
+			PasswordResetIPRateLimit:      getEnvIntOrDefault("AUTH_PASSWORD_RESET_IP_RATE_LIMIT", 10),
+			PasswordResetRateWindow:       getEnvDurationOrDefault("AUTH_PASSWORD_RESET_RATE_WINDOW", time.Hour),
+			MagicLinkSecret:               getEnvOrDefault("AUTH_MAGIC_LINK_SECRET", "dev_magic_link_secret"),
+			MagicLinkTTL:                  getEnvDurationOrDefault("AUTH_MAGIC_LINK_TTL", 10*time.Minute),
+			MagicLinkRateLimit:            getEnvIntOrDefault("AUTH_MAGIC_LINK_RATE_LIMIT", 3),
+			MagicLinkIPRateLimit:          getEnvIntOrDefault("AUTH_MAGIC_LINK_IP_RATE_LIMIT", 10),
+			MagicLinkRateWindow:           getEnvDurationOrDefault("AUTH_MAGIC_LINK_RATE_WINDOW", 15*time.Minute),
+			LoginMaxAttempts:              getEnvIntOrDefault("AUTH_LOGIN_MAX_ATTEMPTS", 5),
+			LoginAccountMaxAttempts:       getEnvIntOrDefault("AUTH_LOGIN_ACCOUNT_MAX_ATTEMPTS", 20),
+			LoginAttemptWindow:            getEnvDurationOrDefault("AUTH_LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+			LoginLockout:                  getEnvDurationOrDefault("AUTH_LOGIN_LOCKOUT", time.Minute),
+			LoginMaxLockout:               getEnvDurationOrDefault("AUTH_LOGIN_MAX_LOCKOUT", time.Hour),
+			LoginLockoutStore:             getEnvOrDefault("AUTH_LOGIN_LOCKOUT_STORE", "redis"),
+			AccountDeletionGracePeriod:    getEnvDurationOrDefault("AUTH_ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour),
+			AccountPurgeInterval:          getEnvDurationOrDefault("AUTH_ACCOUNT_PURGE_INTERVAL", time.Hour),
+			DataExportTTL:                 getEnvDurationOrDefault("AUTH_DATA_EXPORT_TTL", 24*time.Hour),
+			DataExportRateLimit:           getEnvIntOrDefault("AUTH_DATA_EXPORT_RATE_LIMIT", 3),
+			EmailChangeRateLimit:          getEnvIntOrDefault("AUTH_EMAIL_CHANGE_RATE_LIMIT", 3),
+			EmailChangeRateWindow:         getEnvDurationOrDefault("AUTH_EMAIL_CHANGE_RATE_WINDOW", time.Hour),
+			ReauthMaxAge:                  getEnvDurationOrDefault("AUTH_REAUTH_MAX_AGE", 10*time.Minute),
 		},
 		Log: LogConfig{
 			Level:  getEnvOrDefault("LOG_LEVEL", "info"),
@@ -131,14 +311,76 @@ func Load() (*Config, error) {
 		Security: SecurityConfig{
 			CORS: CORSConfig{
 				AllowedOrigins:   getEnvStringSliceOrDefault("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://localhost:8080"}),
-				AllowedMethods:   getEnvStringSliceOrDefault("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
-				AllowedHeaders:   getEnvStringSliceOrDefault("CORS_ALLOWED_HEADERS", []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"}),
-				ExposedHeaders:   getEnvStringSliceOrDefault("CORS_EXPOSED_HEADERS", []string{"Link"}),
+				AllowedMethods:   getEnvStringSliceOrDefault("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+				AllowedHeaders:   getEnvStringSliceOrDefault("CORS_ALLOWED_HEADERS", []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Token-Delivery", "X-Organization-ID", "If-Match"}),
+				ExposedHeaders:   getEnvStringSliceOrDefault("CORS_EXPOSED_HEADERS", []string{"Link", "X-Total-Count", "ETag"}),
 				AllowCredentials: true,
 				MaxAge:           getEnvIntOrDefault("CORS_MAX_AGE", 86400),
 			},
 		},
+		MFA: MFAConfig{
+			Issuer:          getEnvOrDefault("MFA_ISSUER", "KufaTech"),
+			EncryptionKey:   getEnvOrDefault("MFA_ENCRYPTION_KEY", "dev_mfa_encryption_key"),
+			ChallengeTTL:    getEnvDurationOrDefault("MFA_CHALLENGE_TTL", 5*time.Minute),
+			MaxAttempts:     getEnvIntOrDefault("MFA_MAX_ATTEMPTS", 5),
+			UserMaxAttempts: getEnvIntOrDefault("MFA_USER_MAX_ATTEMPTS", 10),
+		},
+		WebAuthn: WebAuthnConfig{
+			RPID:          getEnvOrDefault("WEBAUTHN_RP_ID", "localhost"),
+			RPDisplayName: getEnvOrDefault("WEBAUTHN_RP_NAME", "KufaTech"),
+			RPOrigins:     getEnvStringSliceOrDefault("WEBAUTHN_RP_ORIGINS", []string{"http://localhost:3000"}),
+			CeremonyTTL:   getEnvDurationOrDefault("WEBAUTHN_CEREMONY_TTL", 5*time.Minute),
+		},
+		OIDC: OIDCConfig{
+			StateTTL: getEnvDurationOrDefault("OIDC_STATE_TTL", 10*time.Minute),
+		},
+		OAuth: OAuthConfig{
+			Issuer:         strings.TrimSuffix(getEnvOrDefault("OAUTH_ISSUER", "http://localhost:8081"), "/"),
+			CodeTTL:        getEnvDurationOrDefault("OAUTH_CODE_TTL", time.Minute),
+			IDTokenKeyFile: getEnvOrDefault("OAUTH_ID_TOKEN_KEY_FILE", ""),
+			IDTokenTTL:     getEnvDurationOrDefault("OAUTH_ID_TOKEN_TTL", time.Hour),
+		},
+		Organization: OrganizationConfig{
+			InvitationSecret:      getEnvOrDefault("ORG_INVITATION_SECRET", "dev_org_invitation_secret"),
+			InvitationTTL:         getEnvDurationOrDefault("ORG_INVITATION_TTL", 168*time.Hour),
+			InvitationDefaultRole: getEnvOrDefault("ORG_INVITATION_DEFAULT_ROLE", "member"),
+		},
+		Mail: MailConfig{
+			Host:     getEnvOrDefault("MAIL_HOST", ""),
+			Port:     getEnvIntOrDefault("MAIL_PORT", 587),
+			Username: getEnvOrDefault("MAIL_USERNAME", ""),
+			Password: getEnvOrDefault("MAIL_PASSWORD", ""),
+			From:     getEnvOrDefault("MAIL_FROM", "no-reply@kufatech.local"),
+		},
 	}
 
+	cfg.OIDC.Providers = loadOIDCProviders(cfg.Server.PublicURL)
+	cfg.OAuth.LoginURL = getEnvOrDefault("OAUTH_LOGIN_URL", cfg.Server.PublicURL+"/oauth/authorize")
+	cfg.OAuth.LogoutURL = getEnvOrDefault("OAUTH_LOGOUT_URL", cfg.Server.PublicURL+"/oauth/logout")
+
 	return cfg, nil
 }
+
+// loadOIDCProviders lê os provedores listados em OIDC_PROVIDERS (ex: "google,microsoft").
+// Cada provedor é configurado por OIDC_<NOME>_ISSUER, OIDC_<NOME>_CLIENT_ID, OIDC_<NOME>_CLIENT_SECRET,
+// OIDC_<NOME>_REDIRECT_URL e OIDC_<NOME>_SCOPES.
+func loadOIDCProviders(publicURL string) []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range getEnvStringSliceOrDefault("OIDC_PROVIDERS", nil) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			Issuer:       getEnvOrDefault(prefix+"ISSUER", ""),
+			ClientID:     getEnvOrDefault(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnvOrDefault(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnvOrDefault(prefix+"REDIRECT_URL", publicURL+"/oidc/callback/"+name),
+			Scopes:       strings.Fields(getEnvOrDefault(prefix+"SCOPES", "openid email profile")),
+		})
+	}
+	return providers
+}
@@ -0,0 +1 @@
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS post_logout_redirect_uris TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id VARCHAR(64) PRIMARY KEY,
+    use VARCHAR(20) NOT NULL,
+    algorithm VARCHAR(10) NOT NULL,
+    private_key TEXT NOT NULL,
+    activates_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    retired_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT chk_signing_keys_use CHECK (use IN ('access', 'refresh'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_keys_use_activates_at ON signing_keys(use, activates_at) WHERE retired_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organization_memberships;
+DROP TABLE IF EXISTS organizations; 
\ No newline at end of file
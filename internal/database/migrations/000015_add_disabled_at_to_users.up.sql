@@ -0,0 +1,2 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP WITH TIME ZONE;
\ No newline at end of file
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS account_erasures;
+
+DROP INDEX IF EXISTS idx_users_purge_at;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS purge_at;
\ No newline at end of file
@@ -0,0 +1,12 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS purge_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_purge_at ON users(purge_at) WHERE purge_at IS NOT NULL;
+
+-- Registro anonimizado das contas apagadas definitivamente: apenas o antigo ID e as datas
+CREATE TABLE IF NOT EXISTS account_erasures (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    requested_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    purged_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
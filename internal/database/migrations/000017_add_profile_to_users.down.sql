@@ -0,0 +1,7 @@
+ALTER TABLE users
+DROP COLUMN IF EXISTS admin_metadata,
+DROP COLUMN IF EXISTS metadata,
+DROP COLUMN IF EXISTS timezone,
+DROP COLUMN IF EXISTS locale,
+DROP COLUMN IF EXISTS avatar_url,
+DROP COLUMN IF EXISTS display_name;
\ No newline at end of file
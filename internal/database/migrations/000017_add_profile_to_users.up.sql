@@ -0,0 +1,8 @@
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS display_name VARCHAR(100) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS avatar_url VARCHAR(2048) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS locale VARCHAR(35) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT '',
+-- metadata é editado pelo próprio usuário; admin_metadata apenas pela administração
+ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}',
+ADD COLUMN IF NOT EXISTS admin_metadata JSONB NOT NULL DEFAULT '{}';
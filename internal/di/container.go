@@ -8,20 +8,69 @@ import (
 	"auth-template/internal/services"
 	"auth-template/pkg/auth"
 	"auth-template/pkg/logger"
+	"auth-template/pkg/mailer"
 
+	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 type Container struct {
-	Config         *config.Config
-	Logger         *logger.Logger
-	DB             *gorm.DB
-	Redis          *redis.Client
-	UserRepo       repository.UserRepository
-	TokenManager   *auth.TokenManager
-	TokenBlacklist *services.TokenBlacklist
-	AuthService    service.AuthService
-	AuthHandler    *handlers.AuthHandler
-	HealthHandler  *handlers.HealthHandler
+	Config              *config.Config
+	Logger              *logger.Logger
+	DB                  *gorm.DB
+	Redis               *redis.Client
+	Mailer              mailer.Mailer
+	UserRepo            repository.UserRepository
+	MFARepo             repository.MFARepository
+	WebAuthnRepo        repository.WebAuthnRepository
+	IdentityRepo        repository.IdentityRepository
+	OAuthClientRepo     repository.OAuthClientRepository
+	SigningKeyRepo      repository.SigningKeyRepository
+	SessionRepo         repository.SessionRepository
+	APIKeyRepo          repository.APIKeyRepository
+	RoleRepo            repository.RoleRepository
+	OrganizationRepo    repository.OrganizationRepository
+	InvitationRepo      repository.InvitationRepository
+	TokenManager        *auth.TokenManager
+	TokenBlacklist      *services.TokenBlacklist
+	RefreshFamilies     *services.RefreshTokenFamilyStore
+	SecurityEvents      *services.SecurityEvents
+	OneTimeTokens       *services.OneTimeTokenStore
+	RateLimits          *services.RateLimitStore
+	LoginThrottle       *services.LoginThrottle
+	DataExports         *services.DataExportStore
+	EmailChanges        *services.EmailChangeStore
+	MFAChallenges       *services.MFAChallengeStore
+	Encryptor           *auth.Encryptor
+	WebAuthn            *webauthn.WebAuthn
+	WebAuthnSessions    *services.WebAuthnSessionStore
+	OIDCStates          *services.OIDCStateStore
+	OAuthCodes          *services.OAuthCodeStore
+	IDTokenKeys         *auth.Keyring
+	AuthService         service.AuthService
+	MFAService          service.MFAService
+	WebAuthnService     service.WebAuthnService
+	OIDCService         service.OIDCService
+	OAuthService        service.OAuthService
+	APIKeyService       service.APIKeyService
+	RoleService         service.RoleService
+	OrganizationService service.OrganizationService
+	InvitationService   service.InvitationService
+	UserAdminService    service.UserAdminService
+	DataExportService   service.DataExportService
+	SigningKeys         service.SigningKeyService
+	AccountPurger       *services.AccountPurger
+	AuthHandler         *handlers.AuthHandler
+	MFAHandler          *handlers.MFAHandler
+	WebAuthnHandler     *handlers.WebAuthnHandler
+	OIDCHandler         *handlers.OIDCHandler
+	OAuthHandler        *handlers.OAuthHandler
+	APIKeyHandler       *handlers.APIKeyHandler
+	RoleHandler         *handlers.RoleHandler
+	OrganizationHandler *handlers.OrganizationHandler
+	InvitationHandler   *handlers.InvitationHandler
+	UserAdminHandler    *handlers.UserAdminHandler
+	DataExportHandler   *handlers.DataExportHandler
+	HealthHandler       *handlers.HealthHandler
 }
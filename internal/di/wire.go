@@ -4,8 +4,11 @@
 package di
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/golang-jwt/jwt"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
@@ -19,17 +22,68 @@ import (
 	"auth-template/pkg/auth"
 	"auth-template/pkg/database"
 	"auth-template/pkg/logger"
+	"auth-template/pkg/mailer"
 )
 
 var containerSet = wire.NewSet(
 	logger.NewLogger,
 	database.NewDB,
 	provideRedis,
+	mailer.NewMailer,
 	provideUserRepository,
+	provideMFARepository,
+	provideWebAuthnRepository,
+	provideIdentityRepository,
+	provideOAuthClientRepository,
+	provideSigningKeyRepository,
+	provideSessionRepository,
+	provideAPIKeyRepository,
+	provideRoleRepository,
+	provideOrganizationRepository,
+	provideInvitationRepository,
 	provideTokenManager,
 	provideTokenBlacklist,
+	provideRefreshTokenFamilyStore,
+	provideAccessTokenRevocations,
+	provideOneTimeTokenStore,
+	provideRateLimitStore,
+	services.NewLoginThrottle,
+	services.NewMFAThrottle,
+	provideDataExportStore,
+	provideEmailChangeStore,
+	provideMFAChallengeStore,
+	provideWebAuthnSessionStore,
+	provideOIDCStateStore,
+	provideOAuthCodeStore,
+	provideIDTokenKeys,
+	provideSigningKeyService,
+	provideEncryptor,
+	services.NewSecurityEvents,
+	services.NewWebAuthn,
 	provideAuthService,
+	services.NewAccountPurger,
+	provideMFAService,
+	services.NewWebAuthnService,
+	services.NewOIDCService,
+	services.NewOAuthService,
+	services.NewAPIKeyService,
+	services.NewRoleService,
+	services.NewOrganizationService,
+	services.NewInvitationService,
+	services.NewUserAdminService,
+	services.NewDataExportService,
+	provideTokenCookies,
 	handlers.NewAuthHandler,
+	handlers.NewMFAHandler,
+	handlers.NewWebAuthnHandler,
+	handlers.NewOIDCHandler,
+	handlers.NewOAuthHandler,
+	handlers.NewAPIKeyHandler,
+	handlers.NewRoleHandler,
+	handlers.NewOrganizationHandler,
+	handlers.NewInvitationHandler,
+	handlers.NewUserAdminHandler,
+	handlers.NewDataExportHandler,
 	handlers.NewHealthHandler,
 	wire.Struct(new(Container), "*"),
 )
@@ -47,26 +101,214 @@ func provideUserRepository(db *gorm.DB) repository.UserRepository {
 	return repo.NewUserRepository(db)
 }
 
-func provideTokenManager(cfg *config.Config) *auth.TokenManager {
-	return auth.NewTokenManager(
-		cfg.Auth.AccessTokenSecret,
-		cfg.Auth.RefreshTokenSecret,
-		cfg.Auth.AccessTokenTTL,
-		cfg.Auth.RefreshTokenTTL,
-	)
+func provideMFARepository(db *gorm.DB) repository.MFARepository {
+	return repo.NewMFARepository(db)
+}
+
+func provideWebAuthnRepository(db *gorm.DB) repository.WebAuthnRepository {
+	return repo.NewWebAuthnRepository(db)
+}
+
+func provideSessionRepository(db *gorm.DB) repository.SessionRepository {
+	return repo.NewSessionRepository(db)
+}
+
+func provideAPIKeyRepository(db *gorm.DB) repository.APIKeyRepository {
+	return repo.NewAPIKeyRepository(db)
+}
+
+func provideRoleRepository(db *gorm.DB) repository.RoleRepository {
+	return repo.NewRoleRepository(db)
+}
+
+func provideOrganizationRepository(db *gorm.DB) repository.OrganizationRepository {
+	return repo.NewOrganizationRepository(db)
+}
+
+func provideInvitationRepository(db *gorm.DB) repository.InvitationRepository {
+	return repo.NewInvitationRepository(db)
+}
+
+func provideIdentityRepository(db *gorm.DB) repository.IdentityRepository {
+	return repo.NewIdentityRepository(db)
+}
+
+func provideOAuthClientRepository(db *gorm.DB) repository.OAuthClientRepository {
+	return repo.NewOAuthClientRepository(db)
+}
+
+func provideSigningKeyRepository(db *gorm.DB) repository.SigningKeyRepository {
+	return repo.NewSigningKeyRepository(db)
+}
+
+// provideTokenManager monta os keyrings com as chaves configuradas por variáveis de ambiente, que valem até a
+// primeira sincronização com o banco. Os refresh tokens usam HS256, pois só este serviço os verifica.
+func provideTokenManager(cfg *config.Config) (*auth.TokenManager, error) {
+	accessSigner := auth.NewHMACSigner(cfg.Auth.AccessTokenSecret)
+	if cfg.Auth.JWTAlgorithm != jwt.SigningMethodHS256.Alg() {
+		if cfg.Auth.JWTPrivateKeyFile == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE é obrigatória com JWT_ALGORITHM=%s", cfg.Auth.JWTAlgorithm)
+		}
+		key, err := auth.LoadPrivateKey(cfg.Auth.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if accessSigner, err = auth.NewSigner(key); err != nil {
+			return nil, err
+		}
+		if accessSigner.Algorithm() != cfg.Auth.JWTAlgorithm {
+			return nil, fmt.Errorf("a chave em JWT_PRIVATE_KEY_FILE usa %s, mas JWT_ALGORITHM é %s", accessSigner.Algorithm(), cfg.Auth.JWTAlgorithm)
+		}
+	}
+
+	accessKeys := auth.NewKeyring(accessSigner)
+	refreshKeys := auth.NewKeyring(auth.NewHMACSigner(cfg.Auth.RefreshTokenSecret))
+
+	// Tokens sem kid, emitidos antes do keyring com os segredos HS256, valem até expirar. A sincronização com o
+	// banco encurta o prazo para o TTL contado da primeira chave registrada.
+	now := time.Now()
+	if err := accessKeys.SetLegacy(auth.NewHMACSigner(cfg.Auth.AccessTokenSecret), now.Add(cfg.Auth.AccessTokenTTL)); err != nil {
+		return nil, err
+	}
+	if err := refreshKeys.SetLegacy(auth.NewHMACSigner(cfg.Auth.RefreshTokenSecret), now.Add(cfg.Auth.RefreshTokenTTL)); err != nil {
+		return nil, err
+	}
+
+	return auth.NewTokenManager(accessKeys, refreshKeys, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL), nil
 }
 
 func provideTokenBlacklist(redis *redis.Client) *services.TokenBlacklist {
 	return services.NewTokenBlacklist(redis)
 }
 
+func provideRefreshTokenFamilyStore(redis *redis.Client, cfg *config.Config) *services.RefreshTokenFamilyStore {
+	return services.NewRefreshTokenFamilyStore(redis, cfg.Auth.RefreshTokenTTL)
+}
+
+func provideAccessTokenRevocations(blacklist *services.TokenBlacklist, cfg *config.Config) *services.AccessTokenRevocations {
+	return services.NewAccessTokenRevocations(blacklist, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL, cfg.Auth.RevocationCacheTTL)
+}
+
+func provideOneTimeTokenStore(redis *redis.Client) *services.OneTimeTokenStore {
+	return services.NewOneTimeTokenStore(redis)
+}
+
+func provideDataExportStore(redis *redis.Client) *services.DataExportStore {
+	return services.NewDataExportStore(redis)
+}
+
+func provideEmailChangeStore(redis *redis.Client) *services.EmailChangeStore {
+	return services.NewEmailChangeStore(redis)
+}
+
+func provideRateLimitStore(redis *redis.Client) *services.RateLimitStore {
+	return services.NewRateLimitStore(redis)
+}
+
+func provideMFAChallengeStore(redis *redis.Client, cfg *config.Config) *services.MFAChallengeStore {
+	return services.NewMFAChallengeStore(redis, cfg.MFA.ChallengeTTL, cfg.MFA.MaxAttempts)
+}
+
+func provideWebAuthnSessionStore(redis *redis.Client, cfg *config.Config) *services.WebAuthnSessionStore {
+	return services.NewWebAuthnSessionStore(redis, cfg.WebAuthn.CeremonyTTL)
+}
+
+func provideOIDCStateStore(redis *redis.Client, cfg *config.Config) *services.OIDCStateStore {
+	return services.NewOIDCStateStore(redis, cfg.OIDC.StateTTL)
+}
+
+func provideOAuthCodeStore(redis *redis.Client, cfg *config.Config) *services.OAuthCodeStore {
+	return services.NewOAuthCodeStore(redis, cfg.OAuth.CodeTTL)
+}
+
+// provideIDTokenKeys carrega a chave que assina os ID tokens. Sem OAUTH_ID_TOKEN_KEY_FILE é usado o keyring
+// assimétrico dos access tokens ou, no modo HS256, uma chave temporária adequada apenas para desenvolvimento.
+func provideIDTokenKeys(cfg *config.Config, tokenManager *auth.TokenManager, log *logger.Logger) (*auth.Keyring, error) {
+	if cfg.OAuth.IDTokenKeyFile != "" {
+		key, err := auth.LoadPrivateKey(cfg.OAuth.IDTokenKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := auth.NewSigner(key)
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewKeyring(signer), nil
+	}
+	if cfg.Auth.JWTAlgorithm != jwt.SigningMethodHS256.Alg() {
+		return tokenManager.Keys(auth.TokenTypeAccess), nil
+	}
+
+	log.Warn("OAUTH_ID_TOKEN_KEY_FILE não definida; usando chave temporária para os ID tokens")
+	signer, err := auth.GenerateSigner(jwt.SigningMethodRS256.Alg())
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewKeyring(signer), nil
+}
+
+// provideSigningKeyService carrega as chaves do banco nos keyrings antes que o primeiro token seja emitido
+func provideSigningKeyService(
+	repo repository.SigningKeyRepository,
+	tokenManager *auth.TokenManager,
+	redis *redis.Client,
+	cfg *config.Config,
+	log *logger.Logger,
+) (service.SigningKeyService, error) {
+	encryptor, err := auth.NewEncryptor(cfg.Auth.KeyEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKeys := services.NewSigningKeyService(repo, tokenManager, encryptor, redis, cfg, log)
+	if err := signingKeys.Sync(context.Background()); err != nil {
+		return nil, err
+	}
+	return signingKeys, nil
+}
+
+func provideEncryptor(cfg *config.Config) (*auth.Encryptor, error) {
+	return auth.NewEncryptor(cfg.MFA.EncryptionKey)
+}
+
 func provideAuthService(
 	userRepo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	webAuthnRepo repository.WebAuthnRepository,
+	sessionRepo repository.SessionRepository,
+	roleRepo repository.RoleRepository,
+	orgRepo repository.OrganizationRepository,
+	mfaChallenges *services.MFAChallengeStore,
 	tokenManager *auth.TokenManager,
 	tokenBlacklist *services.TokenBlacklist,
+	revocations *services.AccessTokenRevocations,
+	families *services.RefreshTokenFamilyStore,
+	securityEvents *services.SecurityEvents,
+	oneTimeTokens *services.OneTimeTokenStore,
+	rateLimits *services.RateLimitStore,
+	loginThrottle *services.LoginThrottle,
+	emailChanges *services.EmailChangeStore,
+	mailer mailer.Mailer,
 	cfg *config.Config,
+	log *logger.Logger,
 ) service.AuthService {
-	return services.NewAuthService(userRepo, tokenManager, tokenBlacklist, cfg)
+	return services.NewAuthService(userRepo, mfaRepo, webAuthnRepo, sessionRepo, roleRepo, orgRepo, mfaChallenges, tokenManager, tokenBlacklist, revocations, families, securityEvents, oneTimeTokens, rateLimits, loginThrottle, emailChanges, mailer, cfg, log)
+}
+
+func provideTokenCookies(cfg *config.Config) *handlers.TokenCookies {
+	return handlers.NewTokenCookies(cfg.Auth.CookieMode, cfg.Auth.CookieSameSite, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+}
+
+func provideMFAService(
+	userRepo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	authService service.AuthService,
+	mfaChallenges *services.MFAChallengeStore,
+	mfaThrottle *services.MFAThrottle,
+	encryptor *auth.Encryptor,
+	cfg *config.Config,
+) service.MFAService {
+	return services.NewMFAService(userRepo, mfaRepo, authService, mfaChallenges, mfaThrottle, encryptor, cfg)
 }
 
 // InitializeContainer inicializa o container de dependências
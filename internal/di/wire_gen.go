@@ -7,7 +7,10 @@
 package di
 
 import (
+	"context"
 	"fmt"
+	"time"
+	"github.com/golang-jwt/jwt"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
@@ -20,6 +23,7 @@ import (
 	"auth-template/pkg/auth"
 	"auth-template/pkg/database"
 	"auth-template/pkg/logger"
+	"auth-template/pkg/mailer"
 )
 
 // Injectors from wire.go:
@@ -32,34 +36,172 @@ func InitializeContainer(cfg *config.Config) (*Container, error) {
 		return nil, err
 	}
 	client := provideRedis(cfg)
+	mailerMailer := mailer.NewMailer(cfg, loggerLogger)
 	userRepository := provideUserRepository(db)
-	tokenManager := provideTokenManager(cfg)
+	mfaRepository := provideMFARepository(db)
+	webAuthnRepository := provideWebAuthnRepository(db)
+	identityRepository := provideIdentityRepository(db)
+	oAuthClientRepository := provideOAuthClientRepository(db)
+	signingKeyRepository := provideSigningKeyRepository(db)
+	sessionRepository := provideSessionRepository(db)
+	apiKeyRepository := provideAPIKeyRepository(db)
+	roleRepository := provideRoleRepository(db)
+	organizationRepository := provideOrganizationRepository(db)
+	invitationRepository := provideInvitationRepository(db)
+	mfaChallengeStore := provideMFAChallengeStore(client, cfg)
+	tokenManager, err := provideTokenManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+	signingKeyService, err := provideSigningKeyService(signingKeyRepository, tokenManager, client, cfg, loggerLogger)
+	if err != nil {
+		return nil, err
+	}
 	tokenBlacklist := provideTokenBlacklist(client)
-	authService := provideAuthService(userRepository, tokenManager, tokenBlacklist, cfg)
-	authHandler := handlers.NewAuthHandler(authService, loggerLogger)
+	accessTokenRevocations := provideAccessTokenRevocations(tokenBlacklist, cfg)
+	refreshTokenFamilyStore := provideRefreshTokenFamilyStore(client, cfg)
+	securityEvents := services.NewSecurityEvents(client, loggerLogger)
+	oneTimeTokenStore := provideOneTimeTokenStore(client)
+	rateLimitStore := provideRateLimitStore(client)
+	loginThrottle := services.NewLoginThrottle(cfg, client)
+	emailChangeStore := provideEmailChangeStore(client)
+	authService := provideAuthService(userRepository, mfaRepository, webAuthnRepository, sessionRepository, roleRepository, organizationRepository, mfaChallengeStore, tokenManager, tokenBlacklist, accessTokenRevocations, refreshTokenFamilyStore, securityEvents, oneTimeTokenStore, rateLimitStore, loginThrottle, emailChangeStore, mailerMailer, cfg, loggerLogger)
+	accountPurger := services.NewAccountPurger(cfg, userRepository, securityEvents, loggerLogger)
+	dataExportStore := provideDataExportStore(client)
+	encryptor, err := provideEncryptor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mfaThrottle := services.NewMFAThrottle(cfg, client)
+	mfaService := provideMFAService(userRepository, mfaRepository, authService, mfaChallengeStore, mfaThrottle, encryptor, cfg)
+	webAuthn, err := services.NewWebAuthn(cfg)
+	if err != nil {
+		return nil, err
+	}
+	webAuthnSessionStore := provideWebAuthnSessionStore(client, cfg)
+	webAuthnService := services.NewWebAuthnService(webAuthn, userRepository, webAuthnRepository, authService, webAuthnSessionStore, mfaChallengeStore, securityEvents, mailerMailer, cfg, loggerLogger)
+	oidcStateStore := provideOIDCStateStore(client, cfg)
+	oidcService := services.NewOIDCService(cfg, userRepository, identityRepository, roleRepository, authService, oidcStateStore, loggerLogger)
+	oAuthCodeStore := provideOAuthCodeStore(client, cfg)
+	keyring, err := provideIDTokenKeys(cfg, tokenManager, loggerLogger)
+	if err != nil {
+		return nil, err
+	}
+	oAuthService := services.NewOAuthService(oAuthClientRepository, userRepository, sessionRepository, authService, oAuthCodeStore, tokenManager, tokenBlacklist, refreshTokenFamilyStore, securityEvents, keyring, cfg)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepository, userRepository)
+	roleService := services.NewRoleService(roleRepository, userRepository)
+	organizationService := services.NewOrganizationService(organizationRepository)
+	userAdminService := services.NewUserAdminService(userRepository, roleRepository, sessionRepository, apiKeyRepository, authService, loginThrottle)
+	dataExportService := services.NewDataExportService(userRepository, sessionRepository, identityRepository, mfaRepository, webAuthnRepository, apiKeyRepository, roleRepository, organizationRepository, securityEvents, dataExportStore, rateLimitStore, mailerMailer, cfg, loggerLogger)
+	invitationService := services.NewInvitationService(cfg, invitationRepository, organizationRepository, userRepository, authService, mailerMailer, loggerLogger)
+	tokenCookies := provideTokenCookies(cfg)
+	authHandler := handlers.NewAuthHandler(authService, apiKeyService, tokenCookies, loggerLogger)
+	mfaHandler := handlers.NewMFAHandler(mfaService, tokenCookies, loggerLogger)
+	webAuthnHandler := handlers.NewWebAuthnHandler(webAuthnService, tokenCookies, loggerLogger)
+	oidcHandler := handlers.NewOIDCHandler(oidcService, tokenCookies, loggerLogger)
+	oAuthHandler := handlers.NewOAuthHandler(oAuthService, tokenCookies, loggerLogger)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService, loggerLogger)
+	roleHandler := handlers.NewRoleHandler(roleService, loggerLogger)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService, loggerLogger)
+	invitationHandler := handlers.NewInvitationHandler(invitationService, loggerLogger)
+	userAdminHandler := handlers.NewUserAdminHandler(userAdminService, loggerLogger)
+	dataExportHandler := handlers.NewDataExportHandler(dataExportService, loggerLogger)
 	healthHandler := handlers.NewHealthHandler(db)
 	container := &Container{
-		Config:         cfg,
-		Logger:         loggerLogger,
-		DB:             db,
-		Redis:          client,
-		UserRepo:       userRepository,
-		TokenManager:   tokenManager,
-		TokenBlacklist: tokenBlacklist,
-		AuthService:    authService,
-		AuthHandler:    authHandler,
-		HealthHandler:  healthHandler,
+		Config:           cfg,
+		Logger:           loggerLogger,
+		DB:               db,
+		Redis:            client,
+		Mailer:           mailerMailer,
+		UserRepo:         userRepository,
+		MFARepo:          mfaRepository,
+		WebAuthnRepo:     webAuthnRepository,
+		IdentityRepo:     identityRepository,
+		OAuthClientRepo:  oAuthClientRepository,
+		SigningKeyRepo:   signingKeyRepository,
+		SessionRepo:      sessionRepository,
+		APIKeyRepo:       apiKeyRepository,
+		RoleRepo:         roleRepository,
+		OrganizationRepo: organizationRepository,
+		InvitationRepo: invitationRepository,
+		TokenManager:     tokenManager,
+		TokenBlacklist:   tokenBlacklist,
+		RefreshFamilies:  refreshTokenFamilyStore,
+		SecurityEvents:   securityEvents,
+		OneTimeTokens:    oneTimeTokenStore,
+		RateLimits:       rateLimitStore,
+		LoginThrottle: loginThrottle,
+		EmailChanges: emailChangeStore,
+		DataExports: dataExportStore,
+		MFAChallenges:    mfaChallengeStore,
+		Encryptor:        encryptor,
+		WebAuthn:         webAuthn,
+		WebAuthnSessions: webAuthnSessionStore,
+		OIDCStates:       oidcStateStore,
+		OAuthCodes:       oAuthCodeStore,
+		IDTokenKeys:      keyring,
+		AuthService:      authService,
+		MFAService:       mfaService,
+		WebAuthnService:  webAuthnService,
+		OIDCService:      oidcService,
+		OAuthService:     oAuthService,
+		APIKeyService:    apiKeyService,
+		RoleService:      roleService,
+		OrganizationService: organizationService,
+		InvitationService: invitationService,
+		UserAdminService: userAdminService,
+		DataExportService: dataExportService,
+		SigningKeys:      signingKeyService,
+		AccountPurger: accountPurger,
+		AuthHandler:      authHandler,
+		MFAHandler:       mfaHandler,
+		WebAuthnHandler:  webAuthnHandler,
+		OIDCHandler:      oidcHandler,
+		OAuthHandler:     oAuthHandler,
+		APIKeyHandler:    apiKeyHandler,
+		RoleHandler:      roleHandler,
+		OrganizationHandler: organizationHandler,
+		InvitationHandler: invitationHandler,
+		UserAdminHandler: userAdminHandler,
+		DataExportHandler: dataExportHandler,
+		HealthHandler:    healthHandler,
 	}
 	return container, nil
 }
 
 // wire.go:
 
-var containerSet = wire.NewSet(logger.NewLogger, database.NewDB, provideRedis,
+var containerSet = wire.NewSet(logger.NewLogger, database.NewDB, provideRedis, mailer.NewMailer,
 	provideUserRepository,
+	provideMFARepository,
+	provideWebAuthnRepository,
+	provideIdentityRepository,
+	provideOAuthClientRepository,
+	provideSigningKeyRepository,
+	provideSessionRepository,
+	provideAPIKeyRepository,
+	provideRoleRepository,
+	provideOrganizationRepository,
+	provideInvitationRepository,
 	provideTokenManager,
 	provideTokenBlacklist,
-	provideAuthService, handlers.NewAuthHandler, handlers.NewHealthHandler, wire.Struct(new(Container), "*"),
+	provideRefreshTokenFamilyStore,
+	provideAccessTokenRevocations,
+	provideOneTimeTokenStore,
+	provideRateLimitStore,
+	services.NewLoginThrottle,
+	services.NewMFAThrottle,
+	provideDataExportStore,
+	provideEmailChangeStore,
+	provideMFAChallengeStore,
+	provideWebAuthnSessionStore,
+	provideOIDCStateStore,
+	provideOAuthCodeStore,
+	provideIDTokenKeys,
+	provideSigningKeyService,
+	provideEncryptor, services.NewSecurityEvents, services.NewWebAuthn,
+	provideAuthService, services.NewAccountPurger,
+	provideMFAService, services.NewWebAuthnService, services.NewOIDCService, services.NewOAuthService, services.NewAPIKeyService, services.NewRoleService, services.NewOrganizationService, services.NewInvitationService, services.NewUserAdminService, services.NewDataExportService, provideTokenCookies, handlers.NewAuthHandler, handlers.NewMFAHandler, handlers.NewWebAuthnHandler, handlers.NewOIDCHandler, handlers.NewOAuthHandler, handlers.NewAPIKeyHandler, handlers.NewRoleHandler, handlers.NewOrganizationHandler, handlers.NewInvitationHandler, handlers.NewUserAdminHandler, handlers.NewDataExportHandler, handlers.NewHealthHandler, wire.Struct(new(Container), "*"),
 )
 
 func provideRedis(cfg *config.Config) *redis.Client {
@@ -75,24 +217,212 @@ func provideUserRepository(db *gorm.DB) repository.UserRepository {
 	return repository.NewUserRepository(db)
 }
 
-func provideTokenManager(cfg *config.Config) *auth.TokenManager {
-	return auth.NewTokenManager(
-		cfg.Auth.AccessTokenSecret,
-		cfg.Auth.RefreshTokenSecret,
-		cfg.Auth.AccessTokenTTL,
-		cfg.Auth.RefreshTokenTTL,
-	)
+func provideMFARepository(db *gorm.DB) repository.MFARepository {
+	return repository.NewMFARepository(db)
+}
+
+func provideWebAuthnRepository(db *gorm.DB) repository.WebAuthnRepository {
+	return repository.NewWebAuthnRepository(db)
+}
+
+func provideSessionRepository(db *gorm.DB) repository.SessionRepository {
+	return repository.NewSessionRepository(db)
+}
+
+func provideAPIKeyRepository(db *gorm.DB) repository.APIKeyRepository {
+	return repository.NewAPIKeyRepository(db)
+}
+
+func provideRoleRepository(db *gorm.DB) repository.RoleRepository {
+	return repository.NewRoleRepository(db)
+}
+
+func provideOrganizationRepository(db *gorm.DB) repository.OrganizationRepository {
+	return repository.NewOrganizationRepository(db)
+}
+
+func provideInvitationRepository(db *gorm.DB) repository.InvitationRepository {
+	return repository.NewInvitationRepository(db)
+}
+
+func provideIdentityRepository(db *gorm.DB) repository.IdentityRepository {
+	return repository.NewIdentityRepository(db)
+}
+
+func provideOAuthClientRepository(db *gorm.DB) repository.OAuthClientRepository {
+	return repository.NewOAuthClientRepository(db)
+}
+
+func provideSigningKeyRepository(db *gorm.DB) repository.SigningKeyRepository {
+	return repository.NewSigningKeyRepository(db)
+}
+
+// provideTokenManager monta os keyrings com as chaves configuradas por variáveis de ambiente, que valem até a
+// primeira sincronização com o banco. Os refresh tokens usam HS256, pois só este serviço os verifica.
+func provideTokenManager(cfg *config.Config) (*auth.TokenManager, error) {
+	accessSigner := auth.NewHMACSigner(cfg.Auth.AccessTokenSecret)
+	if cfg.Auth.JWTAlgorithm != jwt.SigningMethodHS256.Alg() {
+		if cfg.Auth.JWTPrivateKeyFile == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE é obrigatória com JWT_ALGORITHM=%s", cfg.Auth.JWTAlgorithm)
+		}
+		key, err := auth.LoadPrivateKey(cfg.Auth.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if accessSigner, err = auth.NewSigner(key); err != nil {
+			return nil, err
+		}
+		if accessSigner.Algorithm() != cfg.Auth.JWTAlgorithm {
+			return nil, fmt.Errorf("a chave em JWT_PRIVATE_KEY_FILE usa %s, mas JWT_ALGORITHM é %s", accessSigner.Algorithm(), cfg.Auth.JWTAlgorithm)
+		}
+	}
+
+	accessKeys := auth.NewKeyring(accessSigner)
+	refreshKeys := auth.NewKeyring(auth.NewHMACSigner(cfg.Auth.RefreshTokenSecret))
+
+	// Tokens sem kid, emitidos antes do keyring com os segredos HS256, valem até expirar. A sincronização com o
+	// banco encurta o prazo para o TTL contado da primeira chave registrada.
+	now := time.Now()
+	if err := accessKeys.SetLegacy(auth.NewHMACSigner(cfg.Auth.AccessTokenSecret), now.Add(cfg.Auth.AccessTokenTTL)); err != nil {
+		return nil, err
+	}
+	if err := refreshKeys.SetLegacy(auth.NewHMACSigner(cfg.Auth.RefreshTokenSecret), now.Add(cfg.Auth.RefreshTokenTTL)); err != nil {
+		return nil, err
+	}
+
+	return auth.NewTokenManager(accessKeys, refreshKeys, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL), nil
 }
 
 func provideTokenBlacklist(redis2 *redis.Client) *services.TokenBlacklist {
 	return services.NewTokenBlacklist(redis2)
 }
 
+func provideRefreshTokenFamilyStore(redis2 *redis.Client, cfg *config.Config) *services.RefreshTokenFamilyStore {
+	return services.NewRefreshTokenFamilyStore(redis2, cfg.Auth.RefreshTokenTTL)
+}
+
+func provideAccessTokenRevocations(blacklist *services.TokenBlacklist, cfg *config.Config) *services.AccessTokenRevocations {
+	return services.NewAccessTokenRevocations(blacklist, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL, cfg.Auth.RevocationCacheTTL)
+}
+
+func provideOneTimeTokenStore(redis2 *redis.Client) *services.OneTimeTokenStore {
+	return services.NewOneTimeTokenStore(redis2)
+}
+
+func provideDataExportStore(redis2 *redis.Client) *services.DataExportStore {
+	return services.NewDataExportStore(redis2)
+}
+
+func provideEmailChangeStore(redis2 *redis.Client) *services.EmailChangeStore {
+	return services.NewEmailChangeStore(redis2)
+}
+
+func provideRateLimitStore(redis2 *redis.Client) *services.RateLimitStore {
+	return services.NewRateLimitStore(redis2)
+}
+
+func provideMFAChallengeStore(redis2 *redis.Client, cfg *config.Config) *services.MFAChallengeStore {
+	return services.NewMFAChallengeStore(redis2, cfg.MFA.ChallengeTTL, cfg.MFA.MaxAttempts)
+}
+
+func provideWebAuthnSessionStore(redis2 *redis.Client, cfg *config.Config) *services.WebAuthnSessionStore {
+	return services.NewWebAuthnSessionStore(redis2, cfg.WebAuthn.CeremonyTTL)
+}
+
+func provideOIDCStateStore(redis2 *redis.Client, cfg *config.Config) *services.OIDCStateStore {
+	return services.NewOIDCStateStore(redis2, cfg.OIDC.StateTTL)
+}
+
+func provideOAuthCodeStore(redis2 *redis.Client, cfg *config.Config) *services.OAuthCodeStore {
+	return services.NewOAuthCodeStore(redis2, cfg.OAuth.CodeTTL)
+}
+
+// provideIDTokenKeys carrega a chave que assina os ID tokens. Sem OAUTH_ID_TOKEN_KEY_FILE é usado o keyring
+// assimétrico dos access tokens ou, no modo HS256, uma chave temporária adequada apenas para desenvolvimento.
+func provideIDTokenKeys(cfg *config.Config, tokenManager *auth.TokenManager, log *logger.Logger) (*auth.Keyring, error) {
+	if cfg.OAuth.IDTokenKeyFile != "" {
+		key, err := auth.LoadPrivateKey(cfg.OAuth.IDTokenKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := auth.NewSigner(key)
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewKeyring(signer), nil
+	}
+	if cfg.Auth.JWTAlgorithm != jwt.SigningMethodHS256.Alg() {
+		return tokenManager.Keys(auth.TokenTypeAccess), nil
+	}
+
+	log.Warn("OAUTH_ID_TOKEN_KEY_FILE não definida; usando chave temporária para os ID tokens")
+	signer, err := auth.GenerateSigner(jwt.SigningMethodRS256.Alg())
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewKeyring(signer), nil
+}
+
+// provideSigningKeyService carrega as chaves do banco nos keyrings antes que o primeiro token seja emitido
+func provideSigningKeyService(
+	repo repository.SigningKeyRepository,
+	tokenManager *auth.TokenManager,
+	redis2 *redis.Client,
+	cfg *config.Config,
+	log *logger.Logger,
+) (service.SigningKeyService, error) {
+	encryptor, err := auth.NewEncryptor(cfg.Auth.KeyEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKeys := services.NewSigningKeyService(repo, tokenManager, encryptor, redis2, cfg, log)
+	if err := signingKeys.Sync(context.Background()); err != nil {
+		return nil, err
+	}
+	return signingKeys, nil
+}
+
+func provideEncryptor(cfg *config.Config) (*auth.Encryptor, error) {
+	return auth.NewEncryptor(cfg.MFA.EncryptionKey)
+}
+
 func provideAuthService(
 	userRepo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	webAuthnRepo repository.WebAuthnRepository,
+	sessionRepo repository.SessionRepository,
+	roleRepo repository.RoleRepository,
+	orgRepo repository.OrganizationRepository,
+	mfaChallenges *services.MFAChallengeStore,
 	tokenManager *auth.TokenManager,
 	tokenBlacklist *services.TokenBlacklist,
+	revocations *services.AccessTokenRevocations,
+	families *services.RefreshTokenFamilyStore,
+	securityEvents *services.SecurityEvents,
+	oneTimeTokens *services.OneTimeTokenStore,
+	rateLimits *services.RateLimitStore,
+	loginThrottle *services.LoginThrottle,
+	emailChanges *services.EmailChangeStore,
+	mailer2 mailer.Mailer,
 	cfg *config.Config,
+	log *logger.Logger,
 ) service.AuthService {
-	return services.NewAuthService(userRepo, tokenManager, tokenBlacklist, cfg)
+	return services.NewAuthService(userRepo, mfaRepo, webAuthnRepo, sessionRepo, roleRepo, orgRepo, mfaChallenges, tokenManager, tokenBlacklist, revocations, families, securityEvents, oneTimeTokens, rateLimits, loginThrottle, emailChanges, mailer2, cfg, log)
+}
+
+func provideTokenCookies(cfg *config.Config) *handlers.TokenCookies {
+	return handlers.NewTokenCookies(cfg.Auth.CookieMode, cfg.Auth.CookieSameSite, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+}
+
+func provideMFAService(
+	userRepo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	authService service.AuthService,
+	mfaChallenges *services.MFAChallengeStore,
+	mfaThrottle *services.MFAThrottle,
+	encryptor *auth.Encryptor,
+	cfg *config.Config,
+) service.MFAService {
+	return services.NewMFAService(userRepo, mfaRepo, authService, mfaChallenges, mfaThrottle, encryptor, cfg)
 }
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey é uma chave de API (token de acesso pessoal) criada pelo usuário para integrações. Apenas o hash
+// do segredo é armazenado; o prefixo, público, localiza a chave quando ela é apresentada.
+type APIKey struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"index;not null"`
+	User       User       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix" gorm:"uniqueIndex;not null"`
+	SecretHash string     `json:"-" gorm:"not null"`
+	Scopes     string     `json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList retorna os escopos concedidos à chave
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// IsExpired informa se a chave tem validade e ela já passou
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+// UserIdentity vincula um usuário a uma conta em um provedor OIDC externo, identificada por (provider, subject)
+type UserIdentity struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"-" gorm:"index;not null"`
+	User        User       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Provider    string     `json:"provider" gorm:"uniqueIndex:uq_user_identities_provider_subject;not null"`
+	Subject     string     `json:"-" gorm:"uniqueIndex:uq_user_identities_provider_subject;not null"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
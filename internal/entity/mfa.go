@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+)
+
+// TOTPFactor representa o fator TOTP de um usuário. O segredo é armazenado cifrado.
+type TOTPFactor struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"user_id" gorm:"uniqueIndex;not null"`
+	User            User       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	SecretEncrypted string     `json:"-" gorm:"not null"`
+	ConfirmedAt     *time.Time `json:"confirmed_at"`
+	LastUsedStep    int64      `json:"-" gorm:"not null;default:0"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (TOTPFactor) TableName() string {
+	return "user_totp_factors"
+}
+
+// IsConfirmed indica se o usuário concluiu o cadastro do fator
+func (f *TOTPFactor) IsConfirmed() bool {
+	return f.ConfirmedAt != nil
+}
+
+// RecoveryCode é um código de recuperação de uso único, armazenado apenas como hash
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index;not null"`
+	CodeHash  string     `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
@@ -0,0 +1,103 @@
+package entity
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OAuthClientType string
+
+const (
+	// OAuthClientConfidential autentica-se com client_secret (backends)
+	OAuthClientConfidential OAuthClientType = "confidential"
+	// OAuthClientPublic não guarda segredo (SPAs, apps nativos) e depende exclusivamente de PKCE
+	OAuthClientPublic OAuthClientType = "public"
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+)
+
+// OAuthClient é uma aplicação registrada no servidor de autorização.
+// RedirectURIs, PostLogoutRedirectURIs, Scopes e GrantTypes são listas separadas por espaço.
+type OAuthClient struct {
+	ID                     string          `json:"client_id" gorm:"primaryKey"`
+	Name                   string          `json:"name" gorm:"not null"`
+	Type                   OAuthClientType `json:"type" gorm:"not null"`
+	SecretHash             string          `json:"-"`
+	RedirectURIs           string          `json:"-"`
+	PostLogoutRedirectURIs string          `json:"-"`
+	Scopes                 string          `json:"-"`
+	GrantTypes             string          `json:"-"`
+	CreatedAt              time.Time       `json:"created_at"`
+	UpdatedAt              time.Time       `json:"updated_at"`
+	DeletedAt              gorm.DeletedAt  `json:"-" gorm:"index"`
+}
+
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+func (c *OAuthClient) IsConfidential() bool {
+	return c.Type == OAuthClientConfidential
+}
+
+// ScopeList retorna os escopos que o cliente pode solicitar
+func (c *OAuthClient) ScopeList() []string {
+	return strings.Fields(c.Scopes)
+}
+
+// AllowsGrant informa se o cliente pode usar o grant_type
+func (c *OAuthClient) AllowsGrant(grantType string) bool {
+	for _, g := range strings.Fields(c.GrantTypes) {
+		if g == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsRedirectURI compara o redirect_uri com os registrados por igualdade exata. Para redirecionamentos
+// de loopback (apps nativos) a porta pode variar, conforme o OAuth 2.1.
+func (c *OAuthClient) AllowsRedirectURI(redirectURI string) bool {
+	requested, err := url.Parse(redirectURI)
+	if err != nil || requested.Fragment != "" {
+		return false
+	}
+
+	for _, registered := range strings.Fields(c.RedirectURIs) {
+		if registered == redirectURI {
+			return true
+		}
+
+		allowed, err := url.Parse(registered)
+		if err != nil || allowed.Scheme != "http" || !isLoopback(allowed.Hostname()) {
+			continue
+		}
+		if requested.Scheme == allowed.Scheme && requested.Hostname() == allowed.Hostname() &&
+			requested.Path == allowed.Path && requested.RawQuery == allowed.RawQuery {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsPostLogoutRedirectURI compara o destino após o logout iniciado pelo cliente (OIDC RP-Initiated Logout)
+// com os registrados por igualdade exata
+func (c *OAuthClient) AllowsPostLogoutRedirectURI(redirectURI string) bool {
+	for _, registered := range strings.Fields(c.PostLogoutRedirectURIs) {
+		if registered == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+func isLoopback(host string) bool {
+	return host == "127.0.0.1" || host == "::1"
+}
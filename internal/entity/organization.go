@@ -0,0 +1,75 @@
+package entity
+
+import "time"
+
+// Papéis dos membros dentro de uma organização; independentes dos papéis globais (Role)
+const (
+	OrganizationRoleOwner  = "owner"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleMember = "member"
+)
+
+// Organization é um tenant: um espaço de trabalho de cliente. Os usuários são globais e participam das
+// organizações pelas memberships.
+type Organization struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// Membership é a participação de um usuário em uma organização, com o papel dele nela
+type Membership struct {
+	OrganizationID uint         `json:"organization_id" gorm:"primaryKey"`
+	UserID         uint         `json:"user_id" gorm:"primaryKey"`
+	Organization   Organization `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	User           User         `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Role           string       `json:"role" gorm:"not null"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+func (Membership) TableName() string {
+	return "organization_memberships"
+}
+
+// CanManageMembers indica se o papel permite alterar e remover membros
+func (m *Membership) CanManageMembers() bool {
+	return m.Role == OrganizationRoleOwner || m.Role == OrganizationRoleAdmin
+}
+
+// IsValidOrganizationRole informa se role é um dos papéis de organização
+func IsValidOrganizationRole(role string) bool {
+	switch role {
+	case OrganizationRoleOwner, OrganizationRoleAdmin, OrganizationRoleMember:
+		return true
+	}
+	return false
+}
+
+// Invitation é um convite, enviado por email, para participar de uma organização. Apenas o hash do token do
+// link é armazenado; o convite vale até ExpiresAt e pode ser aceito uma única vez.
+type Invitation struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	OrganizationID uint         `json:"organization_id" gorm:"index;not null"`
+	Organization   Organization `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Email          string       `json:"email" gorm:"not null"`
+	Role           string       `json:"role" gorm:"not null"`
+	TokenHash      string       `json:"-" gorm:"uniqueIndex;not null"`
+	InvitedBy      *uint        `json:"invited_by"`
+	ExpiresAt      time.Time    `json:"expires_at"`
+	AcceptedAt     *time.Time   `json:"-"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+func (Invitation) TableName() string {
+	return "organization_invitations"
+}
+
+// IsPending informa se o convite ainda pode ser aceito
+func (i *Invitation) IsPending(now time.Time) bool {
+	return i.AcceptedAt == nil && now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,62 @@
+package entity
+
+import "time"
+
+// Papéis criados pelas migrations
+const (
+	RoleAdmin = "admin"
+	// RoleUser é atribuído a todo usuário no cadastro
+	RoleUser = "user"
+)
+
+// Permissões criadas pelas migrations, verificadas com AuthHandler.RequirePermission
+const (
+	PermissionUsersRead  = "users:read"
+	PermissionUsersWrite = "users:write"
+	PermissionRolesRead  = "roles:read"
+	PermissionRolesWrite = "roles:write"
+)
+
+// Role agrupa permissões atribuídas aos usuários. Os papéis e as permissões efetivas do usuário são
+// copiados para o access token nas claims roles e permissions.
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"uniqueIndex;not null"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+// PermissionNames retorna os nomes das permissões do papel
+func (r *Role) PermissionNames() []string {
+	names := make([]string, 0, len(r.Permissions))
+	for _, permission := range r.Permissions {
+		names = append(names, permission.Name)
+	}
+	return names
+}
+
+type Permission struct {
+	ID          uint   `json:"-" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"uniqueIndex;not null"`
+	Description string `json:"description"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// UserRole é a atribuição de um papel a um usuário
+type UserRole struct {
+	UserID    uint `gorm:"primaryKey"`
+	RoleID    uint `gorm:"primaryKey"`
+	CreatedAt time.Time
+}
+
+func (UserRole) TableName() string {
+	return "user_roles"
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// Session registra um login de primeira parte. Os tokens emitidos no login e nas renovações carregam
+// o ID da sessão na claim sid; uma sessão revogada não renova mais seus tokens. OrganizationID é a organização
+// selecionada na sessão, enviada na claim tenant_id.
+type Session struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"-" gorm:"index;not null"`
+	User           User       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	IPAddress      string     `json:"ip_address"`
+	UserAgent      string     `json:"user_agent"`
+	DeviceName     string     `json:"device_name"`
+	OrganizationID *uint      `json:"organization_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     time.Time  `json:"last_used_at"`
+	RevokedAt      *time.Time `json:"-"`
+}
+
+func (Session) TableName() string {
+	return "user_sessions"
+}
+
+// IsRevoked informa se a sessão foi encerrada (logout, revogação pelo usuário ou troca de senha)
+func (s *Session) IsRevoked() bool {
+	return s.RevokedAt != nil
+}
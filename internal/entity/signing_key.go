@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+type SigningKeyUse string
+
+const (
+	SigningKeyAccess  SigningKeyUse = "access"
+	SigningKeyRefresh SigningKeyUse = "refresh"
+)
+
+// SigningKey é uma chave do keyring de um tipo de token, identificada pelo kid. A chave mais recente com
+// ActivatesAt no passado assina os novos tokens; as anteriores apenas verificam os tokens em circulação
+// até serem aposentadas. PrivateKey é cifrada (segredo HMAC ou PEM PKCS#8).
+type SigningKey struct {
+	ID          string        `json:"kid" gorm:"primaryKey"`
+	Use         SigningKeyUse `json:"use" gorm:"not null"`
+	Algorithm   string        `json:"alg" gorm:"not null"`
+	PrivateKey  string        `json:"-" gorm:"not null"`
+	ActivatesAt time.Time     `json:"activates_at" gorm:"not null"`
+	RetiredAt   *time.Time    `json:"retired_at,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
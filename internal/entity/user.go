@@ -1,19 +1,84 @@
 package entity
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Situações de uma conta, derivadas de DisabledAt e DeletedAt
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+	UserStatusDeleted  = "deleted"
+)
+
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	Email           string     `json:"email" gorm:"uniqueIndex;not null"`
+	Password        string     `json:"-" gorm:"not null"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	DisabledAt      *time.Time `json:"disabled_at"`
+	PurgeAt         *time.Time `json:"purge_at"`
+	DisplayName     string     `json:"display_name"`
+	AvatarURL       string     `json:"avatar_url"`
+	Locale          string     `json:"locale"`
+	Timezone        string     `json:"timezone"`
+	// Metadata é editado pelo próprio usuário; AdminMetadata apenas pela administração e não é exibido a ele
+	Metadata      Metadata       `json:"metadata" gorm:"type:jsonb;not null;default:'{}'"`
+	AdminMetadata Metadata       `json:"-" gorm:"type:jsonb;not null;default:'{}'"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// Metadata é um objeto JSON livre, gravado em coluna JSONB
+type Metadata map[string]interface{}
+
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (m *Metadata) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = Metadata{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	}
+	return fmt.Errorf("tipo incompatível com metadata: %T", value)
+}
+
+// Merge aplica patch no estilo JSON Merge Patch (RFC 7396) no primeiro nível: chaves com valor null são
+// removidas e as demais substituem as atuais
+func (m Metadata) Merge(patch map[string]interface{}) Metadata {
+	merged := Metadata{}
+	for key, value := range m {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
 }
 
 func NewUser(email, password string) (*User, error) {
@@ -32,3 +97,47 @@ func (u *User) CheckPassword(password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
 	return err == nil
 }
+
+// IsEmailVerified indica se o endereço de email do usuário já foi confirmado
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsDisabled indica se a conta foi desativada por um administrador
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// IsPendingDeletion indica se o próprio usuário excluiu a conta e ela aguarda a remoção definitiva
+func (u *User) IsPendingDeletion() bool {
+	return u.DeletedAt.Valid && u.PurgeAt != nil
+}
+
+// Version identifica o estado atual da conta, para a concorrência otimista (ETag) das alterações do perfil
+func (u *User) Version() string {
+	return strconv.FormatInt(u.UpdatedAt.UnixMicro(), 36)
+}
+
+// Status retorna a situação da conta: excluída, desativada ou ativa
+func (u *User) Status() string {
+	switch {
+	case u.DeletedAt.Valid:
+		return UserStatusDeleted
+	case u.IsDisabled():
+		return UserStatusDisabled
+	}
+	return UserStatusActive
+}
+
+// AccountErasure é o registro anonimizado de uma conta removida definitivamente: guarda apenas o antigo ID e
+// as datas do pedido e da remoção
+type AccountErasure struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id"`
+	RequestedAt time.Time `json:"requested_at"`
+	PurgedAt    time.Time `json:"purged_at"`
+}
+
+func (AccountErasure) TableName() string {
+	return "account_erasures"
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// WebAuthnCredential representa uma passkey/chave de segurança registrada por um usuário
+type WebAuthnCredential struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	UserID          uint       `json:"-" gorm:"index;not null"`
+	User            User       `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Name            string     `json:"name"`
+	CredentialID    []byte     `json:"-" gorm:"uniqueIndex;not null"`
+	PublicKey       []byte     `json:"-" gorm:"not null"`
+	AttestationType string     `json:"-"`
+	Transports      string     `json:"-"`
+	AAGUID          []byte     `json:"-" gorm:"column:aaguid"`
+	SignCount       uint32     `json:"-" gorm:"not null;default:0"`
+	BackupEligible  bool       `json:"backup_eligible"`
+	BackupState     bool       `json:"backup_state"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "user_webauthn_credentials"
+}
+
+// TransportList retorna os transportes suportados pelo autenticador (usb, nfc, ble, internal, hybrid)
+func (c *WebAuthnCredential) TransportList() []string {
+	if c.Transports == "" {
+		return nil
+	}
+	return strings.Split(c.Transports, ",")
+}
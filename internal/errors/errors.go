@@ -2,12 +2,15 @@ package apperrors
 
 import (
 	"fmt"
+	"time"
 )
 
 type AppError struct {
 	Message string
 	Code    int
 	Err     error
+	// RetryAfter, quando positivo, é enviado no header Retry-After
+	RetryAfter time.Duration
 }
 
 func (e *AppError) Error() string {
@@ -60,6 +63,20 @@ func NewConflictError(message string) *AppError {
 	}
 }
 
+func NewPreconditionFailedError(message string) *AppError {
+	return &AppError{
+		Message: message,
+		Code:    412,
+	}
+}
+
+func NewPreconditionRequiredError(message string) *AppError {
+	return &AppError{
+		Message: message,
+		Code:    428,
+	}
+}
+
 func NewInternalError(err error) *AppError {
 	return &AppError{
 		Message: "erro interno do servidor",
@@ -74,3 +91,17 @@ func NewRateLimitError(message string) *AppError {
 		Code:    429,
 	}
 }
+
+func NewLockedError(message string, retryAfter time.Duration) *AppError {
+	return &AppError{
+		Message:    message,
+		Code:       423,
+		RetryAfter: retryAfter,
+	}
+}
+
+// WithRetryAfter informa ao cliente quando a operação pode ser repetida
+func (e *AppError) WithRetryAfter(retryAfter time.Duration) *AppError {
+	e.RetryAfter = retryAfter
+	return e
+}
@@ -0,0 +1,48 @@
+package apperrors
+
+import "net/http"
+
+// Códigos de erro do OAuth 2.1 (RFC 6749, seções 4.1.2.1 e 5.2, e RFC 6750, seção 3.1)
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthAccessDenied            = "access_denied"
+	OAuthInvalidToken            = "invalid_token"
+	OAuthInsufficientScope       = "insufficient_scope"
+)
+
+// OAuthError é um erro dos endpoints OAuth, respondido no formato {"error", "error_description"}
+// em vez do formato padrão da API
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// StatusCode retorna 401 para falhas de autenticação do cliente ou do token, 403 para escopo
+// insuficiente e 400 para os demais erros
+func (e *OAuthError) StatusCode() int {
+	switch e.Code {
+	case OAuthInvalidClient, OAuthInvalidToken:
+		return http.StatusUnauthorized
+	case OAuthInsufficientScope:
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func NewOAuthError(code, description string) *OAuthError {
+	return &OAuthError{
+		Code:        code,
+		Description: description,
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+	log           *logger.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService, log *logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		log:           log,
+	}
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type apiKeyResponse struct {
+	*entity.APIKey
+	Scopes []string `json:"scopes"`
+}
+
+// createdAPIKeyResponse traz a chave completa, exibida apenas nesta resposta
+type createdAPIKeyResponse struct {
+	apiKeyResponse
+	Key string `json:"key"`
+}
+
+func newAPIKeyResponse(key *entity.APIKey) apiKeyResponse {
+	return apiKeyResponse{
+		APIKey: key,
+		Scopes: key.ScopeList(),
+	}
+}
+
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req createAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	created, err := h.apiKeyService.CreateKey(r.Context(), userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		h.log.Error("Erro ao criar chave de API: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, h.log, http.StatusCreated, createdAPIKeyResponse{
+		apiKeyResponse: newAPIKeyResponse(created.APIKey),
+		Key:            created.Key,
+	})
+}
+
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	keys, err := h.apiKeyService.ListKeys(r.Context(), userID)
+	if err != nil {
+		h.log.Error("Erro ao listar chaves de API: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	resp := make([]apiKeyResponse, 0, len(keys))
+	for i := range keys {
+		resp = append(resp, newAPIKeyResponse(&keys[i]))
+	}
+
+	writeJSON(w, h.log, http.StatusOK, resp)
+}
+
+func (h *APIKeyHandler) DeleteKey(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	if err := h.apiKeyService.DeleteKey(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao remover chave de API: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,23 +2,34 @@ package handlers
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+
+	"auth-template/internal/entity"
 	apperrors "auth-template/internal/errors"
 	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
 	"auth-template/pkg/logger"
 )
 
 type AuthHandler struct {
-	authService service.AuthService
-	log         *logger.Logger
+	authService   service.AuthService
+	apiKeyService service.APIKeyService
+	cookies       *TokenCookies
+	log           *logger.Logger
 }
 
-func NewAuthHandler(authService service.AuthService, log *logger.Logger) *AuthHandler {
+func NewAuthHandler(authService service.AuthService, apiKeyService service.APIKeyService, cookies *TokenCookies, log *logger.Logger) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		log:         log,
+		authService:   authService,
+		apiKeyService: apiKeyService,
+		cookies:       cookies,
+		log:           log,
 	}
 }
 
@@ -36,14 +47,114 @@ type refreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+type switchOrganizationRequest struct {
+	RefreshToken   string `json:"refresh_token"`
+	OrganizationID string `json:"organization_id"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
+
+type resendVerificationRequest struct {
+	Email string `json:"email"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type magicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+type magicLinkResponse struct {
+	Nonce string `json:"nonce"`
+}
+
+type consumeMagicLinkRequest struct {
+	Token string `json:"token"`
+	Nonce string `json:"nonce"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type changeEmailRequest struct {
+	Password string `json:"password"`
+	NewEmail string `json:"new_email"`
+}
+
+type emailChangeTokenRequest struct {
+	Token string `json:"token"`
+}
+
+type deleteAccountRequest struct {
+	Password string `json:"password"`
+}
+
+type deleteAccountResponse struct {
+	PurgeAt time.Time `json:"purge_at"`
+}
+
 type tokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
 
+type mfaChallengeResponse struct {
+	MFARequired bool     `json:"mfa_required"`
+	MFAToken    string   `json:"mfa_token"`
+	MFAMethods  []string `json:"mfa_methods"`
+}
+
+type sessionResponse struct {
+	entity.Session
+	Current bool `json:"current"`
+}
+
 type userResponse struct {
-	ID    uint   `json:"id"`
-	Email string `json:"email"`
+	ID          uint            `json:"id"`
+	Email       string          `json:"email"`
+	DisplayName string          `json:"display_name"`
+	AvatarURL   string          `json:"avatar_url"`
+	Locale      string          `json:"locale"`
+	Timezone    string          `json:"timezone"`
+	Metadata    entity.Metadata `json:"metadata"`
+}
+
+// updateProfileRequest usa ponteiros para distinguir campo ausente (mantido) de campo vazio (removido)
+type updateProfileRequest struct {
+	DisplayName *string                `json:"display_name"`
+	AvatarURL   *string                `json:"avatar_url"`
+	Locale      *string                `json:"locale"`
+	Timezone    *string                `json:"timezone"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+func newUserResponse(user *entity.User) userResponse {
+	return userResponse{
+		ID:          user.ID,
+		Email:       user.Email,
+		DisplayName: user.DisplayName,
+		AvatarURL:   user.AvatarURL,
+		Locale:      user.Locale,
+		Timezone:    user.Timezone,
+		Metadata:    user.Metadata,
+	}
+}
+
+// writeProfile responde com o perfil e a versão atual no ETag, usada no If-Match da próxima alteração
+func (h *AuthHandler) writeProfile(w http.ResponseWriter, user *entity.User) {
+	w.Header().Set("ETag", `"`+user.Version()+`"`)
+	h.writeJSON(w, http.StatusOK, newUserResponse(user))
 }
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
@@ -56,7 +167,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.authService.Register(r.Context(), req.Email, req.Password)
+	_, err := h.authService.Register(r.Context(), req.Email, req.Password)
 	if err != nil {
 		h.log.Error("Erro no registro: %v", err)
 		h.writeError(w, err)
@@ -76,58 +187,159 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tokens, err := h.authService.Login(r.Context(), req.Email, req.Password)
+	result, err := h.authService.Login(r.Context(), req.Email, req.Password)
 	if err != nil {
 		h.log.Error("Erro no login: %v", err)
 		h.writeError(w, err)
 		return
 	}
 
-	resp := tokenResponse{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
+	h.cookies.writeLoginResult(w, r, h.log, result)
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	refreshToken, err := h.decodeRefreshToken(r)
+	if err != nil {
+		h.writeError(w, err)
+		return
 	}
 
-	h.writeJSON(w, http.StatusOK, resp)
+	tokens, err := h.authService.RefreshTokens(r.Context(), refreshToken)
+	if err != nil {
+		h.log.Error("Erro no refresh: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.cookies.writeTokens(w, r, h.log, tokens)
 }
 
-func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+// SwitchOrganization renova os tokens da sessão, como /auth/refresh, com outra organização selecionada
+func (h *AuthHandler) SwitchOrganization(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
 
-	var req refreshRequest
+	var req switchOrganizationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.log.Error("Erro ao decodificar requisição: %v", err)
 		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
 		return
 	}
-
-	tokens, err := h.authService.RefreshTokens(r.Context(), req.RefreshToken)
+	refreshToken, err := h.cookies.refreshToken(r, req.RefreshToken)
 	if err != nil {
-		h.log.Error("Erro no refresh: %v", err)
 		h.writeError(w, err)
 		return
 	}
 
-	resp := tokenResponse{
-		AccessToken:  tokens.AccessToken,
-		RefreshToken: tokens.RefreshToken,
+	tokens, err := h.authService.SwitchOrganization(r.Context(), refreshToken, req.OrganizationID)
+	if err != nil {
+		h.log.Error("Erro ao trocar de organização: %v", err)
+		h.writeError(w, err)
+		return
 	}
 
-	h.writeJSON(w, http.StatusOK, resp)
+	h.cookies.writeTokens(w, r, h.log, tokens)
 }
 
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
 
-	var req refreshRequest
+	refreshToken, err := h.decodeRefreshToken(r)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	// O access token é opcional; quando enviado, é revogado junto com a sessão
+	accessToken, _ := h.cookies.accessToken(r)
+	if err := h.authService.Logout(r.Context(), refreshToken, accessToken); err != nil {
+		h.log.Error("Erro no logout: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.cookies.clear(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		h.writeError(w, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	user, err := h.authService.GetUser(r.Context(), userID)
+	if err != nil {
+		h.log.Error("Erro ao obter usuário: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeProfile(w, user)
+}
+
+// UpdateProfile altera o perfil do usuário autenticado. O header If-Match com o ETag obtido em GET /auth/me é
+// obrigatório, para que alterações simultâneas de dois dispositivos não se sobrescrevam.
+func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		h.writeError(w, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" {
+		h.writeError(w, apperrors.NewPreconditionRequiredError("header If-Match obrigatório"))
+		return
+	}
+	expectedVersion := ""
+	if ifMatch != "*" {
+		expectedVersion = strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+	}
+
+	var req updateProfileRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	user, err := h.authService.UpdateProfile(r.Context(), userID, expectedVersion, service.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		AvatarURL:   req.AvatarURL,
+		Locale:      req.Locale,
+		Timezone:    req.Timezone,
+		Metadata:    req.Metadata,
+	})
+	if err != nil {
+		h.log.Error("Erro ao atualizar perfil: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeProfile(w, user)
+}
+
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req verifyEmailRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.log.Error("Erro ao decodificar requisição: %v", err)
 		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
 		return
 	}
 
-	if err := h.authService.Logout(r.Context(), req.RefreshToken); err != nil {
-		h.log.Error("Erro no logout: %v", err)
+	if err := h.authService.VerifyEmail(r.Context(), req.Token); err != nil {
+		h.log.Error("Erro na verificação de email: %v", err)
 		h.writeError(w, err)
 		return
 	}
@@ -135,70 +347,401 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+func (h *AuthHandler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req resendVerificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	if err := h.authService.ResendVerificationEmail(r.Context(), req.Email); err != nil {
+		h.log.Error("Erro ao reenviar email de verificação: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req forgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	if err := h.authService.ForgotPassword(r.Context(), req.Email); err != nil {
+		h.log.Error("Erro na recuperação de senha: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
 
-	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
-	if token == "" {
+	var req resetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	if err := h.authService.ResetPassword(r.Context(), req.Token, req.NewPassword); err != nil {
+		h.log.Error("Erro na redefinição de senha: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req magicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	nonce, err := h.authService.RequestMagicLink(r.Context(), req.Email)
+	if err != nil {
+		h.log.Error("Erro na solicitação de magic link: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.writeJSON(w, http.StatusAccepted, magicLinkResponse{Nonce: nonce})
+}
+
+func (h *AuthHandler) ConsumeMagicLink(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req consumeMagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	result, err := h.authService.ConsumeMagicLink(r.Context(), req.Token, req.Nonce)
+	if err != nil {
+		h.log.Error("Erro no login por magic link: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.cookies.writeLoginResult(w, r, h.log, result)
+}
+
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	claims, ok := GetClaims(r.Context())
+	if !ok {
+		h.writeError(w, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req changePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	tokens, err := h.authService.ChangePassword(r.Context(), claims, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		h.log.Error("Erro na troca de senha: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.cookies.writeTokens(w, r, h.log, tokens)
+}
+
+func (h *AuthHandler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	claims, ok := GetClaims(r.Context())
+	if !ok {
+		h.writeError(w, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req changeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	if err := h.authService.RequestEmailChange(r.Context(), claims, req.Password, req.NewEmail); err != nil {
+		h.log.Error("Erro no pedido de troca de email: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ConfirmEmailChange conclui a troca com o token enviado ao novo endereço e retorna um novo par de tokens,
+// pois as demais sessões são encerradas
+func (h *AuthHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	claims, ok := GetClaims(r.Context())
+	if !ok {
 		h.writeError(w, apperrors.NewUnauthorizedError("token não fornecido"))
 		return
 	}
 
-	user, err := h.authService.GetUserFromToken(r.Context(), token)
+	var req emailChangeTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	tokens, err := h.authService.ConfirmEmailChange(r.Context(), claims, req.Token)
 	if err != nil {
-		h.log.Error("Erro ao obter usuário do token: %v", err)
+		h.log.Error("Erro na confirmação da troca de email: %v", err)
 		h.writeError(w, err)
 		return
 	}
 
-	resp := userResponse{
-		ID:    user.ID,
-		Email: user.Email,
+	h.cookies.writeTokens(w, r, h.log, tokens)
+}
+
+// CancelEmailChange descarta a troca pendente com o token enviado ao endereço atual; não exige autenticação
+func (h *AuthHandler) CancelEmailChange(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req emailChangeTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	if err := h.authService.CancelEmailChange(r.Context(), req.Token); err != nil {
+		h.log.Error("Erro no cancelamento da troca de email: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteAccount exclui a conta do usuário autenticado. A remoção definitiva ocorre em purge_at; até lá, o
+// login com a senha restaura a conta.
+func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	claims, ok := GetClaims(r.Context())
+	if !ok {
+		h.writeError(w, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	// O corpo é opcional: sem a senha, vale um login recente
+	var req deleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		h.writeError(w, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	purgeAt, err := h.authService.DeleteAccount(r.Context(), claims, req.Password)
+	if err != nil {
+		h.log.Error("Erro na exclusão da conta: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	h.cookies.clear(w)
+	writeJSON(w, h.log, http.StatusOK, deleteAccountResponse{PurgeAt: purgeAt})
+}
+
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		h.writeError(w, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	sessions, err := h.authService.ListSessions(r.Context(), userID)
+	if err != nil {
+		h.log.Error("Erro ao listar sessões: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	currentSessionID := currentSession(r)
+	resp := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp = append(resp, sessionResponse{
+			Session: session,
+			Current: session.ID == currentSessionID,
+		})
 	}
 
 	h.writeJSON(w, http.StatusOK, resp)
 }
 
-func (h *AuthHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		h.log.Error("Erro ao codificar resposta: %v", err)
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		h.writeError(w, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
 	}
+
+	if err := h.authService.RevokeSession(r.Context(), userID, chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao encerrar sessão: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *AuthHandler) writeError(w http.ResponseWriter, err error) {
-	var status int
-	var message string
+func (h *AuthHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
 
-	switch e := err.(type) {
-	case *apperrors.AppError:
-		status = e.StatusCode()
-		message = e.Error()
-	default:
-		status = http.StatusInternalServerError
-		message = "erro interno do servidor"
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		h.writeError(w, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
 	}
 
-	h.writeJSON(w, status, map[string]interface{}{
-		"error": message,
-		"code":  status,
-	})
+	if err := h.authService.RevokeOtherSessions(r.Context(), userID, currentSession(r)); err != nil {
+		h.log.Error("Erro ao encerrar sessões: %v", err)
+		h.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
+// currentSession retorna a sessão do access token da requisição; vazio para tokens anteriores às sessões
+func currentSession(r *http.Request) string {
+	claims, ok := GetClaims(r.Context())
+	if !ok {
+		return ""
+	}
+	return claims.SessionID
+}
+
+func (h *AuthHandler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeJSON(w, h.log, status, data)
+}
+
+func (h *AuthHandler) writeError(w http.ResponseWriter, err error) {
+	writeError(w, h.log, err)
+}
+
+// decodeRefreshToken lê o refresh token do corpo ou, no modo cookie, do cookie da sessão. O corpo é
+// opcional para os clientes que usam cookies.
+func (h *AuthHandler) decodeRefreshToken(r *http.Request) (string, error) {
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		return "", apperrors.NewValidationError("requisição inválida")
+	}
+	return h.cookies.refreshToken(r, req.RefreshToken)
+}
+
+// AuthMiddleware autentica pelo header Authorization, com um access token ou uma chave de API, ou, no modo
+// cookie, pelo cookie do access token. Requisições autenticadas por cookie que alteram estado exigem o token CSRF.
 func (h *AuthHandler) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		token, fromCookie := h.cookies.accessToken(r)
 		if token == "" {
 			http.Error(w, "token não fornecido", http.StatusUnauthorized)
 			return
 		}
 
-		if err := h.authService.ValidateAccessToken(r.Context(), token); err != nil {
+		if !fromCookie && auth.IsAPIKey(token) {
+			claims, err := h.apiKeyService.Authenticate(r.Context(), token)
+			if err != nil {
+				h.log.Error("Erro ao autenticar chave de API: %v", err)
+				http.Error(w, "token inválido", http.StatusUnauthorized)
+				return
+			}
+			ctx := WithUserID(r.Context(), claims.UserID)
+			next.ServeHTTP(w, r.WithContext(WithClaims(ctx, claims)))
+			return
+		}
+		if fromCookie && !isSafeMethod(r.Method) {
+			if err := h.cookies.checkCSRF(r); err != nil {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+
+		claims, err := h.authService.ValidateAccessToken(r.Context(), token)
+		if err != nil {
 			http.Error(w, "token inválido", http.StatusUnauthorized)
 			return
 		}
 
+		ctx := WithUserID(r.Context(), claims.UserID)
+		next.ServeHTTP(w, r.WithContext(WithClaims(ctx, claims)))
+	})
+}
+
+// RequireScope restringe a rota às chaves de API com o escopo informado. Os access tokens de primeira parte
+// não carregam escopos e passam direto.
+func (h *AuthHandler) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaims(r.Context())
+			if ok && claims.Type == auth.TokenTypeAPIKey && !slices.Contains(strings.Fields(claims.Scope), scope) {
+				h.writeError(w, apperrors.NewForbiddenError("escopo insuficiente: "+scope))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePermission restringe a rota aos access tokens com a permissão informada na claim permissions.
+// Chaves de API e tokens de clientes OAuth não carregam permissões e são recusados.
+func (h *AuthHandler) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaims(r.Context())
+			if !ok || !slices.Contains(claims.Permissions, permission) {
+				h.writeError(w, apperrors.NewForbiddenError("permissão insuficiente: "+permission))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireUserSession recusa as chaves de API nas rotas que gerenciam a conta e as credenciais, para que uma
+// chave vazada não consiga trocar a senha, criar novas chaves ou alterar o MFA
+func (h *AuthHandler) RequireUserSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := GetClaims(r.Context()); ok && claims.Type == auth.TokenTypeAPIKey {
+			h.writeError(w, apperrors.NewForbiddenError("operação não permitida com chave de API"))
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
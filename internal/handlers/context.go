@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"context"
+
+	"auth-template/pkg/auth"
 )
 
 type contextKey string
 
-const userEmailKey contextKey = "userEmail"
+const (
+	userEmailKey contextKey = "userEmail"
+	userIDKey    contextKey = "userID"
+	claimsKey    contextKey = "claims"
+)
 
 // WithUserEmail adiciona o email do usuário ao contexto
 func WithUserEmail(ctx context.Context, email string) context.Context {
@@ -18,3 +24,34 @@ func GetUserEmail(ctx context.Context) (string, bool) {
 	email, ok := ctx.Value(userEmailKey).(string)
 	return email, ok
 }
+
+// WithUserID adiciona o ID do usuário autenticado ao contexto
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// GetUserID obtém o ID do usuário autenticado do contexto
+func GetUserID(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey).(string)
+	return userID, ok
+}
+
+// WithClaims adiciona as claims do access token da sessão ao contexto
+func WithClaims(ctx context.Context, claims *auth.Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// GetClaims obtém as claims do access token da sessão do contexto
+func GetClaims(ctx context.Context) (*auth.Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*auth.Claims)
+	return claims, ok
+}
+
+// GetTenantID obtém a organização selecionada no access token (claim tenant_id); vazio sem organização
+func GetTenantID(ctx context.Context) string {
+	claims, ok := GetClaims(ctx)
+	if !ok {
+		return ""
+	}
+	return claims.TenantID
+}
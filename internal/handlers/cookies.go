@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
+	"auth-template/pkg/logger"
+)
+
+const (
+	accessTokenCookie  = "__Host-access_token"
+	refreshTokenCookie = "__Host-refresh_token"
+	csrfTokenCookie    = "__Host-csrf_token"
+	// oidcBindingCookie prende o state do login externo ao navegador que o iniciou
+	oidcBindingCookie = "__Host-oidc_binding"
+
+	// csrfHeader deve repetir o valor do cookie csrfTokenCookie nas requisições que alteram estado
+	csrfHeader = "X-CSRF-Token"
+	// tokenDeliveryHeader com o valor "cookie" pede os tokens em cookies em vez do corpo da resposta
+	tokenDeliveryHeader = "X-Token-Delivery"
+	tokenDeliveryCookie = "cookie"
+
+	csrfTokenBytes = 32
+)
+
+type cookieSessionResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
+
+// TokenCookies implementa o modo de sessão por cookies para SPAs. Com o modo habilitado, o cliente que envia
+// X-Token-Delivery: cookie recebe os tokens em cookies __Host- HttpOnly, Secure e SameSite, fora do alcance
+// do JavaScript, e um token CSRF em um cookie legível que deve ser repetido no header X-CSRF-Token (double
+// submit). Clientes que não pedem cookies, como os aplicativos móveis, continuam recebendo os tokens no corpo.
+type TokenCookies struct {
+	enabled    bool
+	sameSite   http.SameSite
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokenCookies cria a configuração dos cookies; sameSite aceita "Strict" ou "Lax"
+func NewTokenCookies(enabled bool, sameSite string, accessTTL, refreshTTL time.Duration) *TokenCookies {
+	mode := http.SameSiteLaxMode
+	if strings.EqualFold(sameSite, "strict") {
+		mode = http.SameSiteStrictMode
+	}
+	return &TokenCookies{
+		enabled:    enabled,
+		sameSite:   mode,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// writeTokens responde com os tokens no corpo ou, se o cliente pediu, nos cookies
+func (c *TokenCookies) writeTokens(w http.ResponseWriter, r *http.Request, log *logger.Logger, tokens *service.TokenPair) {
+	if !c.enabled || r.Header.Get(tokenDeliveryHeader) != tokenDeliveryCookie {
+		writeJSON(w, log, http.StatusOK, tokenResponse{
+			AccessToken:  tokens.AccessToken,
+			RefreshToken: tokens.RefreshToken,
+		})
+		return
+	}
+
+	csrfToken, err := auth.GenerateRandomToken(csrfTokenBytes)
+	if err != nil {
+		log.Error("Erro ao gerar token CSRF: %v", err)
+		writeError(w, log, err)
+		return
+	}
+
+	c.setCookie(w, accessTokenCookie, tokens.AccessToken, c.accessTTL, true)
+	c.setCookie(w, refreshTokenCookie, tokens.RefreshToken, c.refreshTTL, true)
+	c.setCookie(w, csrfTokenCookie, csrfToken, c.refreshTTL, false)
+	w.Header().Set("Cache-Control", "no-store")
+
+	writeJSON(w, log, http.StatusOK, cookieSessionResponse{CSRFToken: csrfToken})
+}
+
+// writeLoginResult responde com os tokens ou, quando o MFA é exigido, com o desafio pendente
+func (c *TokenCookies) writeLoginResult(w http.ResponseWriter, r *http.Request, log *logger.Logger, result *service.LoginResult) {
+	if result.MFARequired {
+		writeJSON(w, log, http.StatusOK, mfaChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+			MFAMethods:  result.MFAMethods,
+		})
+		return
+	}
+
+	c.writeTokens(w, r, log, result.Tokens)
+}
+
+// clear remove os cookies da sessão (logout)
+func (c *TokenCookies) clear(w http.ResponseWriter) {
+	if !c.enabled {
+		return
+	}
+	for _, name := range []string{accessTokenCookie, refreshTokenCookie, csrfTokenCookie} {
+		c.setCookie(w, name, "", -1, name != csrfTokenCookie)
+	}
+}
+
+// accessToken retorna o access token do header Authorization ou, no modo cookie, do cookie da sessão.
+// fromCookie indica que a requisição deve ser protegida contra CSRF.
+func (c *TokenCookies) accessToken(r *http.Request) (token string, fromCookie bool) {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		return token, false
+	}
+	if !c.enabled {
+		return "", false
+	}
+	cookie, err := r.Cookie(accessTokenCookie)
+	if err != nil || cookie.Value == "" {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// refreshToken retorna o refresh token enviado no corpo ou, no modo cookie, o do cookie da sessão, que só
+// é aceito com o token CSRF
+func (c *TokenCookies) refreshToken(r *http.Request, bodyToken string) (string, error) {
+	if bodyToken != "" || !c.enabled {
+		return bodyToken, nil
+	}
+	cookie, err := r.Cookie(refreshTokenCookie)
+	if err != nil || cookie.Value == "" {
+		return "", nil
+	}
+	if err := c.checkCSRF(r); err != nil {
+		return "", err
+	}
+	return cookie.Value, nil
+}
+
+// checkCSRF exige que o header X-CSRF-Token seja igual ao cookie CSRF. Um site de terceiros consegue
+// fazer o navegador enviar os cookies, mas não consegue lê-los para preencher o header.
+func (c *TokenCookies) checkCSRF(r *http.Request) error {
+	cookie, err := r.Cookie(csrfTokenCookie)
+	header := r.Header.Get(csrfHeader)
+	if err != nil || cookie.Value == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+		return apperrors.NewForbiddenError("token CSRF inválido")
+	}
+	return nil
+}
+
+func (c *TokenCookies) setCookie(w http.ResponseWriter, name, value string, ttl time.Duration, httpOnly bool) {
+	maxAge := int(ttl.Seconds())
+	if ttl < 0 {
+		maxAge = -1
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: httpOnly,
+		Secure:   true,
+		SameSite: c.sameSite,
+	})
+}
+
+// setOIDCBinding guarda o vínculo do login externo em um cookie HttpOnly que dura até o navegador ser fechado;
+// o state correspondente expira antes no servidor. É usado com ou sem o modo de sessão por cookies.
+func (c *TokenCookies) setOIDCBinding(w http.ResponseWriter, binding string) {
+	c.setCookie(w, oidcBindingCookie, binding, 0, true)
+}
+
+// oidcBinding retorna o vínculo do login externo enviado pelo navegador e remove o cookie, de uso único
+func (c *TokenCookies) oidcBinding(w http.ResponseWriter, r *http.Request) string {
+	cookie, err := r.Cookie(oidcBindingCookie)
+	if err != nil {
+		return ""
+	}
+	c.setCookie(w, oidcBindingCookie, "", -1, true)
+	return cookie.Value
+}
+
+// isSafeMethod indica os métodos que não alteram estado e dispensam o token CSRF
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
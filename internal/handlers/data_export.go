@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+)
+
+type DataExportHandler struct {
+	dataExportService service.DataExportService
+	log               *logger.Logger
+}
+
+func NewDataExportHandler(dataExportService service.DataExportService, log *logger.Logger) *DataExportHandler {
+	return &DataExportHandler{
+		dataExportService: dataExportService,
+		log:               log,
+	}
+}
+
+// RequestExport agenda a exportação dos dados do usuário; o link de download chega por email
+func (h *DataExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	if err := h.dataExportService.RequestExport(r.Context(), userID); err != nil {
+		h.log.Error("Erro ao solicitar exportação de dados: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Download entrega o arquivo do link enviado por email; o token do link é a autenticação
+func (h *DataExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		writeError(w, h.log, apperrors.NewValidationError("token não informado"))
+		return
+	}
+
+	archive, err := h.dataExportService.Download(r.Context(), token)
+	if err != nil {
+		h.log.Error("Erro no download da exportação de dados: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="dados-pessoais.json"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(archive); err != nil {
+		h.log.Error("Erro ao escrever exportação de dados: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+)
+
+type InvitationHandler struct {
+	invitationService service.InvitationService
+	log               *logger.Logger
+}
+
+func NewInvitationHandler(invitationService service.InvitationService, log *logger.Logger) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: invitationService,
+		log:               log,
+	}
+}
+
+type createInvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type acceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+type registerWithInvitationRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+func (h *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req createInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	invitation, err := h.invitationService.CreateInvitation(r.Context(), GetTenantID(r.Context()), userID, req.Email, req.Role)
+	if err != nil {
+		h.log.Error("Erro ao criar convite: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusCreated, invitation)
+}
+
+func (h *InvitationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	invitations, err := h.invitationService.ListInvitations(r.Context(), GetTenantID(r.Context()), userID)
+	if err != nil {
+		h.log.Error("Erro ao listar convites: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, invitations)
+}
+
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(r.Context(), GetTenantID(r.Context()), userID, chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao revogar convite: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *InvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req acceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	membership, err := h.invitationService.AcceptInvitation(r.Context(), req.Token, userID)
+	if err != nil {
+		h.log.Error("Erro ao aceitar convite: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, organizationResponse{
+		Organization: membership.Organization,
+		Role:         membership.Role,
+	})
+}
+
+// RegisterWithInvitation cadastra quem ainda não tem conta a partir do link do convite; o usuário entra em
+// seguida pelo login, já na organização do convite
+func (h *InvitationHandler) RegisterWithInvitation(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req registerWithInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	membership, err := h.invitationService.RegisterWithInvitation(r.Context(), req.Token, req.Password)
+	if err != nil {
+		h.log.Error("Erro no registro por convite: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusCreated, organizationResponse{
+		Organization: membership.Organization,
+		Role:         membership.Role,
+	})
+}
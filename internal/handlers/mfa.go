@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+)
+
+type MFAHandler struct {
+	mfaService service.MFAService
+	cookies    *TokenCookies
+	log        *logger.Logger
+}
+
+func NewMFAHandler(mfaService service.MFAService, cookies *TokenCookies, log *logger.Logger) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+		cookies:    cookies,
+		log:        log,
+	}
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type mfaVerifyRequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func (h *MFAHandler) SetupTOTP(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	claims, ok := GetClaims(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	setup, err := h.mfaService.SetupTOTP(r.Context(), claims)
+	if err != nil {
+		h.log.Error("Erro no cadastro TOTP: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, setup)
+}
+
+func (h *MFAHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmTOTP(r.Context(), userID, req.Code)
+	if err != nil {
+		h.log.Error("Erro na confirmação TOTP: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *MFAHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	claims, ok := GetClaims(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	if err := h.mfaService.DisableTOTP(r.Context(), claims, req.Code); err != nil {
+		h.log.Error("Erro ao desativar TOTP: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req mfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		h.log.Error("Erro ao gerar códigos de recuperação: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+func (h *MFAHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req mfaVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	tokens, err := h.mfaService.VerifyLogin(r.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		h.log.Error("Erro na verificação MFA: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	h.cookies.writeTokens(w, r, h.log, tokens)
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+)
+
+type OAuthHandler struct {
+	oauthService service.OAuthService
+	cookies      *TokenCookies
+	log          *logger.Logger
+}
+
+func NewOAuthHandler(oauthService service.OAuthService, cookies *TokenCookies, log *logger.Logger) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService: oauthService,
+		cookies:      cookies,
+		log:          log,
+	}
+}
+
+type oauthConsentRequest struct {
+	service.AuthorizationRequest
+	Approved bool `json:"approved"`
+}
+
+type oauthConsentResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+type oauthLogoutRequest struct {
+	service.LogoutRequest
+	RefreshToken string `json:"refresh_token"`
+}
+
+type oauthLogoutResponse struct {
+	RedirectTo string `json:"redirect_to,omitempty"`
+}
+
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// Authorize valida a requisição do cliente e encaminha o navegador para a página de login e consentimento
+// do frontend. Erros no client_id ou redirect_uri são exibidos ao usuário; os demais voltam ao cliente.
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	query := r.URL.Query()
+	req := &service.AuthorizationRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+		Nonce:               query.Get("nonce"),
+	}
+
+	redirectTo, err := h.oauthService.BeginAuthorization(r.Context(), req)
+	if err != nil {
+		h.log.Error("Requisição de autorização inválida: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	http.Redirect(w, r, redirectTo, http.StatusFound)
+}
+
+// Consent registra a decisão do usuário autenticado e retorna a URL de retorno ao cliente
+func (h *OAuthHandler) Consent(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	session, ok := GetClaims(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req oauthConsentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	redirectTo, err := h.oauthService.Authorize(r.Context(), session, &req.AuthorizationRequest, req.Approved)
+	if err != nil {
+		h.log.Error("Erro ao autorizar cliente OAuth: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, oauthConsentResponse{RedirectTo: redirectTo})
+}
+
+// Token implementa /oauth/token. O cliente se autentica por HTTP Basic (client_secret_basic)
+// ou pelos campos client_id e client_secret do formulário (client_secret_post).
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, h.log, apperrors.NewOAuthError(apperrors.OAuthInvalidRequest, "corpo da requisição inválido"))
+		return
+	}
+
+	req := &service.TokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		Scope:        r.PostForm.Get("scope"),
+	}
+
+	if username, password, ok := r.BasicAuth(); ok {
+		// As credenciais Basic são codificadas como application/x-www-form-urlencoded (RFC 6749, seção 2.3.1)
+		clientID, errID := url.QueryUnescape(username)
+		clientSecret, errSecret := url.QueryUnescape(password)
+		if errID != nil || errSecret != nil {
+			writeOAuthError(w, h.log, apperrors.NewOAuthError(apperrors.OAuthInvalidClient, "cliente não autenticado"))
+			return
+		}
+		req.ClientID = clientID
+		req.ClientSecret = clientSecret
+	}
+
+	response, err := h.oauthService.Token(r.Context(), req)
+	if err != nil {
+		h.log.Error("Erro no endpoint de token: %v", err)
+		writeOAuthError(w, h.log, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	writeJSON(w, h.log, http.StatusOK, response)
+}
+
+// Discovery publica o documento OpenID Connect Discovery do provedor
+func (h *OAuthHandler) Discovery(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	writeJSON(w, h.log, http.StatusOK, h.oauthService.Discovery())
+}
+
+// JWKS publica as chaves públicas que verificam os access tokens e os ID tokens
+func (h *OAuthHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	writeJSON(w, h.log, http.StatusOK, h.oauthService.JWKS())
+}
+
+// UserInfo retorna as claims do usuário para o access token delegado (OpenID Connect Core, seção 5.3)
+func (h *OAuthHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="oauth"`)
+		writeJSON(w, h.log, http.StatusUnauthorized, oauthErrorResponse{Error: apperrors.OAuthInvalidToken})
+		return
+	}
+
+	info, err := h.oauthService.UserInfo(r.Context(), token)
+	if err != nil {
+		h.log.Error("Erro no endpoint userinfo: %v", err)
+		writeOAuthError(w, h.log, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, h.log, http.StatusOK, info)
+}
+
+// BeginLogout valida o logout iniciado pelo cliente e encaminha o navegador para a página de logout do frontend
+func (h *OAuthHandler) BeginLogout(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	query := r.URL.Query()
+	redirectTo, err := h.oauthService.BeginLogout(r.Context(), &service.LogoutRequest{
+		IDTokenHint:           query.Get("id_token_hint"),
+		ClientID:              query.Get("client_id"),
+		PostLogoutRedirectURI: query.Get("post_logout_redirect_uri"),
+		State:                 query.Get("state"),
+	})
+	if err != nil {
+		h.log.Error("Requisição de logout inválida: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	http.Redirect(w, r, redirectTo, http.StatusFound)
+}
+
+// Logout encerra a sessão do frontend e retorna o destino pós-logout do cliente, quando informado
+func (h *OAuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req oauthLogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	// No modo cookie, a sessão do frontend é identificada pelo cookie do refresh token
+	refreshToken, err := h.cookies.refreshToken(r, req.RefreshToken)
+	if err != nil {
+		writeError(w, h.log, err)
+		return
+	}
+
+	redirectTo, err := h.oauthService.Logout(r.Context(), &req.LogoutRequest, refreshToken)
+	if err != nil {
+		h.log.Error("Erro no logout do cliente OAuth: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	h.cookies.clear(w)
+
+	writeJSON(w, h.log, http.StatusOK, oauthLogoutResponse{RedirectTo: redirectTo})
+}
+
+// writeOAuthError responde no formato de erro do OAuth (RFC 6749, seção 5.2)
+func writeOAuthError(w http.ResponseWriter, log *logger.Logger, err error) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	oauthErr, ok := err.(*apperrors.OAuthError)
+	if !ok {
+		writeJSON(w, log, http.StatusInternalServerError, oauthErrorResponse{Error: "server_error"})
+		return
+	}
+
+	switch oauthErr.Code {
+	case apperrors.OAuthInvalidClient:
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	case apperrors.OAuthInvalidToken, apperrors.OAuthInsufficientScope:
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="oauth", error=%q`, oauthErr.Code))
+	}
+	writeJSON(w, log, oauthErr.StatusCode(), oauthErrorResponse{
+		Error:            oauthErr.Code,
+		ErrorDescription: oauthErr.Description,
+	})
+}
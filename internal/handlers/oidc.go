@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+)
+
+type OIDCHandler struct {
+	oidcService service.OIDCService
+	cookies     *TokenCookies
+	log         *logger.Logger
+}
+
+func NewOIDCHandler(oidcService service.OIDCService, cookies *TokenCookies, log *logger.Logger) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+		cookies:     cookies,
+		log:         log,
+	}
+}
+
+type oidcCallbackRequest struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+type oidcProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+func (h *OIDCHandler) ListProviders(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	writeJSON(w, h.log, http.StatusOK, oidcProvidersResponse{
+		Providers: h.oidcService.Providers(),
+	})
+}
+
+func (h *OIDCHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	authorization, err := h.oidcService.BeginLogin(r.Context(), chi.URLParam(r, "provider"))
+	if err != nil {
+		h.log.Error("Erro ao iniciar login externo: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	h.cookies.setOIDCBinding(w, authorization.Binding)
+	writeJSON(w, h.log, http.StatusOK, authorization)
+}
+
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req oidcCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	binding := h.cookies.oidcBinding(w, r)
+	result, err := h.oidcService.FinishLogin(r.Context(), chi.URLParam(r, "provider"), req.Code, req.State, binding)
+	if err != nil {
+		h.log.Error("Erro no login externo: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	h.cookies.writeLoginResult(w, r, h.log, result)
+}
+
+func (h *OIDCHandler) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	identities, err := h.oidcService.ListIdentities(r.Context(), userID)
+	if err != nil {
+		h.log.Error("Erro ao listar identidades: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, identities)
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+)
+
+type OrganizationHandler struct {
+	organizationService service.OrganizationService
+	log                 *logger.Logger
+}
+
+func NewOrganizationHandler(organizationService service.OrganizationService, log *logger.Logger) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+		log:                 log,
+	}
+}
+
+type createOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type updateMemberRequest struct {
+	Role string `json:"role"`
+}
+
+type organizationResponse struct {
+	entity.Organization
+	Role    string `json:"role"`
+	Current bool   `json:"current"`
+}
+
+type memberResponse struct {
+	UserID   uint      `json:"user_id"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+func (h *OrganizationHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req createOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	organization, err := h.organizationService.CreateOrganization(r.Context(), userID, req.Name)
+	if err != nil {
+		h.log.Error("Erro ao criar organização: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusCreated, organizationResponse{
+		Organization: *organization,
+		Role:         entity.OrganizationRoleOwner,
+	})
+}
+
+func (h *OrganizationHandler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	memberships, err := h.organizationService.ListOrganizations(r.Context(), userID)
+	if err != nil {
+		h.log.Error("Erro ao listar organizações: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	tenantID := GetTenantID(r.Context())
+	resp := make([]organizationResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		resp = append(resp, organizationResponse{
+			Organization: membership.Organization,
+			Role:         membership.Role,
+			Current:      tenantID != "" && tenantID == strconv.FormatUint(uint64(membership.OrganizationID), 10),
+		})
+	}
+
+	writeJSON(w, h.log, http.StatusOK, resp)
+}
+
+func (h *OrganizationHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	members, err := h.organizationService.ListMembers(r.Context(), GetTenantID(r.Context()), userID)
+	if err != nil {
+		h.log.Error("Erro ao listar membros: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	resp := make([]memberResponse, 0, len(members))
+	for _, member := range members {
+		resp = append(resp, memberResponse{
+			UserID:   member.UserID,
+			Email:    member.User.Email,
+			Role:     member.Role,
+			JoinedAt: member.CreatedAt,
+		})
+	}
+
+	writeJSON(w, h.log, http.StatusOK, resp)
+}
+
+func (h *OrganizationHandler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req updateMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	err := h.organizationService.UpdateMemberRole(r.Context(), GetTenantID(r.Context()), userID, chi.URLParam(r, "userID"), req.Role)
+	if err != nil {
+		h.log.Error("Erro ao alterar membro: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	if err := h.organizationService.RemoveMember(r.Context(), GetTenantID(r.Context()), userID, chi.URLParam(r, "userID")); err != nil {
+		h.log.Error("Erro ao remover membro: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	apperrors "auth-template/internal/errors"
+	"auth-template/pkg/logger"
+)
+
+func writeJSON(w http.ResponseWriter, log *logger.Logger, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Error("Erro ao codificar resposta: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, log *logger.Logger, err error) {
+	var status int
+	var message string
+
+	switch e := err.(type) {
+	case *apperrors.AppError:
+		status = e.StatusCode()
+		message = e.Error()
+		if e.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+		}
+	default:
+		status = http.StatusInternalServerError
+		message = "erro interno do servidor"
+	}
+
+	writeJSON(w, log, status, map[string]interface{}{
+		"error": message,
+		"code":  status,
+	})
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+)
+
+type RoleHandler struct {
+	roleService service.RoleService
+	log         *logger.Logger
+}
+
+func NewRoleHandler(roleService service.RoleService, log *logger.Logger) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+		log:         log,
+	}
+}
+
+type assignRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	roles, err := h.roleService.ListRoles(r.Context())
+	if err != nil {
+		h.log.Error("Erro ao listar papéis: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, roles)
+}
+
+func (h *RoleHandler) ListUserRoles(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	roles, err := h.roleService.GetUserRoles(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.log.Error("Erro ao listar papéis do usuário: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, roles)
+}
+
+func (h *RoleHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req assignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	if err := h.roleService.AssignRole(r.Context(), chi.URLParam(r, "id"), req.Role); err != nil {
+		h.log.Error("Erro ao atribuir papel: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RoleHandler) RemoveRole(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	if err := h.roleService.RemoveRole(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "role")); err != nil {
+		h.log.Error("Erro ao remover papel: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
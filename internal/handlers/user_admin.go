@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+)
+
+type UserAdminHandler struct {
+	userAdminService service.UserAdminService
+	log              *logger.Logger
+}
+
+func NewUserAdminHandler(userAdminService service.UserAdminService, log *logger.Logger) *UserAdminHandler {
+	return &UserAdminHandler{
+		userAdminService: userAdminService,
+		log:              log,
+	}
+}
+
+type adminUserResponse struct {
+	*entity.User
+	Status        string          `json:"status"`
+	DeletedAt     *time.Time      `json:"deleted_at"`
+	AdminMetadata entity.Metadata `json:"admin_metadata"`
+}
+
+type adminUserDetailsResponse struct {
+	adminUserResponse
+	Roles    []string         `json:"roles"`
+	Sessions []entity.Session `json:"sessions"`
+}
+
+func newAdminUserResponse(user *entity.User) adminUserResponse {
+	resp := adminUserResponse{
+		User:          user,
+		Status:        user.Status(),
+		AdminMetadata: user.AdminMetadata,
+	}
+	if user.DeletedAt.Valid {
+		resp.DeletedAt = &user.DeletedAt.Time
+	}
+	return resp
+}
+
+// ListUsers busca usuários por email, situação e data de cadastro. A página vem no corpo e o total de
+// usuários da busca no header X-Total-Count.
+func (h *UserAdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	params := r.URL.Query()
+	query := service.UserQuery{
+		Email:  params.Get("email"),
+		Status: params.Get("status"),
+	}
+	var err error
+	if query.Page, err = intParam(params, "page"); err != nil {
+		writeError(w, h.log, err)
+		return
+	}
+	if query.PerPage, err = intParam(params, "per_page"); err != nil {
+		writeError(w, h.log, err)
+		return
+	}
+	if query.CreatedFrom, err = dateParam(params, "created_from", false); err != nil {
+		writeError(w, h.log, err)
+		return
+	}
+	if query.CreatedUntil, err = dateParam(params, "created_to", true); err != nil {
+		writeError(w, h.log, err)
+		return
+	}
+
+	users, total, err := h.userAdminService.ListUsers(r.Context(), query)
+	if err != nil {
+		h.log.Error("Erro ao listar usuários: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	resp := make([]adminUserResponse, 0, len(users))
+	for i := range users {
+		resp = append(resp, newAdminUserResponse(&users[i]))
+	}
+
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	writeJSON(w, h.log, http.StatusOK, resp)
+}
+
+func (h *UserAdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	details, err := h.userAdminService.GetUser(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.log.Error("Erro ao buscar usuário: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, adminUserDetailsResponse{
+		adminUserResponse: newAdminUserResponse(details.User),
+		Roles:             details.Roles,
+		Sessions:          details.Sessions,
+	})
+}
+
+// UpdateAdminMetadata recebe um objeto JSON mesclado aos metadados da administração; chaves null são removidas
+func (h *UserAdminHandler) UpdateAdminMetadata(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var patch map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	metadata, err := h.userAdminService.UpdateAdminMetadata(r.Context(), chi.URLParam(r, "id"), patch)
+	if err != nil {
+		h.log.Error("Erro ao atualizar metadados do usuário: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, metadata)
+}
+
+func (h *UserAdminHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	actorID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	if err := h.userAdminService.DisableUser(r.Context(), actorID, chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao desativar usuário: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAdminHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	if err := h.userAdminService.EnableUser(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao reativar usuário: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAdminHandler) ForceLogout(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	if err := h.userAdminService.ForceLogout(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao encerrar sessões do usuário: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAdminHandler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	if err := h.userAdminService.ForcePasswordReset(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao forçar redefinição de senha: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAdminHandler) UnlockLogin(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	if err := h.userAdminService.UnlockLogin(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao desbloquear login: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	actorID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	if err := h.userAdminService.DeleteUser(r.Context(), actorID, chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao excluir usuário: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserAdminHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	if err := h.userAdminService.RestoreUser(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao restaurar usuário: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// intParam lê um parâmetro inteiro opcional da query string; ausente, retorna zero
+func intParam(params url.Values, name string) (int, error) {
+	value := params.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, apperrors.NewValidationError(name + " inválido")
+	}
+	return n, nil
+}
+
+// dateParam lê uma data (AAAA-MM-DD) ou um instante RFC 3339 opcional da query string. Com endOfDay, uma data
+// sem horário inclui o dia inteiro, devolvendo o início do dia seguinte.
+func dateParam(params url.Values, name string, endOfDay bool) (*time.Time, error) {
+	value := params.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, apperrors.NewValidationError(name + " inválido")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+)
+
+type WebAuthnHandler struct {
+	webAuthnService service.WebAuthnService
+	cookies         *TokenCookies
+	log             *logger.Logger
+}
+
+func NewWebAuthnHandler(webAuthnService service.WebAuthnService, cookies *TokenCookies, log *logger.Logger) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		webAuthnService: webAuthnService,
+		cookies:         cookies,
+		log:             log,
+	}
+}
+
+type webAuthnRegisterBeginRequest struct {
+	Name string `json:"name"`
+}
+
+type webAuthnLoginBeginRequest struct {
+	MFAToken string `json:"mfa_token"`
+}
+
+type webAuthnFinishRequest struct {
+	CeremonyID string          `json:"ceremony_id"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+func (h *WebAuthnHandler) BeginRegistration(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	claims, ok := GetClaims(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	// O corpo é opcional; permite apenas nomear a credencial
+	var req webAuthnRegisterBeginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	ceremony, err := h.webAuthnService.BeginRegistration(r.Context(), claims, req.Name)
+	if err != nil {
+		h.log.Error("Erro ao iniciar registro WebAuthn: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, ceremony)
+}
+
+func (h *WebAuthnHandler) FinishRegistration(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	var req webAuthnFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	credential, err := h.webAuthnService.FinishRegistration(r.Context(), userID, req.CeremonyID, bytes.NewReader(req.Credential))
+	if err != nil {
+		h.log.Error("Erro ao concluir registro WebAuthn: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusCreated, credential)
+}
+
+func (h *WebAuthnHandler) BeginLogin(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req webAuthnLoginBeginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	ceremony, err := h.webAuthnService.BeginLogin(r.Context(), req.MFAToken)
+	if err != nil {
+		h.log.Error("Erro ao iniciar autenticação WebAuthn: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, ceremony)
+}
+
+func (h *WebAuthnHandler) FinishLogin(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	var req webAuthnFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.log.Error("Erro ao decodificar requisição: %v", err)
+		writeError(w, h.log, apperrors.NewValidationError("requisição inválida"))
+		return
+	}
+
+	tokens, err := h.webAuthnService.FinishLogin(r.Context(), req.CeremonyID, bytes.NewReader(req.Credential))
+	if err != nil {
+		h.log.Error("Erro ao concluir autenticação WebAuthn: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	h.cookies.writeTokens(w, r, h.log, tokens)
+}
+
+func (h *WebAuthnHandler) ListCredentials(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	userID, ok := GetUserID(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	credentials, err := h.webAuthnService.ListCredentials(r.Context(), userID)
+	if err != nil {
+		h.log.Error("Erro ao listar credenciais WebAuthn: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	writeJSON(w, h.log, http.StatusOK, credentials)
+}
+
+func (h *WebAuthnHandler) DeleteCredential(w http.ResponseWriter, r *http.Request) {
+	h.log.Info("Request: %s %s", r.Method, r.URL.Path)
+
+	claims, ok := GetClaims(r.Context())
+	if !ok {
+		writeError(w, h.log, apperrors.NewUnauthorizedError("token não fornecido"))
+		return
+	}
+
+	if err := h.webAuthnService.DeleteCredential(r.Context(), claims, chi.URLParam(r, "id")); err != nil {
+		h.log.Error("Erro ao remover credencial WebAuthn: %v", err)
+		writeError(w, h.log, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *entity.APIKey) error
+	// FindByPrefix retorna nil, nil quando a chave não existe
+	FindByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error)
+	FindByUserID(ctx context.Context, userID string) ([]entity.APIKey, error)
+	CountByUserID(ctx context.Context, userID string) (int64, error)
+	// Touch registra o uso da chave
+	Touch(ctx context.Context, id uint, at time.Time) error
+	Delete(ctx context.Context, userID, id string) (bool, error)
+	// DeleteByUserID remove todas as chaves do usuário
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	return r.db.WithContext(ctx).Omit("User").Create(key).Error
+}
+
+func (r *apiKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := r.db.WithContext(ctx).Where("prefix = ?", prefix).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindByUserID(ctx context.Context, userID string) ([]entity.APIKey, error) {
+	var keys []entity.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.APIKey{}).Where("user_id = ?", userID).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *apiKeyRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
+
+func (r *apiKeyRepository) Delete(ctx context.Context, userID, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND id = ?", userID, id).Delete(&entity.APIKey{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *apiKeyRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entity.APIKey{}).Error
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type IdentityRepository interface {
+	// FindByProviderSubject retorna nil, nil quando a identidade não existe
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*entity.UserIdentity, error)
+	FindByUserID(ctx context.Context, userID string) ([]entity.UserIdentity, error)
+	Create(ctx context.Context, identity *entity.UserIdentity) error
+	// CreateWithUser cria o usuário e a identidade vinculada na mesma transação
+	CreateWithUser(ctx context.Context, user *entity.User, identity *entity.UserIdentity) error
+	Update(ctx context.Context, identity *entity.UserIdentity) error
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{
+		db: db,
+	}
+}
+
+func (r *identityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*entity.UserIdentity, error) {
+	var identity entity.UserIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) FindByUserID(ctx context.Context, userID string) ([]entity.UserIdentity, error) {
+	var identities []entity.UserIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+	if err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *entity.UserIdentity) error {
+	return r.db.WithContext(ctx).Omit("User").Create(identity).Error
+}
+
+func (r *identityRepository) CreateWithUser(ctx context.Context, user *entity.User, identity *entity.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Omit("User").Create(identity).Error
+	})
+}
+
+func (r *identityRepository) Update(ctx context.Context, identity *entity.UserIdentity) error {
+	return r.db.WithContext(ctx).Omit("User").Save(identity).Error
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvitationUnavailable indica que o convite já foi aceito, revogado ou expirou
+var ErrInvitationUnavailable = errors.New("convite indisponível")
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *entity.Invitation) error
+	// FindByTokenHash retorna nil, nil quando não há convite com o token
+	FindByTokenHash(ctx context.Context, tokenHash string) (*entity.Invitation, error)
+	// Accept marca o convite como aceito e cria a participação do usuário na mesma transação;
+	// retorna ErrInvitationUnavailable quando o convite deixou de estar pendente
+	Accept(ctx context.Context, invitation *entity.Invitation, userID uint) error
+
+	// Os métodos abaixo acessam os convites da organização tenantID e nunca retornam os de outra organização
+
+	// FindPending retorna os convites ainda não aceitos e não expirados
+	FindPending(ctx context.Context, tenantID string) ([]entity.Invitation, error)
+	// DeletePendingByEmail remove os convites pendentes para o email, substituídos por um novo convite
+	DeletePendingByEmail(ctx context.Context, tenantID, email string) error
+	// DeletePending retorna false quando não há convite pendente com o id
+	DeletePending(ctx context.Context, tenantID, id string) (bool, error)
+}
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{
+		db: db,
+	}
+}
+
+func (r *invitationRepository) Create(ctx context.Context, invitation *entity.Invitation) error {
+	return r.db.WithContext(ctx).Omit("Organization").Create(invitation).Error
+}
+
+func (r *invitationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*entity.Invitation, error) {
+	var invitation entity.Invitation
+	err := r.db.WithContext(ctx).Preload("Organization").Where("token_hash = ?", tokenHash).First(&invitation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) Accept(ctx context.Context, invitation *entity.Invitation, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&entity.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL AND expires_at > ?", invitation.ID, now).
+			Update("accepted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInvitationUnavailable
+		}
+		invitation.AcceptedAt = &now
+
+		return tx.Omit("Organization", "User").Create(&entity.Membership{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         userID,
+			Role:           invitation.Role,
+		}).Error
+	})
+}
+
+func (r *invitationRepository) FindPending(ctx context.Context, tenantID string) ([]entity.Invitation, error) {
+	var invitations []entity.Invitation
+	err := r.db.WithContext(ctx).
+		Scopes(tenantScope(tenantID), pendingInvitations).
+		Order("created_at").
+		Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *invitationRepository) DeletePendingByEmail(ctx context.Context, tenantID, email string) error {
+	return r.db.WithContext(ctx).
+		Scopes(tenantScope(tenantID), pendingInvitations).
+		Where("email = ?", email).
+		Delete(&entity.Invitation{}).Error
+}
+
+func (r *invitationRepository) DeletePending(ctx context.Context, tenantID, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Scopes(tenantScope(tenantID), pendingInvitations).
+		Where("id = ?", id).
+		Delete(&entity.Invitation{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func pendingInvitations(db *gorm.DB) *gorm.DB {
+	return db.Where("accepted_at IS NULL AND expires_at > ?", time.Now())
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MFARepository interface {
+	// FindTOTPByUserID retorna nil, nil quando o usuário não possui fator TOTP
+	FindTOTPByUserID(ctx context.Context, userID string) (*entity.TOTPFactor, error)
+	SaveTOTP(ctx context.Context, factor *entity.TOTPFactor) error
+	// UseTOTPStep registra o passo do código aceito somente se for posterior ao último usado; retorna false
+	// quando outra requisição já usou esse passo (ou um posterior)
+	UseTOTPStep(ctx context.Context, factorID uint, step int64) (bool, error)
+	DeleteTOTP(ctx context.Context, userID string) error
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codes []entity.RecoveryCode) error
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID string) (int64, error)
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{
+		db: db,
+	}
+}
+
+func (r *mfaRepository) FindTOTPByUserID(ctx context.Context, userID string) (*entity.TOTPFactor, error) {
+	var factor entity.TOTPFactor
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&factor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &factor, nil
+}
+
+func (r *mfaRepository) SaveTOTP(ctx context.Context, factor *entity.TOTPFactor) error {
+	return r.db.WithContext(ctx).Omit("User").Save(factor).Error
+}
+
+func (r *mfaRepository) UseTOTPStep(ctx context.Context, factorID uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.TOTPFactor{}).
+		Where("id = ? AND last_used_step < ?", factorID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *mfaRepository) DeleteTOTP(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&entity.TOTPFactor{}).Error
+	})
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codes []entity.RecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *mfaRepository) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *mfaRepository) CountUnusedRecoveryCodes(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
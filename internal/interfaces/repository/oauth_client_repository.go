@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *entity.OAuthClient) error
+	// FindByID retorna nil, nil quando o cliente não existe
+	FindByID(ctx context.Context, id string) (*entity.OAuthClient, error)
+}
+
+type oauthClientRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthClientRepository(db *gorm.DB) OAuthClientRepository {
+	return &oauthClientRepository{
+		db: db,
+	}
+}
+
+func (r *oauthClientRepository) Create(ctx context.Context, client *entity.OAuthClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+func (r *oauthClientRepository) FindByID(ctx context.Context, id string) (*entity.OAuthClient, error) {
+	var client entity.OAuthClient
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&client).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
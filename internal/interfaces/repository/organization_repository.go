@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository interface {
+	// CreateWithOwner cria a organização e a participação do dono na mesma transação
+	CreateWithOwner(ctx context.Context, organization *entity.Organization, ownerID uint) error
+	// FindMembershipsByUserID retorna as organizações do usuário, da mais antiga para a mais recente
+	FindMembershipsByUserID(ctx context.Context, userID string) ([]entity.Membership, error)
+
+	// Os métodos abaixo acessam dados da organização tenantID e nunca retornam linhas de outra organização
+
+	// FindMembership retorna nil, nil quando o usuário não participa da organização
+	FindMembership(ctx context.Context, tenantID, userID string) (*entity.Membership, error)
+	FindMembers(ctx context.Context, tenantID string) ([]entity.Membership, error)
+	AddMember(ctx context.Context, membership *entity.Membership) error
+	// UpdateMemberRole retorna false quando o usuário não participa da organização
+	UpdateMemberRole(ctx context.Context, tenantID, userID, role string) (bool, error)
+	// RemoveMember retorna false quando o usuário não participa da organização
+	RemoveMember(ctx context.Context, tenantID, userID string) (bool, error)
+	CountMembersWithRole(ctx context.Context, tenantID, role string) (int64, error)
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{
+		db: db,
+	}
+}
+
+func (r *organizationRepository) CreateWithOwner(ctx context.Context, organization *entity.Organization, ownerID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Organization", "User").Create(&entity.Membership{
+			OrganizationID: organization.ID,
+			UserID:         ownerID,
+			Role:           entity.OrganizationRoleOwner,
+		}).Error
+	})
+}
+
+func (r *organizationRepository) FindMembershipsByUserID(ctx context.Context, userID string) ([]entity.Membership, error) {
+	var memberships []entity.Membership
+	err := r.db.WithContext(ctx).
+		Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("created_at").
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (r *organizationRepository) FindMembership(ctx context.Context, tenantID, userID string) (*entity.Membership, error) {
+	var membership entity.Membership
+	err := r.db.WithContext(ctx).Scopes(tenantScope(tenantID)).Where("user_id = ?", userID).First(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *organizationRepository) FindMembers(ctx context.Context, tenantID string) ([]entity.Membership, error) {
+	var memberships []entity.Membership
+	err := r.db.WithContext(ctx).
+		Scopes(tenantScope(tenantID)).
+		Preload("User").
+		Order("created_at").
+		Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+func (r *organizationRepository) AddMember(ctx context.Context, membership *entity.Membership) error {
+	return r.db.WithContext(ctx).Omit("Organization", "User").Create(membership).Error
+}
+
+func (r *organizationRepository) UpdateMemberRole(ctx context.Context, tenantID, userID, role string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.Membership{}).
+		Scopes(tenantScope(tenantID)).
+		Where("user_id = ?", userID).
+		Update("role", role)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *organizationRepository) RemoveMember(ctx context.Context, tenantID, userID string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Scopes(tenantScope(tenantID)).
+		Where("user_id = ?", userID).
+		Delete(&entity.Membership{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *organizationRepository) CountMembersWithRole(ctx context.Context, tenantID, role string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.Membership{}).
+		Scopes(tenantScope(tenantID)).
+		Where("role = ?", role).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
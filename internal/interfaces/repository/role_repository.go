@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	FindAll(ctx context.Context) ([]entity.Role, error)
+	// FindByName retorna nil, nil quando o papel não existe
+	FindByName(ctx context.Context, name string) (*entity.Role, error)
+	// FindByUserID retorna os papéis do usuário com as permissões carregadas
+	FindByUserID(ctx context.Context, userID string) ([]entity.Role, error)
+	// Assign atribui o papel ao usuário; atribuir um papel que o usuário já tem não é um erro
+	Assign(ctx context.Context, userID string, roleID uint) error
+	// AssignByName atribui o papel pelo nome, usado no cadastro com o papel padrão
+	AssignByName(ctx context.Context, userID uint, name string) error
+	// Remove retorna false quando o usuário não tem o papel
+	Remove(ctx context.Context, userID string, roleID uint) (bool, error)
+	CountUsers(ctx context.Context, roleID uint) (int64, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{
+		db: db,
+	}
+}
+
+func (r *roleRepository) FindAll(ctx context.Context) ([]entity.Role, error) {
+	var roles []entity.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Order("name").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) FindByName(ctx context.Context, name string) (*entity.Role, error) {
+	var role entity.Role
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) FindByUserID(ctx context.Context, userID string) ([]entity.Role, error) {
+	var roles []entity.Role
+	err := r.db.WithContext(ctx).
+		Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *roleRepository) Assign(ctx context.Context, userID string, roleID uint) error {
+	return r.db.WithContext(ctx).Exec(
+		"INSERT INTO user_roles (user_id, role_id) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		userID, roleID,
+	).Error
+}
+
+func (r *roleRepository) AssignByName(ctx context.Context, userID uint, name string) error {
+	return r.db.WithContext(ctx).Exec(
+		"INSERT INTO user_roles (user_id, role_id) SELECT ?, id FROM roles WHERE name = ? ON CONFLICT DO NOTHING",
+		userID, name,
+	).Error
+}
+
+func (r *roleRepository) Remove(ctx context.Context, userID string, roleID uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("user_id = ? AND role_id = ?", userID, roleID).
+		Delete(&entity.UserRole{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *roleRepository) CountUsers(ctx context.Context, roleID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.UserRole{}).Where("role_id = ?", roleID).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
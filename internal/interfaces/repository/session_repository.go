@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *entity.Session) error
+	// FindByID retorna nil, nil quando a sessão não existe
+	FindByID(ctx context.Context, id string) (*entity.Session, error)
+	// FindActiveByUserID retorna as sessões não revogadas, da usada mais recentemente para a mais antiga
+	FindActiveByUserID(ctx context.Context, userID string) ([]entity.Session, error)
+	// FindByUserID retorna todas as sessões do usuário, inclusive as revogadas, da criada mais recentemente
+	// para a mais antiga (histórico de logins)
+	FindByUserID(ctx context.Context, userID string) ([]entity.Session, error)
+	// Touch registra o uso da sessão em uma renovação de tokens
+	Touch(ctx context.Context, id, ipAddress string, at time.Time) error
+	// SetOrganization troca a organização selecionada na sessão
+	SetOrganization(ctx context.Context, id string, organizationID uint) error
+	// Revoke retorna false quando a sessão não existe, é de outro usuário ou já foi revogada
+	Revoke(ctx context.Context, userID, id string, at time.Time) (bool, error)
+	// RevokeAllExcept revoga as sessões do usuário, exceto exceptID (vazio revoga todas), e retorna as revogadas
+	RevokeAllExcept(ctx context.Context, userID, exceptID string, at time.Time) ([]string, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{
+		db: db,
+	}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *entity.Session) error {
+	return r.db.WithContext(ctx).Omit("User").Create(session).Error
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id string) (*entity.Session, error) {
+	var session entity.Session
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) FindActiveByUserID(ctx context.Context, userID string) ([]entity.Session, error) {
+	var sessions []entity.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) FindByUserID(ctx context.Context, userID string) ([]entity.Session, error) {
+	var sessions []entity.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id, ipAddress string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"last_used_at": at, "ip_address": ipAddress}).Error
+}
+
+func (r *sessionRepository) SetOrganization(ctx context.Context, id string, organizationID uint) error {
+	return r.db.WithContext(ctx).Model(&entity.Session{}).
+		Where("id = ?", id).
+		Update("organization_id", organizationID).Error
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, userID, id string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&entity.Session{}).
+		Where("user_id = ? AND id = ? AND revoked_at IS NULL", userID, id).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *sessionRepository) RevokeAllExcept(ctx context.Context, userID, exceptID string, at time.Time) ([]string, error) {
+	var revoked []entity.Session
+	err := r.db.WithContext(ctx).Model(&revoked).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, exceptID).
+		Update("revoked_at", at).Error
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(revoked))
+	for _, session := range revoked {
+		ids = append(ids, session.ID)
+	}
+	return ids, nil
+}
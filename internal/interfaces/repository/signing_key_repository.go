@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SigningKeyRepository interface {
+	// Create ignora uma chave com o mesmo kid já cadastrada
+	Create(ctx context.Context, key *entity.SigningKey) error
+	// FindByID retorna nil, nil quando a chave não existe
+	FindByID(ctx context.Context, id string) (*entity.SigningKey, error)
+	// FindUsable retorna as chaves não aposentadas, da ativação mais antiga para a mais recente
+	FindUsable(ctx context.Context) ([]entity.SigningKey, error)
+	FindAll(ctx context.Context) ([]entity.SigningKey, error)
+	Retire(ctx context.Context, id string, at time.Time) error
+}
+
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepository{
+		db: db,
+	}
+}
+
+func (r *signingKeyRepository) Create(ctx context.Context, key *entity.SigningKey) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(key).Error
+}
+
+func (r *signingKeyRepository) FindByID(ctx context.Context, id string) (*entity.SigningKey, error) {
+	var key entity.SigningKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *signingKeyRepository) FindUsable(ctx context.Context) ([]entity.SigningKey, error) {
+	var keys []entity.SigningKey
+	err := r.db.WithContext(ctx).Where("retired_at IS NULL").Order("activates_at, created_at").Find(&keys).Error
+	return keys, err
+}
+
+func (r *signingKeyRepository) FindAll(ctx context.Context) ([]entity.SigningKey, error) {
+	var keys []entity.SigningKey
+	err := r.db.WithContext(ctx).Order("use, activates_at").Find(&keys).Error
+	return keys, err
+}
+
+func (r *signingKeyRepository) Retire(ctx context.Context, id string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&entity.SigningKey{}).
+		Where("id = ? AND retired_at IS NULL", id).
+		Update("retired_at", at).Error
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrTenantRequired é retornado quando uma consulta a dados de uma organização é feita sem o tenant
+var ErrTenantRequired = errors.New("consulta a dados da organização sem tenant")
+
+// tenantScope restringe a consulta às linhas da organização tenantID. Todo método que lê ou altera dados
+// pertencentes a uma organização recebe o tenant explicitamente e aplica este escopo, de modo que nenhuma
+// consulta atravesse organizações; um tenant vazio faz a consulta falhar em vez de retornar todas as linhas.
+func tenantScope(tenantID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if tenantID == "" {
+			db.AddError(ErrTenantRequired)
+			return db
+		}
+		return db.Where("organization_id = ?", tenantID)
+	}
+}
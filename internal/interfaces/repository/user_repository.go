@@ -3,15 +3,63 @@ package repository
 import (
 	"auth-template/internal/entity"
 	"context"
+	"errors"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// UserFilter seleciona os usuários listados pela administração. Email filtra por trecho do endereço;
+// Status aceita entity.UserStatusActive, UserStatusDisabled ou UserStatusDeleted (vazio lista os não excluídos).
+type UserFilter struct {
+	Email        string
+	Status       string
+	CreatedFrom  *time.Time
+	CreatedUntil *time.Time
+	Offset       int
+	Limit        int
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, user *entity.User) error
 	FindByEmail(ctx context.Context, email string) (*entity.User, error)
+	// ExistsByEmail inclui as contas excluídas, pois o índice único de email também as cobre
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	FindByID(ctx context.Context, id string) (*entity.User, error)
+	// Os métodos abaixo gravam apenas as próprias colunas, sem sobrescrever alterações concorrentes do restante da
+	// conta (perfil, metadados), e avançam updated_at, de modo que a versão lida antes (ETag) deixa de valer
+	UpdatePassword(ctx context.Context, id uint, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, id uint, at time.Time) error
+	// ChangeEmail troca o email e o marca como verificado em verifiedAt
+	ChangeEmail(ctx context.Context, id uint, email string, verifiedAt time.Time) error
+	// SetDisabledAt desativa a conta em at; nil reativa
+	SetDisabledAt(ctx context.Context, id uint, at *time.Time) error
+	// List retorna a página de usuários do filtro, dos mais recentes para os mais antigos, e o total do filtro
+	List(ctx context.Context, filter UserFilter) ([]entity.User, int64, error)
+	// FindByIDWithDeleted inclui os usuários excluídos; retorna nil, nil quando o usuário não existe
+	FindByIDWithDeleted(ctx context.Context, id string) (*entity.User, error)
+	// Delete faz a exclusão lógica; retorna false quando o usuário não existe ou já foi excluído
+	Delete(ctx context.Context, id string) (bool, error)
+	// Restore desfaz a exclusão lógica; retorna false quando o usuário não está excluído
+	Restore(ctx context.Context, id string) (bool, error)
+	// UpdateProfile grava os campos do perfil somente se a conta não mudou desde que foi lida (user.UpdatedAt);
+	// retorna false quando houve alteração concorrente. Em caso de sucesso, user.UpdatedAt recebe o novo valor.
+	UpdateProfile(ctx context.Context, user *entity.User) (bool, error)
+	// UpdateAdminMetadata grava os metadados da administração sem alterar a versão do perfil (updated_at), somente
+	// se eles ainda forem previous; retorna false quando houve alteração concorrente ou o usuário não existe
+	UpdateAdminMetadata(ctx context.Context, id string, previous, metadata entity.Metadata) (bool, error)
+	// ScheduleDeletion faz a exclusão lógica pedida pelo próprio usuário e agenda a remoção definitiva para purgeAt;
+	// retorna false quando o usuário não existe ou já foi excluído
+	ScheduleDeletion(ctx context.Context, id string, purgeAt time.Time) (bool, error)
+	// FindPendingDeletionByEmail busca a conta com remoção agendada; retorna nil, nil quando não existe
+	FindPendingDeletionByEmail(ctx context.Context, email string) (*entity.User, error)
+	// FindDueForPurge lista até limit contas cuja remoção definitiva venceu até now
+	FindDueForPurge(ctx context.Context, now time.Time, limit int) ([]entity.User, error)
+	// Purge remove definitivamente a conta com remoção vencida, com os dados relacionados (em cascata) e os convites
+	// enviados ao email, passa a outro membro as organizações que ficariam sem dono e grava o registro
+	// anonimizado; retorna false quando a conta foi restaurada antes
+	Purge(ctx context.Context, user *entity.User, now time.Time) (bool, error)
 }
 
 type userRepository struct {
@@ -39,7 +87,7 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity
 
 func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&entity.User{}).Where("email = ?", email).Count(&count).Error
+	err := r.db.WithContext(ctx).Unscoped().Model(&entity.User{}).Where("email = ?", email).Count(&count).Error
 	if err != nil {
 		return false, err
 	}
@@ -54,3 +102,261 @@ func (r *userRepository) FindByID(ctx context.Context, id string) (*entity.User,
 	}
 	return &user, nil
 }
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, passwordHash string) error {
+	return r.updateColumns(ctx, id, map[string]interface{}{"password": passwordHash})
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uint, at time.Time) error {
+	return r.updateColumns(ctx, id, map[string]interface{}{"email_verified_at": at})
+}
+
+func (r *userRepository) ChangeEmail(ctx context.Context, id uint, email string, verifiedAt time.Time) error {
+	return r.updateColumns(ctx, id, map[string]interface{}{"email": email, "email_verified_at": verifiedAt})
+}
+
+func (r *userRepository) SetDisabledAt(ctx context.Context, id uint, at *time.Time) error {
+	return r.updateColumns(ctx, id, map[string]interface{}{"disabled_at": at})
+}
+
+func (r *userRepository) updateColumns(ctx context.Context, id uint, columns map[string]interface{}) error {
+	// O Postgres guarda microssegundos, como em UpdateProfile
+	columns["updated_at"] = time.Now().Truncate(time.Microsecond)
+	return r.db.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).Updates(columns).Error
+}
+
+func (r *userRepository) List(ctx context.Context, filter UserFilter) ([]entity.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.User{})
+	switch filter.Status {
+	case entity.UserStatusActive:
+		query = query.Where("disabled_at IS NULL")
+	case entity.UserStatusDisabled:
+		query = query.Where("disabled_at IS NOT NULL")
+	case entity.UserStatusDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filter.Email != "" {
+		query = query.Where("email ILIKE ?", "%"+escapeLike(filter.Email)+"%")
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedUntil != nil {
+		query = query.Where("created_at < ?", *filter.CreatedUntil)
+	}
+
+	// Sessões separadas para que a contagem não altere a consulta da página
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []entity.User
+	err := query.Order("created_at DESC, id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&users).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepository) FindByIDWithDeleted(ctx context.Context, id string) (*entity.User, error) {
+	var user entity.User
+	err := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ?", id).Delete(&entity.User{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userRepository) Restore(ctx context.Context, id string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&entity.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "purge_at": nil})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, user *entity.User) (bool, error) {
+	// O Postgres guarda microssegundos; truncar mantém o valor em memória igual ao gravado
+	updatedAt := time.Now().Truncate(time.Microsecond)
+	result := r.db.WithContext(ctx).
+		Model(&entity.User{}).
+		Where("id = ? AND updated_at = ?", user.ID, user.UpdatedAt).
+		Updates(map[string]interface{}{
+			"display_name": user.DisplayName,
+			"avatar_url":   user.AvatarURL,
+			"locale":       user.Locale,
+			"timezone":     user.Timezone,
+			"metadata":     user.Metadata,
+			"updated_at":   updatedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	user.UpdatedAt = updatedAt
+	return true, nil
+}
+
+func (r *userRepository) UpdateAdminMetadata(ctx context.Context, id string, previous, metadata entity.Metadata) (bool, error) {
+	// A comparação do JSONB ignora a ordem das chaves e a formatação
+	result := r.db.WithContext(ctx).
+		Unscoped().
+		Model(&entity.User{}).
+		Where("id = ? AND admin_metadata = ?", id, previous).
+		UpdateColumn("admin_metadata", metadata)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userRepository) ScheduleDeletion(ctx context.Context, id string, purgeAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"deleted_at": time.Now(), "purge_at": purgeAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *userRepository) FindPendingDeletionByEmail(ctx context.Context, email string) (*entity.User, error) {
+	var user entity.User
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("email = ? AND deleted_at IS NOT NULL AND purge_at IS NOT NULL", email).
+		First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindDueForPurge(ctx context.Context, now time.Time, limit int) ([]entity.User, error) {
+	var users []entity.User
+	err := r.db.WithContext(ctx).
+		Unscoped().
+		Where("deleted_at IS NOT NULL AND purge_at <= ?", now).
+		Order("purge_at").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+func (r *userRepository) Purge(ctx context.Context, user *entity.User, now time.Time) (bool, error) {
+	purged := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ownedOrganizations []uint
+		err := tx.Model(&entity.Membership{}).
+			Where("user_id = ? AND role = ?", user.ID, entity.OrganizationRoleOwner).
+			Pluck("organization_id", &ownedOrganizations).Error
+		if err != nil {
+			return err
+		}
+
+		// A condição protege contra uma restauração feita depois da busca das contas vencidas
+		result := tx.Unscoped().
+			Where("id = ? AND deleted_at IS NOT NULL AND purge_at <= ?", user.ID, now).
+			Delete(&entity.User{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		purged = true
+
+		// Sessões, fatores de MFA, passkeys, identidades, chaves de API, papéis e vínculos saem em cascata
+		if err := handOverOrganizations(tx, ownedOrganizations); err != nil {
+			return err
+		}
+		if err := tx.Where("email = ?", user.Email).Delete(&entity.Invitation{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.AccountErasure{
+			UserID:      user.ID,
+			RequestedAt: user.DeletedAt.Time,
+			PurgedAt:    now,
+		}).Error
+	})
+	if err != nil {
+		return false, err
+	}
+	return purged, nil
+}
+
+// handOverOrganizations mantém um dono nas organizações que ficaram sem nenhum após a remoção de uma conta: o
+// administrador mais antigo, ou na falta dele o membro mais antigo, passa a ser o dono. A organização sem
+// nenhum outro membro é removida junto com os convites pendentes.
+func handOverOrganizations(tx *gorm.DB, organizationIDs []uint) error {
+	for _, organizationID := range organizationIDs {
+		var owners int64
+		err := tx.Model(&entity.Membership{}).
+			Where("organization_id = ? AND role = ?", organizationID, entity.OrganizationRoleOwner).
+			Count(&owners).Error
+		if err != nil {
+			return err
+		}
+		if owners > 0 {
+			continue
+		}
+
+		var successor *entity.Membership
+		for _, role := range []string{entity.OrganizationRoleAdmin, entity.OrganizationRoleMember} {
+			var candidates []entity.Membership
+			err := tx.Where("organization_id = ? AND role = ?", organizationID, role).
+				Order("created_at").
+				Limit(1).
+				Find(&candidates).Error
+			if err != nil {
+				return err
+			}
+			if len(candidates) > 0 {
+				successor = &candidates[0]
+				break
+			}
+		}
+
+		if successor == nil {
+			if err := tx.Delete(&entity.Organization{}, organizationID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		err = tx.Model(&entity.Membership{}).
+			Where("organization_id = ? AND user_id = ?", organizationID, successor.UserID).
+			Update("role", entity.OrganizationRoleOwner).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeLike escapa os curingas do LIKE para que o trecho informado seja buscado literalmente
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
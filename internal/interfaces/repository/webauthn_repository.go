@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type WebAuthnRepository interface {
+	Create(ctx context.Context, credential *entity.WebAuthnCredential) error
+	Update(ctx context.Context, credential *entity.WebAuthnCredential) error
+	FindByUserID(ctx context.Context, userID string) ([]entity.WebAuthnCredential, error)
+	// FindByCredentialID retorna nil, nil quando a credencial não existe
+	FindByCredentialID(ctx context.Context, credentialID []byte) (*entity.WebAuthnCredential, error)
+	CountByUserID(ctx context.Context, userID string) (int64, error)
+	Delete(ctx context.Context, userID, id string) (bool, error)
+}
+
+type webAuthnRepository struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnRepository(db *gorm.DB) WebAuthnRepository {
+	return &webAuthnRepository{
+		db: db,
+	}
+}
+
+func (r *webAuthnRepository) Create(ctx context.Context, credential *entity.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Omit("User").Create(credential).Error
+}
+
+func (r *webAuthnRepository) Update(ctx context.Context, credential *entity.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Omit("User").Save(credential).Error
+}
+
+func (r *webAuthnRepository) FindByUserID(ctx context.Context, userID string) ([]entity.WebAuthnCredential, error) {
+	var credentials []entity.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&credentials).Error
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+func (r *webAuthnRepository) FindByCredentialID(ctx context.Context, credentialID []byte) (*entity.WebAuthnCredential, error) {
+	var credential entity.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("credential_id = ?", credentialID).First(&credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *webAuthnRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entity.WebAuthnCredential{}).Where("user_id = ?", userID).Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *webAuthnRepository) Delete(ctx context.Context, userID, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND id = ?", userID, id).Delete(&entity.WebAuthnCredential{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"auth-template/internal/entity"
+	"auth-template/pkg/auth"
+	"context"
+	"time"
+)
+
+// Escopos que podem ser concedidos às chaves de API. As rotas de gestão da conta (senha, MFA, passkeys e
+// as próprias chaves) não aceitam chaves de API, independentemente dos escopos.
+const (
+	ScopeProfileRead   = "profile:read"
+	ScopeSessionsRead  = "sessions:read"
+	ScopeSessionsWrite = "sessions:write"
+)
+
+// APIKeyScopes lista os escopos aceitos na criação de uma chave
+var APIKeyScopes = []string{ScopeProfileRead, ScopeSessionsRead, ScopeSessionsWrite}
+
+// CreatedAPIKey é retornado apenas na criação; Key é o único momento em que o segredo fica visível
+type CreatedAPIKey struct {
+	APIKey *entity.APIKey
+	Key    string
+}
+
+type APIKeyService interface {
+	CreateKey(ctx context.Context, userID, name string, scopes []string, expiresAt *time.Time) (*CreatedAPIKey, error)
+	ListKeys(ctx context.Context, userID string) ([]entity.APIKey, error)
+	DeleteKey(ctx context.Context, userID, id string) error
+	// Authenticate valida a chave apresentada no header Authorization e retorna as claims da requisição
+	Authenticate(ctx context.Context, key string) (*auth.Claims, error)
+}
@@ -2,19 +2,77 @@ package service
 
 import (
 	"auth-template/internal/entity"
+	"auth-template/pkg/auth"
 	"context"
+	"time"
 )
 
+// ProfileUpdate traz os campos alterados pelo PATCH /auth/me; campos nil permanecem como estão. Metadata é
+// mesclado ao atual no estilo JSON Merge Patch: chaves com valor null são removidas.
+type ProfileUpdate struct {
+	DisplayName *string
+	AvatarURL   *string
+	Locale      *string
+	Timezone    *string
+	Metadata    map[string]interface{}
+}
+
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
 
+// LoginResult contém os tokens emitidos ou, quando o usuário possui MFA ativo,
+// o token do desafio que deve ser concluído com um dos métodos em MFAMethods
+type LoginResult struct {
+	Tokens      *TokenPair
+	MFARequired bool
+	MFAToken    string
+	MFAMethods  []string
+}
+
 type AuthService interface {
-	Register(ctx context.Context, email, password string) error
-	Login(ctx context.Context, email, password string) (*TokenPair, error)
+	Register(ctx context.Context, email, password string) (*entity.User, error)
+	Login(ctx context.Context, email, password string) (*LoginResult, error)
+	// StartSession é chamado após o primeiro fator (senha, magic link, provedor externo) e exige o MFA quando ativo
+	StartSession(ctx context.Context, user *entity.User, amr []string) (*LoginResult, error)
+	// CompleteLogin emite os tokens após o segundo fator ou a passkey, conferindo de novo as regras de acesso da conta
+	CompleteLogin(ctx context.Context, userID string, amr []string) (*TokenPair, error)
 	RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error)
-	ValidateAccessToken(ctx context.Context, token string) error
-	Logout(ctx context.Context, refreshToken string) error
+	// SwitchOrganization renova os tokens da sessão com outra organização na claim tenant_id
+	SwitchOrganization(ctx context.Context, refreshToken, organizationID string) (*TokenPair, error)
+	ValidateAccessToken(ctx context.Context, token string) (*auth.Claims, error)
+	Logout(ctx context.Context, refreshToken, accessToken string) error
+	ListSessions(ctx context.Context, userID string) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error
+	// RevokeUserTokens invalida imediatamente todos os tokens e sessões do usuário
+	RevokeUserTokens(ctx context.Context, userID string) error
 	GetUserFromToken(ctx context.Context, token string) (*entity.User, error)
+	GetUser(ctx context.Context, userID string) (*entity.User, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerificationEmail(ctx context.Context, email string) error
+	ForgotPassword(ctx context.Context, email string) error
+	// SendPasswordResetEmail envia o link de redefinição ao usuário, sem o limite de pedidos do ForgotPassword
+	SendPasswordResetEmail(ctx context.Context, user *entity.User) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	RequestMagicLink(ctx context.Context, email string) (string, error)
+	ConsumeMagicLink(ctx context.Context, token, nonce string) (*LoginResult, error)
+	// ChangePassword troca a senha e encerra as demais sessões; a sessão atual recebe um novo par de tokens
+	ChangePassword(ctx context.Context, current *auth.Claims, currentPassword, newPassword string) (*TokenPair, error)
+	// DeleteAccount confirma a senha, ou sem ela exige um login recente, exclui a conta e encerra todas as sessões.
+	// A remoção definitiva ocorre no instante retornado; até lá, o login restaura a conta.
+	DeleteAccount(ctx context.Context, current *auth.Claims, password string) (time.Time, error)
+	// UpdateProfile altera o perfil do usuário. Com expectedVersion, a alteração só é aplicada se a conta ainda
+	// estiver nessa versão (entity.User.Version); vazio dispensa a conferência.
+	UpdateProfile(ctx context.Context, userID, expectedVersion string, update ProfileUpdate) (*entity.User, error)
+	// RequestEmailChange confirma a senha, ou sem ela exige um login recente, e envia o link de confirmação ao novo
+	// endereço e o aviso, com o link de cancelamento, ao endereço atual. O email só é trocado na confirmação; um
+	// endereço já cadastrado recebe apenas um aviso, sem que a resposta o revele.
+	RequestEmailChange(ctx context.Context, current *auth.Claims, password, newEmail string) error
+	// ConfirmEmailChange troca o email do usuário da sessão atual, encerra as demais sessões e retorna um novo par
+	// de tokens para o dispositivo atual, com o auth_time e o amr da sessão
+	ConfirmEmailChange(ctx context.Context, current *auth.Claims, token string) (*TokenPair, error)
+	// CancelEmailChange descarta a troca pendente pelo link enviado ao endereço atual
+	CancelEmailChange(ctx context.Context, token string) error
 }
@@ -0,0 +1,25 @@
+package service
+
+import "context"
+
+type clientInfoKey struct{}
+
+// ClientInfo descreve o dispositivo que fez a requisição; é registrado nas sessões criadas no login.
+// OrganizationID é a organização escolhida pelo cliente para a sessão criada no login.
+type ClientInfo struct {
+	IPAddress      string
+	UserAgent      string
+	DeviceName     string
+	OrganizationID string
+}
+
+// WithClientInfo adiciona os dados do dispositivo ao contexto
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext obtém os dados do dispositivo do contexto; vazio quando ausente
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
@@ -0,0 +1,13 @@
+package service
+
+import "context"
+
+// DataExportService atende os pedidos de acesso e portabilidade dos dados pessoais do usuário
+type DataExportService interface {
+	// RequestExport monta o arquivo em segundo plano e envia ao email do usuário o link de download, que expira
+	RequestExport(ctx context.Context, userID string) error
+	// Build monta o arquivo JSON com os dados mantidos sobre o usuário, sem segredos nem hashes
+	Build(ctx context.Context, userID string) ([]byte, error)
+	// Download retorna o arquivo do link de exportação enquanto ele não expirar
+	Download(ctx context.Context, token string) ([]byte, error)
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"auth-template/internal/entity"
+	"context"
+)
+
+// InvitationService gerencia os convites para a organização selecionada no token (claim tenant_id) e o
+// aceite deles pelos links enviados por email
+type InvitationService interface {
+	// CreateInvitation envia o link do convite apenas para o email convidado
+	CreateInvitation(ctx context.Context, tenantID, actorID, email, role string) (*entity.Invitation, error)
+	// ListInvitations retorna os convites pendentes (não aceitos e não expirados)
+	ListInvitations(ctx context.Context, tenantID, actorID string) ([]entity.Invitation, error)
+	RevokeInvitation(ctx context.Context, tenantID, actorID, id string) error
+	// AcceptInvitation vincula o usuário autenticado, dono do email convidado, à organização
+	AcceptInvitation(ctx context.Context, token, userID string) (*entity.Membership, error)
+	// RegisterWithInvitation cadastra o convidado, que ainda confirma o email pelo link de verificação, e o
+	// vincula à organização
+	RegisterWithInvitation(ctx context.Context, token, password string) (*entity.Membership, error)
+}
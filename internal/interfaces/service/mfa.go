@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+
+	"auth-template/pkg/auth"
+)
+
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type MFAService interface {
+	SetupTOTP(ctx context.Context, current *auth.Claims) (*TOTPSetup, error)
+	ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, current *auth.Claims, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error)
+	VerifyLogin(ctx context.Context, mfaToken, code string) (*TokenPair, error)
+}
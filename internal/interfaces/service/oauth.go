@@ -0,0 +1,108 @@
+package service
+
+import (
+	"auth-template/internal/entity"
+	"auth-template/pkg/auth"
+	"context"
+)
+
+// OAuthClientRegistration descreve um novo cliente do servidor de autorização
+type OAuthClientRegistration struct {
+	Name                   string
+	Type                   entity.OAuthClientType
+	RedirectURIs           []string
+	PostLogoutRedirectURIs []string
+	Scopes                 []string
+	GrantTypes             []string
+}
+
+// AuthorizationRequest contém os parâmetros de /oauth/authorize
+type AuthorizationRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Nonce               string `json:"nonce"`
+}
+
+// TokenRequest contém os parâmetros de /oauth/token, já com as credenciais do cliente extraídas
+type TokenRequest struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+}
+
+// OAuthTokenResponse é a resposta de sucesso de /oauth/token (RFC 6749, seção 5.1)
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+}
+
+// LogoutRequest contém os parâmetros do logout iniciado pelo cliente (OIDC RP-Initiated Logout)
+type LogoutRequest struct {
+	IDTokenHint           string `json:"id_token_hint"`
+	ClientID              string `json:"client_id"`
+	PostLogoutRedirectURI string `json:"post_logout_redirect_uri"`
+	State                 string `json:"state"`
+}
+
+// UserInfo são as claims do usuário retornadas por /oauth/userinfo conforme os escopos concedidos
+type UserInfo struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// OpenIDConfiguration é o documento de discovery do provedor (OpenID Connect Discovery 1.0)
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	EndSessionEndpoint                string   `json:"end_session_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	ResponseModesSupported            []string `json:"response_modes_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	AuthorizationResponseIssParameter bool     `json:"authorization_response_iss_parameter_supported"`
+}
+
+type OAuthService interface {
+	// RegisterClient cria o cliente e retorna o segredo em texto puro (apenas para clientes confidenciais),
+	// que não pode ser recuperado depois
+	RegisterClient(ctx context.Context, registration *OAuthClientRegistration) (*entity.OAuthClient, string, error)
+	// BeginAuthorization valida a requisição e retorna a URL da página de login e consentimento ou, para
+	// erros que podem ser devolvidos ao cliente, a URL de retorno com o erro. Um AppError indica cliente ou
+	// redirect_uri inválido: o erro deve ser exibido ao usuário, sem redirecioná-lo.
+	BeginAuthorization(ctx context.Context, req *AuthorizationRequest) (string, error)
+	// Authorize registra a decisão do usuário autenticado na sessão e retorna a URL de retorno ao cliente,
+	// com o código ou o erro
+	Authorize(ctx context.Context, session *auth.Claims, req *AuthorizationRequest, approved bool) (string, error)
+	Token(ctx context.Context, req *TokenRequest) (*OAuthTokenResponse, error)
+	UserInfo(ctx context.Context, accessToken string) (*UserInfo, error)
+	Discovery() *OpenIDConfiguration
+	JWKS() auth.JWKSet
+	// BeginLogout valida o logout iniciado pelo cliente e retorna a URL da página de logout do frontend
+	BeginLogout(ctx context.Context, req *LogoutRequest) (string, error)
+	// Logout encerra a sessão do refresh token informado (quando houver) e retorna o destino pós-logout
+	// validado, ou vazio se o cliente não informou um
+	Logout(ctx context.Context, req *LogoutRequest, refreshToken string) (string, error)
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"auth-template/internal/entity"
+	"context"
+)
+
+// OIDCAuthorization é retornado no início do login externo. O navegador deve ser redirecionado para
+// AuthorizationURL e o State guardado para conferência quando o provedor redirecionar de volta. Binding
+// não vai no corpo: fica em um cookie HttpOnly e é exigido na conclusão.
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+	Binding          string `json:"-"`
+}
+
+type OIDCService interface {
+	Providers() []string
+	BeginLogin(ctx context.Context, provider string) (*OIDCAuthorization, error)
+	FinishLogin(ctx context.Context, provider, code, state, binding string) (*LoginResult, error)
+	ListIdentities(ctx context.Context, userID string) ([]entity.UserIdentity, error)
+}
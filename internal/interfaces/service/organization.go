@@ -0,0 +1,18 @@
+package service
+
+import (
+	"auth-template/internal/entity"
+	"context"
+)
+
+// OrganizationService gerencia as organizações (tenants) e seus membros. Os métodos que recebem tenantID
+// operam apenas sobre a organização selecionada no token (claim tenant_id).
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, userID, name string) (*entity.Organization, error)
+	// ListOrganizations retorna as participações do usuário com as organizações carregadas
+	ListOrganizations(ctx context.Context, userID string) ([]entity.Membership, error)
+	ListMembers(ctx context.Context, tenantID, actorID string) ([]entity.Membership, error)
+	UpdateMemberRole(ctx context.Context, tenantID, actorID, userID, role string) error
+	// RemoveMember remove o membro; qualquer membro pode remover a si mesmo (sair da organização)
+	RemoveMember(ctx context.Context, tenantID, actorID, userID string) error
+}
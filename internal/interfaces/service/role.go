@@ -0,0 +1,14 @@
+package service
+
+import (
+	"auth-template/internal/entity"
+	"context"
+)
+
+type RoleService interface {
+	ListRoles(ctx context.Context) ([]entity.Role, error)
+	GetUserRoles(ctx context.Context, userID string) ([]entity.Role, error)
+	AssignRole(ctx context.Context, userID, roleName string) error
+	// RemoveRole recusa a remoção do papel admin do último administrador
+	RemoveRole(ctx context.Context, userID, roleName string) error
+}
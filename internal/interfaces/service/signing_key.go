@@ -0,0 +1,21 @@
+package service
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"time"
+)
+
+// SigningKeyService administra os keyrings que assinam os tokens, compartilhados pelas instâncias via banco
+type SigningKeyService interface {
+	// Sync carrega as chaves do banco nos keyrings do TokenManager e aposenta as que já não verificam
+	// nenhum token válido. Na primeira execução, as chaves configuradas por variáveis de ambiente são cadastradas.
+	Sync(ctx context.Context) error
+	// Rotate cadastra uma chave nova, que é publicada imediatamente e passa a assinar os tokens em activatesAt
+	Rotate(ctx context.Context, use entity.SigningKeyUse, algorithm string, activatesAt time.Time) (*entity.SigningKey, error)
+	// Retire aposenta imediatamente uma chave que não seja a ativa (ex: chave comprometida)
+	Retire(ctx context.Context, kid string) error
+	List(ctx context.Context) ([]entity.SigningKey, error)
+	// Run sincroniza os keyrings e aplica a política de rotação periodicamente até o contexto ser cancelado
+	Run(ctx context.Context)
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"auth-template/internal/entity"
+	"context"
+	"time"
+)
+
+// UserQuery é a busca paginada de usuários da administração; Status aceita entity.UserStatusActive,
+// UserStatusDisabled ou UserStatusDeleted (vazio lista os não excluídos)
+type UserQuery struct {
+	Email        string
+	Status       string
+	CreatedFrom  *time.Time
+	CreatedUntil *time.Time
+	Page         int
+	PerPage      int
+}
+
+// UserDetails reúne o que o suporte consulta sobre uma conta
+type UserDetails struct {
+	User     *entity.User
+	Roles    []string
+	Sessions []entity.Session
+}
+
+// UserAdminService atende o suporte na gestão das contas. As ações que encerram o acesso do usuário
+// (desativação, logout forçado, redefinição de senha forçada e exclusão) revogam todas as sessões e tokens.
+// O logout e a redefinição forçados também removem as chaves de API; na desativação e na exclusão elas são
+// mantidas, mas deixam de autenticar.
+type UserAdminService interface {
+	// ListUsers retorna a página pedida e o total de usuários da busca
+	ListUsers(ctx context.Context, query UserQuery) ([]entity.User, int64, error)
+	// GetUser inclui usuários excluídos
+	GetUser(ctx context.Context, userID string) (*UserDetails, error)
+	DisableUser(ctx context.Context, actorID, userID string) error
+	EnableUser(ctx context.Context, userID string) error
+	ForceLogout(ctx context.Context, userID string) error
+	// ForcePasswordReset invalida a senha atual e envia ao usuário o email de redefinição de senha
+	ForcePasswordReset(ctx context.Context, userID string) error
+	// UpdateAdminMetadata mescla patch aos metadados da administração (chaves null são removidas) e retorna o
+	// resultado; esses metadados não são exibidos nem editáveis pelo próprio usuário
+	UpdateAdminMetadata(ctx context.Context, userID string, patch map[string]interface{}) (entity.Metadata, error)
+	// UnlockLogin libera a conta e os clientes bloqueados por excesso de senhas incorretas
+	UnlockLogin(ctx context.Context, userID string) error
+	DeleteUser(ctx context.Context, actorID, userID string) error
+	RestoreUser(ctx context.Context, userID string) error
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"auth-template/internal/entity"
+	"auth-template/pkg/auth"
+	"context"
+	"io"
+)
+
+// WebAuthnCeremony é retornado no início de uma cerimônia. Options deve ser repassado a
+// navigator.credentials.create()/get() e CeremonyID enviado de volta no finish.
+type WebAuthnCeremony struct {
+	CeremonyID string      `json:"ceremony_id"`
+	Options    interface{} `json:"options"`
+}
+
+// WebAuthnService gerencia as passkeys. O registro e a remoção exigem um login recente (AUTH_REAUTH_MAX_AGE),
+// verificado pela claim auth_time do token atual.
+type WebAuthnService interface {
+	BeginRegistration(ctx context.Context, current *auth.Claims, name string) (*WebAuthnCeremony, error)
+	FinishRegistration(ctx context.Context, userID, ceremonyID string, body io.Reader) (*entity.WebAuthnCredential, error)
+	BeginLogin(ctx context.Context, mfaToken string) (*WebAuthnCeremony, error)
+	FinishLogin(ctx context.Context, ceremonyID string, body io.Reader) (*TokenPair, error)
+	ListCredentials(ctx context.Context, userID string) ([]entity.WebAuthnCredential, error)
+	DeleteCredential(ctx context.Context, current *auth.Claims, id string) error
+}
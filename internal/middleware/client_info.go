@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/validation"
+)
+
+const (
+	maxDeviceNameLength = 100
+	maxUserAgentLength  = 512
+)
+
+// ClientInfo registra no contexto o IP, o user agent e o nome do dispositivo da requisição. O nome pode ser
+// informado pelo aplicativo no header X-Device-Name; sem ele, é derivado do user agent (ex: "Chrome no Windows").
+// O header X-Organization-ID escolhe a organização da sessão aberta no login. Deve ser aplicado após
+// RealIP.
+func ClientInfo(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		userAgent := validation.Truncate(r.UserAgent(), maxUserAgentLength)
+		deviceName := strings.TrimSpace(r.Header.Get("X-Device-Name"))
+		if deviceName == "" {
+			deviceName = deviceNameFromUserAgent(userAgent)
+		}
+		deviceName = validation.Truncate(deviceName, maxDeviceNameLength)
+
+		ctx := service.WithClientInfo(r.Context(), service.ClientInfo{
+			IPAddress:      ip,
+			UserAgent:      userAgent,
+			DeviceName:     deviceName,
+			OrganizationID: strings.TrimSpace(r.Header.Get("X-Organization-ID")),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// deviceNameFromUserAgent identifica o navegador e o sistema pelos tokens mais comuns do user agent
+func deviceNameFromUserAgent(userAgent string) string {
+	var browser, system string
+
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(userAgent, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	}
+
+	switch {
+	case strings.Contains(userAgent, "iPhone"):
+		system = "iOS"
+	case strings.Contains(userAgent, "iPad"):
+		system = "iPadOS"
+	case strings.Contains(userAgent, "Android"):
+		system = "Android"
+	case strings.Contains(userAgent, "Windows"):
+		system = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"):
+		system = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		system = "Linux"
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " no " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	return "Dispositivo desconhecido"
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIP substitui o RemoteAddr pelo IP do cliente informado em X-Forwarded-For ou X-Real-IP, mas só quando a
+// conexão vem de um proxy listado em trustedProxies (IPs ou CIDRs). Em conexões diretas os dois headers são
+// descartados, para que nem o ClientInfo nem os rate limiters aceitem um IP forjado pelo próprio cliente.
+// Entradas inválidas em trustedProxies são ignoradas. Deve ser o primeiro middleware da cadeia.
+func RealIP(trustedProxies []string) func(http.Handler) http.Handler {
+	trusted := parseNetworks(trustedProxies)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				peer = r.RemoteAddr
+			}
+
+			ip := peer
+			if isTrusted(trusted, peer) {
+				ip = forwardedClientIP(trusted, r.Header, peer)
+			}
+
+			r.Header.Del("X-Forwarded-For")
+			r.Header.Set("X-Real-IP", ip)
+			r.RemoteAddr = ip
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClientIP percorre o X-Forwarded-For da direita para a esquerda e devolve o primeiro endereço que não é
+// um proxy confiável; os endereços mais à esquerda podem ter sido escritos pelo cliente e não são considerados
+func forwardedClientIP(trusted []*net.IPNet, header http.Header, peer string) string {
+	if forwardedFor := strings.Join(header.Values("X-Forwarded-For"), ","); forwardedFor != "" {
+		ip := peer
+		hops := strings.Split(forwardedFor, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			ip = hop
+			if !isTrusted(trusted, hop) {
+				break
+			}
+		}
+		return ip
+	}
+
+	if realIP := strings.TrimSpace(header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return peer
+}
+
+func parseNetworks(values []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil {
+				bits := 8 * len(ip.To16())
+				if ip.To4() != nil {
+					ip, bits = ip.To4(), 32
+				}
+				networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(value); err == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+func isTrusted(networks []*net.IPNet, value string) bool {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
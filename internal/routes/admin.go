@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"auth-template/internal/entity"
+	"auth-template/internal/handlers"
+)
+
+func SetupAdminRoutes(r chi.Router, authHandler *handlers.AuthHandler, roleHandler *handlers.RoleHandler, userAdminHandler *handlers.UserAdminHandler) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(authHandler.AuthMiddleware)
+
+		r.With(authHandler.RequirePermission(entity.PermissionRolesRead)).Get("/roles", roleHandler.ListRoles)
+
+		r.Route("/users", func(r chi.Router) {
+			read := authHandler.RequirePermission(entity.PermissionUsersRead)
+			write := authHandler.RequirePermission(entity.PermissionUsersWrite)
+
+			r.With(read).Get("/", userAdminHandler.ListUsers)
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.With(read).Get("/", userAdminHandler.GetUser)
+				r.With(write).Delete("/", userAdminHandler.DeleteUser)
+				r.With(write).Post("/restore", userAdminHandler.RestoreUser)
+				r.With(write).Post("/disable", userAdminHandler.DisableUser)
+				r.With(write).Post("/enable", userAdminHandler.EnableUser)
+				r.With(write).Post("/logout", userAdminHandler.ForceLogout)
+				r.With(write).Post("/password-reset", userAdminHandler.ForcePasswordReset)
+				r.With(write).Post("/unlock", userAdminHandler.UnlockLogin)
+				r.With(write).Patch("/metadata", userAdminHandler.UpdateAdminMetadata)
+
+				r.Route("/roles", func(r chi.Router) {
+					r.With(authHandler.RequirePermission(entity.PermissionRolesRead)).Get("/", roleHandler.ListUserRoles)
+					r.With(authHandler.RequirePermission(entity.PermissionRolesWrite)).Post("/", roleHandler.AssignRole)
+					r.With(authHandler.RequirePermission(entity.PermissionRolesWrite)).Delete("/{role}", roleHandler.RemoveRole)
+				})
+			})
+		})
+	})
+}
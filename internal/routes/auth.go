@@ -6,10 +6,19 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"auth-template/internal/handlers"
+	"auth-template/internal/interfaces/service"
 	"auth-template/internal/middleware"
 )
 
-func SetupAuthRoutes(r chi.Router, authHandler *handlers.AuthHandler) {
+func SetupAuthRoutes(
+	r chi.Router,
+	authHandler *handlers.AuthHandler,
+	mfaHandler *handlers.MFAHandler,
+	webAuthnHandler *handlers.WebAuthnHandler,
+	oidcHandler *handlers.OIDCHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	dataExportHandler *handlers.DataExportHandler,
+) {
 	// Rate limiter específico para autenticação
 	authLimiter := middleware.NewAuthRateLimiter(100, time.Hour) // 100 requisições por hora
 
@@ -20,12 +29,64 @@ func SetupAuthRoutes(r chi.Router, authHandler *handlers.AuthHandler) {
 		r.Post("/login", authHandler.Login)
 		r.Post("/register", authHandler.Register)
 		r.Post("/refresh", authHandler.Refresh)
+		r.Post("/switch-organization", authHandler.SwitchOrganization)
 		r.Post("/logout", authHandler.Logout)
+		r.Post("/verify-email", authHandler.VerifyEmail)
+		r.Post("/verify-email/resend", authHandler.ResendVerificationEmail)
+		r.Post("/password/forgot", authHandler.ForgotPassword)
+		r.Post("/password/reset", authHandler.ResetPassword)
+		r.Post("/email/cancel", authHandler.CancelEmailChange)
+		r.Post("/magic-link", authHandler.RequestMagicLink)
+		r.Post("/magic-link/consume", authHandler.ConsumeMagicLink)
+		r.Post("/mfa/verify", mfaHandler.Verify)
+		r.Post("/webauthn/login/begin", webAuthnHandler.BeginLogin)
+		r.Post("/webauthn/login/finish", webAuthnHandler.FinishLogin)
+		r.Get("/oidc/providers", oidcHandler.ListProviders)
+		r.Post("/oidc/{provider}/authorize", oidcHandler.Authorize)
+		r.Post("/oidc/{provider}/callback", oidcHandler.Callback)
+		r.Get("/export", dataExportHandler.Download)
 
 		// Rotas protegidas
 		r.Group(func(r chi.Router) {
 			r.Use(authHandler.AuthMiddleware)
-			r.Get("/me", authHandler.Me)
+
+			// Rotas também acessíveis por chaves de API com o escopo correspondente
+			r.With(authHandler.RequireScope(service.ScopeProfileRead)).Get("/me", authHandler.Me)
+			r.With(authHandler.RequireScope(service.ScopeSessionsRead)).Get("/sessions", authHandler.ListSessions)
+			r.With(authHandler.RequireScope(service.ScopeSessionsWrite)).Delete("/sessions", authHandler.RevokeOtherSessions)
+			r.With(authHandler.RequireScope(service.ScopeSessionsWrite)).Delete("/sessions/{id}", authHandler.RevokeSession)
+
+			// Gestão da conta e das credenciais: apenas com a sessão do usuário
+			r.Group(func(r chi.Router) {
+				r.Use(authHandler.RequireUserSession)
+				r.Patch("/me", authHandler.UpdateProfile)
+				r.Post("/me/password", authHandler.ChangePassword)
+				r.Post("/me/email", authHandler.RequestEmailChange)
+				r.Post("/me/email/confirm", authHandler.ConfirmEmailChange)
+				r.Delete("/me", authHandler.DeleteAccount)
+				r.Post("/me/export", dataExportHandler.RequestExport)
+				r.Get("/me/identities", oidcHandler.ListIdentities)
+
+				r.Route("/mfa", func(r chi.Router) {
+					r.Post("/totp/setup", mfaHandler.SetupTOTP)
+					r.Post("/totp/confirm", mfaHandler.ConfirmTOTP)
+					r.Delete("/totp", mfaHandler.DisableTOTP)
+					r.Post("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+				})
+
+				r.Route("/webauthn", func(r chi.Router) {
+					r.Post("/register/begin", webAuthnHandler.BeginRegistration)
+					r.Post("/register/finish", webAuthnHandler.FinishRegistration)
+					r.Get("/credentials", webAuthnHandler.ListCredentials)
+					r.Delete("/credentials/{id}", webAuthnHandler.DeleteCredential)
+				})
+
+				r.Route("/api-keys", func(r chi.Router) {
+					r.Post("/", apiKeyHandler.CreateKey)
+					r.Get("/", apiKeyHandler.ListKeys)
+					r.Delete("/{id}", apiKeyHandler.DeleteKey)
+				})
+			})
 		})
 	})
 }
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"auth-template/internal/handlers"
+)
+
+func SetupOAuthRoutes(r chi.Router, oauthHandler *handlers.OAuthHandler, authMiddleware func(http.Handler) http.Handler) {
+	r.Get("/.well-known/openid-configuration", oauthHandler.Discovery)
+	r.Get("/.well-known/jwks.json", oauthHandler.JWKS)
+
+	r.Route("/oauth", func(r chi.Router) {
+		r.Get("/authorize", oauthHandler.Authorize)
+		r.Post("/token", oauthHandler.Token)
+		r.Get("/userinfo", oauthHandler.UserInfo)
+		r.Post("/userinfo", oauthHandler.UserInfo)
+		r.Get("/logout", oauthHandler.BeginLogout)
+		r.Post("/logout", oauthHandler.Logout)
+
+		// Consentimento dado pelo usuário autenticado na página de login do frontend
+		r.With(authMiddleware).Post("/authorize", oauthHandler.Consent)
+	})
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/go-chi/chi/v5"
+
+	"auth-template/internal/handlers"
+)
+
+func SetupOrganizationRoutes(r chi.Router, authHandler *handlers.AuthHandler, organizationHandler *handlers.OrganizationHandler, invitationHandler *handlers.InvitationHandler) {
+	r.Route("/organizations", func(r chi.Router) {
+		// Cadastro de quem ainda não tem conta, autenticado pelo próprio link do convite
+		r.Post("/invitations/register", invitationHandler.RegisterWithInvitation)
+
+		r.Group(func(r chi.Router) {
+			r.Use(authHandler.AuthMiddleware)
+			r.Use(authHandler.RequireUserSession)
+
+			r.Post("/", organizationHandler.CreateOrganization)
+			r.Get("/", organizationHandler.ListOrganizations)
+			r.Post("/invitations/accept", invitationHandler.AcceptInvitation)
+
+			// Membros e convites da organização selecionada no token (claim tenant_id)
+			r.Route("/current/members", func(r chi.Router) {
+				r.Get("/", organizationHandler.ListMembers)
+				r.Put("/{userID}", organizationHandler.UpdateMember)
+				r.Delete("/{userID}", organizationHandler.RemoveMember)
+			})
+			r.Route("/current/invitations", func(r chi.Router) {
+				r.Post("/", invitationHandler.CreateInvitation)
+				r.Get("/", invitationHandler.ListInvitations)
+				r.Delete("/{id}", invitationHandler.RevokeInvitation)
+			})
+		})
+	})
+}
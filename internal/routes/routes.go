@@ -15,11 +15,21 @@ func SetupRoutes(
 	r chi.Router,
 	log *logger.Logger,
 	authHandler *handlers.AuthHandler,
+	mfaHandler *handlers.MFAHandler,
+	webAuthnHandler *handlers.WebAuthnHandler,
+	oidcHandler *handlers.OIDCHandler,
+	oauthHandler *handlers.OAuthHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	roleHandler *handlers.RoleHandler,
+	organizationHandler *handlers.OrganizationHandler,
+	invitationHandler *handlers.InvitationHandler,
+	userAdminHandler *handlers.UserAdminHandler,
+	dataExportHandler *handlers.DataExportHandler,
 	healthHandler *handlers.HealthHandler,
 ) {
 	// Middleware básicos
 	r.Use(chimiddleware.RequestID)
-	r.Use(chimiddleware.RealIP)
+	r.Use(middleware.ClientInfo)
 	r.Use(chimiddleware.Recoverer)
 	r.Use(middleware.NewErrorHandler(log).Handle)
 
@@ -32,6 +42,10 @@ func SetupRoutes(
 	})
 
 	// Setup das rotas
-	SetupAuthRoutes(r, authHandler)
+	SetupAuthRoutes(r, authHandler, mfaHandler, webAuthnHandler, oidcHandler, apiKeyHandler, dataExportHandler)
+	// O consentimento OAuth exige a sessão do usuário; chaves de API não autorizam clientes terceiros
+	SetupOAuthRoutes(r, oauthHandler, chi.Chain(authHandler.AuthMiddleware, authHandler.RequireUserSession).Handler)
+	SetupAdminRoutes(r, authHandler, roleHandler, userAdminHandler)
+	SetupOrganizationRoutes(r, authHandler, organizationHandler, invitationHandler)
 	SetupHealthRoutes(r, healthHandler, authHandler.AuthMiddleware)
 }
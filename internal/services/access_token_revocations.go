@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"auth-template/pkg/auth"
+)
+
+// maxRevocationCacheEntries limita a memória do cache; ao atingi-lo, as entradas expiradas são descartadas
+// e, se ainda estiver cheio, o cache é esvaziado
+const maxRevocationCacheEntries = 10000
+
+type revocationCacheEntry struct {
+	revoked   bool
+	userID    string
+	sessionID string
+	expiresAt time.Time
+}
+
+// AccessTokenRevocations verifica se um access token foi revogado (pelo jti, pela sessão ou pela marca de
+// revogação do usuário) sem uma consulta ao Redis a cada requisição. O resultado de cada jti fica em cache
+// por cacheTTL; um token revogado permanece revogado até expirar. As revogações feitas nesta instância
+// valem imediatamente, e as feitas em outras instâncias, em até cacheTTL.
+type AccessTokenRevocations struct {
+	blacklist *TokenBlacklist
+	accessTTL time.Duration
+	userTTL   time.Duration
+	cacheTTL  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]revocationCacheEntry
+}
+
+// NewAccessTokenRevocations cria o verificador. accessTTL é a validade dos access tokens e userTTL a do token
+// de maior duração, usada na marca de revogação do usuário.
+func NewAccessTokenRevocations(blacklist *TokenBlacklist, accessTTL, userTTL, cacheTTL time.Duration) *AccessTokenRevocations {
+	return &AccessTokenRevocations{
+		blacklist: blacklist,
+		accessTTL: accessTTL,
+		userTTL:   userTTL,
+		cacheTTL:  cacheTTL,
+		entries:   make(map[string]revocationCacheEntry),
+	}
+}
+
+// IsRevoked verifica se o access token foi revogado. Tokens sem jti, emitidos antes da revogação por jti,
+// são sempre consultados no Redis.
+func (r *AccessTokenRevocations) IsRevoked(ctx context.Context, claims *auth.Claims) (bool, error) {
+	now := time.Now()
+	if claims.Id != "" {
+		r.mu.Lock()
+		entry, ok := r.entries[claims.Id]
+		r.mu.Unlock()
+		if ok && now.Before(entry.expiresAt) {
+			return entry.revoked, nil
+		}
+	}
+
+	revoked, err := r.blacklist.IsTokenRevoked(ctx, claims)
+	if err != nil {
+		return false, err
+	}
+
+	if claims.Id != "" {
+		expiresAt := now.Add(r.cacheTTL)
+		if revoked {
+			expiresAt = time.Unix(claims.ExpiresAt, 0)
+		}
+		r.store(claims.Id, revocationCacheEntry{
+			revoked:   revoked,
+			userID:    claims.UserID,
+			sessionID: claims.SessionID,
+			expiresAt: expiresAt,
+		})
+	}
+	return revoked, nil
+}
+
+// RevokeToken revoga o access token pelo jti até a sua expiração
+func (r *AccessTokenRevocations) RevokeToken(ctx context.Context, claims *auth.Claims) error {
+	if claims.Id == "" {
+		return nil
+	}
+	if err := r.blacklist.RevokeTokenID(ctx, claims.Id, claims.ExpiresAt); err != nil {
+		return err
+	}
+	r.store(claims.Id, revocationCacheEntry{
+		revoked:   true,
+		userID:    claims.UserID,
+		sessionID: claims.SessionID,
+		expiresAt: time.Unix(claims.ExpiresAt, 0),
+	})
+	return nil
+}
+
+// RevokeSession revoga os access tokens da sessão
+func (r *AccessTokenRevocations) RevokeSession(ctx context.Context, sessionID string) error {
+	if err := r.blacklist.RevokeSession(ctx, sessionID, r.accessTTL); err != nil {
+		return err
+	}
+	r.forget(func(entry revocationCacheEntry) bool { return entry.sessionID == sessionID })
+	return nil
+}
+
+// RevokeUser revoga todos os tokens do usuário emitidos até agora (access e refresh)
+func (r *AccessTokenRevocations) RevokeUser(ctx context.Context, userID string) error {
+	if err := r.blacklist.RevokeUserTokens(ctx, userID, r.userTTL); err != nil {
+		return err
+	}
+	r.forget(func(entry revocationCacheEntry) bool { return entry.userID == userID })
+	return nil
+}
+
+func (r *AccessTokenRevocations) store(jti string, entry revocationCacheEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.entries) >= maxRevocationCacheEntries {
+		now := time.Now()
+		for key, cached := range r.entries {
+			if !now.Before(cached.expiresAt) {
+				delete(r.entries, key)
+			}
+		}
+		if len(r.entries) >= maxRevocationCacheEntries {
+			r.entries = make(map[string]revocationCacheEntry)
+		}
+	}
+	r.entries[jti] = entry
+}
+
+// forget descarta do cache os resultados "não revogado" que atendem ao filtro
+func (r *AccessTokenRevocations) forget(match func(revocationCacheEntry) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, entry := range r.entries {
+		if !entry.revoked && match(entry) {
+			delete(r.entries, key)
+		}
+	}
+}
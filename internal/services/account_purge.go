@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"auth-template/internal/config"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/pkg/logger"
+)
+
+// accountPurgeBatchSize limita as contas removidas a cada consulta, para não manter transações longas
+const accountPurgeBatchSize = 100
+
+// AccountPurger remove definitivamente as contas excluídas pelos próprios usuários cujo prazo de carência
+// venceu. Cada remoção apaga também os eventos de segurança do usuário e deixa apenas o registro anonimizado em
+// account_erasures e o evento account_purged, ambos com o antigo ID.
+type AccountPurger struct {
+	userRepo       repository.UserRepository
+	securityEvents *SecurityEvents
+	config         *config.Config
+	log            *logger.Logger
+}
+
+func NewAccountPurger(cfg *config.Config, userRepo repository.UserRepository, securityEvents *SecurityEvents, log *logger.Logger) *AccountPurger {
+	return &AccountPurger{
+		userRepo:       userRepo,
+		securityEvents: securityEvents,
+		config:         cfg,
+		log:            log,
+	}
+}
+
+// Run executa a remoção periodicamente até o contexto ser cancelado. Com várias instâncias, a condição da
+// remoção garante que cada conta seja apagada e registrada uma única vez.
+func (p *AccountPurger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.config.Auth.AccountPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := p.PurgeDue(ctx); err != nil {
+			p.log.Error("Erro na remoção das contas excluídas: %v", err)
+		}
+	}
+}
+
+// PurgeDue remove as contas com o prazo de carência vencido e retorna quantas foram removidas
+func (p *AccountPurger) PurgeDue(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		now := time.Now()
+		users, err := p.userRepo.FindDueForPurge(ctx, now, accountPurgeBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("erro ao buscar contas excluídas: %w", err)
+		}
+
+		for i := range users {
+			ok, err := p.userRepo.Purge(ctx, &users[i], now)
+			if err != nil {
+				return purged, fmt.Errorf("erro ao remover conta: %w", err)
+			}
+			if !ok {
+				continue
+			}
+			purged++
+
+			userID := fmt.Sprintf("%d", users[i].ID)
+			if err := p.securityEvents.DeleteByUser(ctx, userID); err != nil {
+				p.log.Error("Erro ao remover os eventos de segurança da conta %s: %v", userID, err)
+			}
+			p.securityEvents.Emit(ctx, SecurityEvent{
+				Type:   SecurityEventAccountPurged,
+				UserID: userID,
+			})
+		}
+
+		if len(users) < accountPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
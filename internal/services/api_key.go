@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
+	"auth-template/pkg/validation"
+)
+
+const (
+	maxAPIKeyNameLength = 100
+	maxAPIKeysPerUser   = 25
+	// apiKeyTouchInterval evita uma escrita no banco a cada requisição só para atualizar last_used_at
+	apiKeyTouchInterval = time.Minute
+)
+
+type APIKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, userRepo repository.UserRepository) service.APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *APIKeyService) CreateKey(ctx context.Context, userID, name string, scopes []string, expiresAt *time.Time) (*service.CreatedAPIKey, error) {
+	name = validation.SanitizeString(name)
+	if name == "" {
+		return nil, apperrors.NewValidationError("nome da chave é obrigatório")
+	}
+	if len(name) > maxAPIKeyNameLength {
+		return nil, apperrors.NewValidationError("nome da chave muito longo")
+	}
+	if len(scopes) == 0 {
+		return nil, apperrors.NewValidationError("informe ao menos um escopo")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(service.APIKeyScopes, scope) {
+			return nil, apperrors.NewValidationError(fmt.Sprintf("escopo inválido: %s", scope))
+		}
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, apperrors.NewValidationError("a data de expiração deve estar no futuro")
+	}
+
+	count, err := s.apiKeyRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao contar chaves de API: %w", err)
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, apperrors.NewValidationError("limite de chaves de API atingido")
+	}
+
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("ID de usuário inválido: %w", err)
+	}
+
+	key, prefix, secret, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+
+	apiKey := &entity.APIKey{
+		UserID:     uint(uid),
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: auth.HashToken(secret),
+		Scopes:     strings.Join(slices.Compact(scopes), " "),
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, fmt.Errorf("erro ao salvar chave de API: %w", err)
+	}
+
+	return &service.CreatedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context, userID string) ([]entity.APIKey, error) {
+	keys, err := s.apiKeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar chaves de API: %w", err)
+	}
+	return keys, nil
+}
+
+// DeleteKey revoga a chave. Como cada requisição consulta o banco, a revogação vale imediatamente.
+func (s *APIKeyService) DeleteKey(ctx context.Context, userID, id string) error {
+	deleted, err := s.apiKeyRepo.Delete(ctx, userID, id)
+	if err != nil {
+		return fmt.Errorf("erro ao remover chave de API: %w", err)
+	}
+	if !deleted {
+		return apperrors.NewNotFoundError("chave de API não encontrada")
+	}
+	return nil
+}
+
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*auth.Claims, error) {
+	prefix, secret, ok := auth.ParseAPIKey(key)
+	if !ok {
+		return nil, apperrors.NewUnauthorizedError("chave de API inválida")
+	}
+
+	apiKey, err := s.apiKeyRepo.FindByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar chave de API: %w", err)
+	}
+	if apiKey == nil || !auth.VerifyAPIKeySecret(secret, apiKey.SecretHash) {
+		return nil, apperrors.NewUnauthorizedError("chave de API inválida")
+	}
+
+	now := time.Now()
+	if apiKey.IsExpired(now) {
+		return nil, apperrors.NewUnauthorizedError("chave de API expirada")
+	}
+
+	userID := strconv.FormatUint(uint64(apiKey.UserID), 10)
+	// Usuários removidos (exclusão lógica) não são encontrados e, como os desativados, perdem o acesso pelas chaves
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil || user.IsDisabled() {
+		return nil, apperrors.NewUnauthorizedError("chave de API inválida")
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.apiKeyRepo.Touch(ctx, apiKey.ID, now); err != nil {
+			return nil, fmt.Errorf("erro ao registrar uso da chave de API: %w", err)
+		}
+	}
+
+	return &auth.Claims{
+		UserID: userID,
+		Type:   auth.TokenTypeAPIKey,
+		Scope:  apiKey.Scopes,
+	}, nil
+}
@@ -3,7 +3,12 @@ package services
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/bcrypt"
 
 	"auth-template/internal/config"
@@ -12,50 +17,105 @@ import (
 	"auth-template/internal/interfaces/repository"
 	"auth-template/internal/interfaces/service"
 	"auth-template/pkg/auth"
+	"auth-template/pkg/logger"
+	"auth-template/pkg/mailer"
 	"auth-template/pkg/validation"
 )
 
+// sessionIDBytes é o tamanho do identificador das sessões, enviado na claim sid
+const sessionIDBytes = 16
+
 type AuthService struct {
 	userRepo       repository.UserRepository
+	mfaRepo        repository.MFARepository
+	webAuthnRepo   repository.WebAuthnRepository
+	sessionRepo    repository.SessionRepository
+	roleRepo       repository.RoleRepository
+	orgRepo        repository.OrganizationRepository
+	mfaChallenges  *MFAChallengeStore
 	tokenManager   *auth.TokenManager
 	tokenBlacklist *TokenBlacklist
+	revocations    *AccessTokenRevocations
+	families       *RefreshTokenFamilyStore
+	securityEvents *SecurityEvents
+	oneTimeTokens  *OneTimeTokenStore
+	rateLimits     *RateLimitStore
+	loginThrottle  *LoginThrottle
+	emailChanges   *EmailChangeStore
+	mailer         mailer.Mailer
 	config         *config.Config
+	log            *logger.Logger
 }
 
-func NewAuthService(userRepo repository.UserRepository, tokenManager *auth.TokenManager, tokenBlacklist *TokenBlacklist, config *config.Config) service.AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	webAuthnRepo repository.WebAuthnRepository,
+	sessionRepo repository.SessionRepository,
+	roleRepo repository.RoleRepository,
+	orgRepo repository.OrganizationRepository,
+	mfaChallenges *MFAChallengeStore,
+	tokenManager *auth.TokenManager,
+	tokenBlacklist *TokenBlacklist,
+	revocations *AccessTokenRevocations,
+	families *RefreshTokenFamilyStore,
+	securityEvents *SecurityEvents,
+	oneTimeTokens *OneTimeTokenStore,
+	rateLimits *RateLimitStore,
+	loginThrottle *LoginThrottle,
+	emailChanges *EmailChangeStore,
+	mailer mailer.Mailer,
+	config *config.Config,
+	log *logger.Logger,
+) service.AuthService {
 	return &AuthService{
 		userRepo:       userRepo,
+		mfaRepo:        mfaRepo,
+		webAuthnRepo:   webAuthnRepo,
+		sessionRepo:    sessionRepo,
+		roleRepo:       roleRepo,
+		orgRepo:        orgRepo,
+		mfaChallenges:  mfaChallenges,
 		tokenManager:   tokenManager,
 		tokenBlacklist: tokenBlacklist,
+		revocations:    revocations,
+		families:       families,
+		securityEvents: securityEvents,
+		oneTimeTokens:  oneTimeTokens,
+		rateLimits:     rateLimits,
+		loginThrottle:  loginThrottle,
+		emailChanges:   emailChanges,
+		mailer:         mailer,
 		config:         config,
+		log:            log,
 	}
 }
 
-func (s *AuthService) Register(ctx context.Context, email, password string) error {
+func (s *AuthService) Register(ctx context.Context, email, password string) (*entity.User, error) {
 	// Validar email
 	sanitizedEmail, err := validation.ValidateEmail(email)
 	if err != nil {
-		return apperrors.NewValidationError("email inválido")
+		return nil, apperrors.NewValidationError("email inválido")
 	}
 
 	// Validar senha
 	if err := validation.ValidatePassword(password, validation.DefaultPasswordPolicy); err != nil {
-		return apperrors.NewValidationError(err.Error())
+		return nil, apperrors.NewValidationError(err.Error())
 	}
 
 	// Verificar se email já existe
 	exists, err := s.userRepo.ExistsByEmail(ctx, sanitizedEmail)
 	if err != nil {
-		return fmt.Errorf("erro ao verificar email: %w", err)
+		return nil, fmt.Errorf("erro ao verificar email: %w", err)
 	}
 	if exists {
-		return apperrors.NewConflictError("email já cadastrado")
+		return nil, apperrors.NewConflictError("email já cadastrado")
 	}
 
 	// Hash da senha
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return fmt.Errorf("erro ao gerar hash da senha: %w", err)
+		return nil, fmt.Errorf("erro ao gerar hash da senha: %w", err)
 	}
 
 	// Criar usuário
@@ -65,113 +125,1163 @@ func (s *AuthService) Register(ctx context.Context, email, password string) erro
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
-		return fmt.Errorf("erro ao criar usuário: %w", err)
+		return nil, fmt.Errorf("erro ao criar usuário: %w", err)
+	}
+	if err := s.roleRepo.AssignByName(ctx, user.ID, entity.RoleUser); err != nil {
+		return nil, fmt.Errorf("erro ao atribuir papel padrão: %w", err)
 	}
 
-	return nil
+	// Enviar email de verificação. A conta já existe: uma falha aqui não deve recusar o registro, pois a nova
+	// tentativa do cliente receberia "email já cadastrado"; o usuário pode pedir o reenvio.
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		s.log.Error("Erro ao enviar email de verificação: %v", err)
+	}
+
+	return user, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (*service.TokenPair, error) {
-	// Buscar usuário pelo email
+func (s *AuthService) Login(ctx context.Context, email, password string) (*service.LoginResult, error) {
+	// Recusar contas e clientes bloqueados por excesso de tentativas antes de verificar a senha
+	ipAddress := service.ClientInfoFromContext(ctx).IPAddress
+	if err := s.loginThrottle.Check(ctx, email, ipAddress); err != nil {
+		return nil, err
+	}
+
+	// Buscar usuário pelo email, incluindo as contas excluídas que ainda podem ser restauradas
 	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
-		return nil, apperrors.NewUnauthorizedError("credenciais inválidas")
+		user, err = s.userRepo.FindPendingDeletionByEmail(ctx, email)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+		}
+		if user == nil {
+			return nil, s.loginFailure(ctx, email, ipAddress)
+		}
 	}
 
 	// Verificar senha
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return nil, apperrors.NewUnauthorizedError("credenciais inválidas")
+		return nil, s.loginFailure(ctx, email, ipAddress)
+	}
+
+	if err := s.loginThrottle.Success(ctx, email, ipAddress); err != nil {
+		return nil, err
+	}
+
+	return s.StartSession(ctx, user, []string{auth.AMRPassword})
+}
+
+// loginFailure registra a falha do login por senha e retorna "credenciais inválidas" ou, quando a falha
+// inicia um bloqueio, o erro do bloqueio
+func (s *AuthService) loginFailure(ctx context.Context, email, ipAddress string) error {
+	if err := s.loginThrottle.Failure(ctx, email, ipAddress); err != nil {
+		return err
+	}
+	return apperrors.NewUnauthorizedError("credenciais inválidas")
+}
+
+// verifyPassword confere a senha do usuário autenticado sob o mesmo bloqueio do login por senha, para que um
+// access token roubado não permita testar senhas sem limite. message é o erro de uma senha incorreta.
+func (s *AuthService) verifyPassword(ctx context.Context, user *entity.User, password, message string) error {
+	ipAddress := service.ClientInfoFromContext(ctx).IPAddress
+	if err := s.loginThrottle.Check(ctx, user.Email, ipAddress); err != nil {
+		return err
+	}
+	if !user.CheckPassword(password) {
+		if err := s.loginThrottle.Failure(ctx, user.Email, ipAddress); err != nil {
+			return err
+		}
+		return apperrors.NewUnauthorizedError(message)
+	}
+	return s.loginThrottle.Success(ctx, user.Email, ipAddress)
+}
+
+// checkSignIn aplica as regras de acesso comuns a todos os métodos de login (senha, magic link, OIDC e passkey):
+// recusa contas excluídas (exceto as ainda no prazo de carência), desativadas, com o email não confirmado
+// (quando configurado). O bloqueio por excesso de senhas erradas vale só para o login com senha e é aplicado em Login.
+func (s *AuthService) checkSignIn(user *entity.User) error {
+	if user.DeletedAt.Valid && !user.IsPendingDeletion() {
+		return apperrors.NewUnauthorizedError("credenciais inválidas")
+	}
+	if user.IsDisabled() {
+		return apperrors.NewForbiddenError("conta desativada")
+	}
+
+	// Verificar se o email foi confirmado
+	if s.config.Auth.RequireEmailVerification && !user.IsEmailVerified() {
+		return apperrors.NewForbiddenError("email não verificado")
+	}
+
+	return nil
+}
+
+// StartSession conclui a autenticação do primeiro fator: aplica as regras de acesso da conta e, se o usuário
+// possuir MFA ativo, cria o desafio em vez de emitir os tokens. amr identifica o primeiro fator.
+func (s *AuthService) StartSession(ctx context.Context, user *entity.User, amr []string) (*service.LoginResult, error) {
+	if err := s.checkSignIn(user); err != nil {
+		return nil, err
 	}
 
-	// Gerar tokens
 	userID := fmt.Sprintf("%d", user.ID)
-	accessToken, err := s.tokenManager.GenerateToken(userID, auth.TokenTypeAccess)
+
+	// Exigir o segundo fator quando o MFA estiver ativo
+	methods, err := s.mfaMethods(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao gerar access token: %w", err)
+		return nil, err
+	}
+	if len(methods) > 0 {
+		mfaToken, err := s.mfaChallenges.Create(ctx, userID, amr)
+		if err != nil {
+			return nil, err
+		}
+		return &service.LoginResult{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			MFAMethods:  methods,
+		}, nil
+	}
+
+	if err := s.restorePendingDeletion(ctx, user); err != nil {
+		return nil, err
 	}
 
-	refreshToken, err := s.tokenManager.GenerateToken(userID, auth.TokenTypeRefresh)
+	// Gerar tokens
+	tokens, err := s.issueTokens(ctx, userID, time.Now().Unix(), amr, nil, "", "")
 	if err != nil {
-		return nil, fmt.Errorf("erro ao gerar refresh token: %w", err)
+		return nil, err
 	}
+	return &service.LoginResult{Tokens: tokens}, nil
+}
 
-	return &service.TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+// CompleteLogin emite os tokens de um usuário já autenticado (segundo fator ou passkey). As regras de acesso da
+// conta são conferidas de novo, pois ela pode ter sido desativada depois do primeiro fator ou nem ter passado por
+// StartSession.
+func (s *AuthService) CompleteLogin(ctx context.Context, userID string, amr []string) (*service.TokenPair, error) {
+	user, err := s.userRepo.FindByIDWithDeleted(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil {
+		return nil, apperrors.NewUnauthorizedError("credenciais inválidas")
+	}
+	if err := s.checkSignIn(user); err != nil {
+		return nil, err
+	}
+	if err := s.restorePendingDeletion(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, userID, time.Now().Unix(), amr, nil, "", "")
+}
+
+// restorePendingDeletion cancela a exclusão da conta no prazo de carência. É chamada apenas ao emitir os tokens,
+// depois do segundo fator: a senha sozinha não basta para desfazer a exclusão.
+func (s *AuthService) restorePendingDeletion(ctx context.Context, user *entity.User) error {
+	if !user.IsPendingDeletion() {
+		return nil
+	}
+	if _, err := s.userRepo.Restore(ctx, fmt.Sprintf("%d", user.ID)); err != nil {
+		return fmt.Errorf("erro ao restaurar conta: %w", err)
+	}
+	user.DeletedAt.Valid = false
+	user.PurgeAt = nil
+	return nil
 }
 
 func (s *AuthService) RefreshTokens(ctx context.Context, refreshToken string) (*service.TokenPair, error) {
+	return s.refresh(ctx, refreshToken, "")
+}
+
+// SwitchOrganization renova os tokens da sessão do refresh token com outra organização na claim tenant_id
+func (s *AuthService) SwitchOrganization(ctx context.Context, refreshToken, organizationID string) (*service.TokenPair, error) {
+	if organizationID == "" {
+		return nil, apperrors.NewValidationError("organização é obrigatória")
+	}
+	return s.refresh(ctx, refreshToken, organizationID)
+}
+
+// refresh rotaciona o refresh token e emite um novo par; com organizationID, também troca a organização da sessão
+func (s *AuthService) refresh(ctx context.Context, refreshToken, organizationID string) (*service.TokenPair, error) {
 	// Validar refresh token
 	claims, err := s.tokenManager.ValidateToken(refreshToken, auth.TokenTypeRefresh)
 	if err != nil {
 		return nil, apperrors.NewUnauthorizedError("refresh token inválido")
 	}
 
-	// Verificar se o token está na blacklist
-	blacklisted, err := s.tokenBlacklist.IsBlacklisted(ctx, refreshToken)
+	// Tokens emitidos para clientes OAuth só são renovados em /oauth/token
+	if claims.ClientID != "" {
+		return nil, apperrors.NewUnauthorizedError("refresh token inválido")
+	}
+
+	// Verificar se os tokens do usuário foram revogados (ex: troca de senha)
+	revoked, err := s.tokenBlacklist.IsUserTokenRevoked(ctx, claims)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao verificar token: %w", err)
 	}
-	if blacklisted {
+	if revoked {
 		return nil, apperrors.NewUnauthorizedError("refresh token inválido")
 	}
 
-	// Adicionar o token atual à blacklist
-	if err := s.tokenBlacklist.Add(ctx, refreshToken, s.config.Auth.RefreshTokenTTL); err != nil {
-		return nil, fmt.Errorf("erro ao invalidar token: %w", err)
+	// Recusar tokens de sessões revogadas; tokens anteriores ao registro de sessões ganham uma sessão nova
+	var session *entity.Session
+	if claims.SessionID != "" {
+		session, err = s.sessionRepo.FindByID(ctx, claims.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar sessão: %w", err)
+		}
+		if session == nil || session.IsRevoked() || fmt.Sprintf("%d", session.UserID) != claims.UserID {
+			return nil, apperrors.NewUnauthorizedError("refresh token inválido")
+		}
 	}
 
-	// Gerar novos tokens
-	accessToken, err := s.tokenManager.GenerateToken(claims.UserID, auth.TokenTypeAccess)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao gerar access token: %w", err)
+	// A participação na organização é conferida antes da rotação, para que uma troca recusada não consuma o token
+	var membership *entity.Membership
+	if organizationID != "" {
+		if session == nil {
+			return nil, apperrors.NewUnauthorizedError("refresh token inválido")
+		}
+		membership, err = s.membership(ctx, organizationID, claims.UserID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	newRefreshToken, err := s.tokenManager.GenerateToken(claims.UserID, auth.TokenTypeRefresh)
+	// Invalidar o token atual; um token já rotacionado encerra a família inteira
+	familyID, jti, err := rotateRefreshToken(ctx, s.families, s.tokenBlacklist, s.securityEvents, refreshToken, claims)
+	if err == ErrRefreshTokenReuse || err == ErrRefreshFamilyRevoked {
+		return nil, apperrors.NewUnauthorizedError("refresh token inválido")
+	}
 	if err != nil {
-		return nil, fmt.Errorf("erro ao gerar refresh token: %w", err)
+		return nil, err
 	}
 
-	return &service.TokenPair{
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
-	}, nil
+	// A troca só é gravada depois da rotação: um token reutilizado não altera a organização da sessão
+	if membership != nil {
+		if err := s.sessionRepo.SetOrganization(ctx, session.ID, membership.OrganizationID); err != nil {
+			return nil, fmt.Errorf("erro ao trocar a organização da sessão: %w", err)
+		}
+		session.OrganizationID = &membership.OrganizationID
+	}
+
+	// Registrar o uso da sessão
+	if claims.SessionID != "" {
+		client := service.ClientInfoFromContext(ctx)
+		if err := s.sessionRepo.Touch(ctx, claims.SessionID, client.IPAddress, time.Now()); err != nil {
+			return nil, fmt.Errorf("erro ao atualizar sessão: %w", err)
+		}
+	}
+
+	// Gerar novos tokens, preservando o momento e os métodos da autenticação original
+	return s.issueTokens(ctx, claims.UserID, claims.AuthTime, claims.AMR, session, familyID, jti)
 }
 
-func (s *AuthService) ValidateAccessToken(ctx context.Context, token string) error {
-	_, err := s.tokenManager.ValidateToken(token, auth.TokenTypeAccess)
+// ValidateAccessToken valida um access token de primeira parte. Tokens delegados a clientes OAuth
+// são recusados: eles só dão acesso aos escopos concedidos, não à gestão da conta. Tokens revogados
+// (logout, sessão encerrada, troca de senha) são recusados antes da expiração.
+func (s *AuthService) ValidateAccessToken(ctx context.Context, token string) (*auth.Claims, error) {
+	claims, err := s.tokenManager.ValidateToken(token, auth.TokenTypeAccess)
+	if err != nil || claims.ClientID != "" {
+		return nil, apperrors.NewUnauthorizedError("token inválido")
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, claims)
 	if err != nil {
-		return apperrors.NewUnauthorizedError("token inválido")
+		return nil, fmt.Errorf("erro ao verificar token: %w", err)
 	}
-	return nil
+	if revoked {
+		return nil, apperrors.NewUnauthorizedError("token inválido")
+	}
+	return claims, nil
 }
 
-func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+// Logout encerra a sessão do refresh token. O access token, quando informado, é revogado imediatamente.
+func (s *AuthService) Logout(ctx context.Context, refreshToken, accessToken string) error {
 	// Validar refresh token
-	_, err := s.tokenManager.ValidateToken(refreshToken, auth.TokenTypeRefresh)
+	claims, err := s.tokenManager.ValidateToken(refreshToken, auth.TokenTypeRefresh)
 	if err != nil {
 		return apperrors.NewUnauthorizedError("refresh token inválido")
 	}
 
-	// Adicionar o token à blacklist
-	if err := s.tokenBlacklist.Add(ctx, refreshToken, s.config.Auth.RefreshTokenTTL); err != nil {
-		return fmt.Errorf("erro ao invalidar token: %w", err)
+	if accessToken != "" {
+		accessClaims, err := s.tokenManager.ValidateToken(accessToken, auth.TokenTypeAccess)
+		if err == nil && accessClaims.UserID == claims.UserID && accessClaims.ClientID == "" {
+			if err := s.revocations.RevokeToken(ctx, accessClaims); err != nil {
+				return fmt.Errorf("erro ao revogar access token: %w", err)
+			}
+		}
+	}
+
+	// Encerrar a sessão e a família do token
+	if claims.SessionID != "" {
+		if _, err := s.sessionRepo.Revoke(ctx, claims.UserID, claims.SessionID, time.Now()); err != nil {
+			return fmt.Errorf("erro ao encerrar sessão: %w", err)
+		}
+		if err := s.revocations.RevokeSession(ctx, claims.SessionID); err != nil {
+			return fmt.Errorf("erro ao revogar tokens da sessão: %w", err)
+		}
+	}
+	return revokeRefreshToken(ctx, s.families, s.tokenBlacklist, refreshToken, claims)
+}
+
+func (s *AuthService) ListSessions(ctx context.Context, userID string) ([]entity.Session, error) {
+	sessions, err := s.sessionRepo.FindActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar sessões: %w", err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession encerra uma sessão do usuário: seus refresh tokens deixam de ser renovados e seus
+// access tokens deixam de ser aceitos
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	revoked, err := s.sessionRepo.Revoke(ctx, userID, sessionID, time.Now())
+	if err != nil {
+		return fmt.Errorf("erro ao encerrar sessão: %w", err)
+	}
+	if !revoked {
+		return apperrors.NewNotFoundError("sessão não encontrada")
+	}
+	if err := s.revocations.RevokeSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("erro ao revogar tokens da sessão: %w", err)
+	}
+	return nil
+}
+
+// RevokeOtherSessions encerra todas as sessões do usuário, exceto a atual
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userID, currentSessionID string) error {
+	sessionIDs, err := s.sessionRepo.RevokeAllExcept(ctx, userID, currentSessionID, time.Now())
+	if err != nil {
+		return fmt.Errorf("erro ao encerrar sessões: %w", err)
+	}
+	for _, sessionID := range sessionIDs {
+		if err := s.revocations.RevokeSession(ctx, sessionID); err != nil {
+			return fmt.Errorf("erro ao revogar tokens da sessão: %w", err)
+		}
 	}
+	return nil
+}
 
+// RevokeUserTokens invalida imediatamente todos os tokens e sessões do usuário (ex: ação administrativa
+// ou redefinição de senha). Os tokens emitidos depois da chamada não são afetados.
+func (s *AuthService) RevokeUserTokens(ctx context.Context, userID string) error {
+	if err := s.revocations.RevokeUser(ctx, userID); err != nil {
+		return fmt.Errorf("erro ao revogar tokens: %w", err)
+	}
+	if _, err := s.sessionRepo.RevokeAllExcept(ctx, userID, "", time.Now()); err != nil {
+		return fmt.Errorf("erro ao encerrar sessões: %w", err)
+	}
 	return nil
 }
 
 func (s *AuthService) GetUserFromToken(ctx context.Context, token string) (*entity.User, error) {
-	claims, err := s.tokenManager.ValidateToken(token, auth.TokenTypeAccess)
+	claims, err := s.ValidateAccessToken(ctx, token)
 	if err != nil {
-		return nil, apperrors.NewUnauthorizedError("token inválido")
+		return nil, err
 	}
 
-	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	return s.GetUser(ctx, claims.UserID)
+}
+
+func (s *AuthService) GetUser(ctx context.Context, userID string) (*entity.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
 	}
 
 	return user, nil
 }
+
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	// Consumir token de verificação
+	userID, err := s.oneTimeTokens.Consume(ctx, TokenPurposeEmailVerification, token)
+	if err == ErrOneTimeTokenNotFound {
+		return apperrors.NewValidationError("token de verificação inválido ou expirado")
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao validar token de verificação: %w", err)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return apperrors.NewValidationError("token de verificação inválido ou expirado")
+	}
+
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	// Marcar email como verificado
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.MarkEmailVerified(ctx, user.ID, now); err != nil {
+		return fmt.Errorf("erro ao atualizar usuário: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AuthService) ResendVerificationEmail(ctx context.Context, email string) error {
+	sanitizedEmail, err := validation.ValidateEmail(email)
+	if err != nil {
+		return apperrors.NewValidationError("email inválido")
+	}
+
+	// Limitar antes da busca, para que o limite valha igualmente para emails cadastrados ou não
+	window := s.config.Auth.VerificationResendRateWindow
+	allowed, err := s.rateLimits.Allow(ctx, "verification-resend:"+auth.HashToken(sanitizedEmail), s.config.Auth.VerificationResendRateLimit, window)
+	if err != nil {
+		return err
+	}
+	if allowed {
+		ipAddress := service.ClientInfoFromContext(ctx).IPAddress
+		allowed, err = s.rateLimits.Allow(ctx, "verification-resend-ip:"+ipAddress, s.config.Auth.VerificationResendIPRateLimit, window)
+		if err != nil {
+			return err
+		}
+	}
+	if !allowed {
+		return apperrors.NewRateLimitError("muitos pedidos de reenvio; tente novamente mais tarde")
+	}
+
+	// Não revelar se o email existe ou já foi verificado
+	user, err := s.userRepo.FindByEmail(ctx, sanitizedEmail)
+	if err != nil || user.IsEmailVerified() {
+		return nil
+	}
+
+	return s.sendVerificationEmail(ctx, user)
+}
+
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	sanitizedEmail, err := validation.ValidateEmail(email)
+	if err != nil {
+		return apperrors.NewValidationError("email inválido")
+	}
+
+	// Limitar antes da busca, para que o limite valha igualmente para emails cadastrados ou não
+	window := s.config.Auth.PasswordResetRateWindow
+	allowed, err := s.rateLimits.Allow(ctx, "password-reset:"+auth.HashToken(sanitizedEmail), s.config.Auth.PasswordResetRateLimit, window)
+	if err != nil {
+		return err
+	}
+	if allowed {
+		ipAddress := service.ClientInfoFromContext(ctx).IPAddress
+		allowed, err = s.rateLimits.Allow(ctx, "password-reset-ip:"+ipAddress, s.config.Auth.PasswordResetIPRateLimit, window)
+		if err != nil {
+			return err
+		}
+	}
+	if !allowed {
+		return apperrors.NewRateLimitError("muitos pedidos de redefinição; tente novamente mais tarde")
+	}
+
+	// Buscar o usuário e gerar o token em segundo plano, junto com o envio, para que nem o conteúdo nem o tempo
+	// da resposta dependam da existência do email
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		user, err := s.userRepo.FindByEmail(ctx, sanitizedEmail)
+		if err != nil {
+			return
+		}
+		if err := s.SendPasswordResetEmail(ctx, user); err != nil {
+			s.log.Error("Erro ao enviar email de recuperação: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// SendPasswordResetEmail envia o link de redefinição de senha sem o limite de pedidos, usado pela redefinição
+// forçada por um administrador
+func (s *AuthService) SendPasswordResetEmail(ctx context.Context, user *entity.User) error {
+	userID := fmt.Sprintf("%d", user.ID)
+	token, err := s.oneTimeTokens.Issue(ctx, TokenPurposePasswordReset, userID, s.config.Auth.PasswordResetTTL)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar token de recuperação: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", s.config.Server.PublicURL, token)
+	body := fmt.Sprintf("Recebemos um pedido para redefinir sua senha. Acesse o link abaixo para criar uma nova senha:\n\n%s\n\nO link expira em %s. Se você não fez este pedido, ignore este email.", link, s.config.Auth.PasswordResetTTL)
+
+	go func() {
+		if err := s.mailer.Send(context.WithoutCancel(ctx), user.Email, "Redefinição de senha", body); err != nil {
+			s.log.Error("Erro ao enviar email de recuperação: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	// Validar a nova senha antes de consumir o token
+	if err := validation.ValidatePassword(newPassword, validation.DefaultPasswordPolicy); err != nil {
+		return apperrors.NewValidationError(err.Error())
+	}
+
+	userID, err := s.oneTimeTokens.Consume(ctx, TokenPurposePasswordReset, token)
+	if err == ErrOneTimeTokenNotFound {
+		return apperrors.NewValidationError("token de recuperação inválido ou expirado")
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao validar token de recuperação: %w", err)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return apperrors.NewValidationError("token de recuperação inválido ou expirado")
+	}
+
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	// Revogar todas as sessões existentes
+	if err := s.RevokeUserTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	// As passkeys continuam valendo depois da redefinição: o usuário é avisado para remover as que não reconhecer,
+	// já que uma passkey cadastrada por quem teve acesso à conta ainda permite o login
+	passkeys, err := s.webAuthnRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar passkeys: %w", err)
+	}
+	if len(passkeys) > 0 {
+		names := make([]string, 0, len(passkeys))
+		for _, passkey := range passkeys {
+			names = append(names, "- "+passkey.Name)
+		}
+		body := fmt.Sprintf("Sua senha foi redefinida e todas as sessões foram encerradas. Estas passkeys continuam cadastradas e permitem entrar na conta:\n\n%s\n\nRemova as que você não reconhecer.", strings.Join(names, "\n"))
+		go func() {
+			if err := s.mailer.Send(context.WithoutCancel(ctx), user.Email, "Senha redefinida", body); err != nil {
+				s.log.Error("Erro ao enviar aviso de redefinição de senha: %v", err)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// RequestMagicLink envia um link de login de uso único para o email e retorna o nonce ao qual o link
+// está vinculado. O nonce deve ser guardado pelo navegador que fez o pedido e apresentado no consumo;
+// ele é retornado mesmo quando o email não existe para não revelar quais endereços estão cadastrados.
+func (s *AuthService) RequestMagicLink(ctx context.Context, email string) (string, error) {
+	sanitizedEmail, err := validation.ValidateEmail(email)
+	if err != nil {
+		return "", apperrors.NewValidationError("email inválido")
+	}
+
+	// Limitar antes da busca, para que o limite valha igualmente para emails cadastrados ou não
+	window := s.config.Auth.MagicLinkRateWindow
+	allowed, err := s.rateLimits.Allow(ctx, "magic-link:"+auth.HashToken(sanitizedEmail), s.config.Auth.MagicLinkRateLimit, window)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", apperrors.NewRateLimitError("muitas solicitações de magic link para este email")
+	}
+	ipAddress := service.ClientInfoFromContext(ctx).IPAddress
+	allowed, err = s.rateLimits.Allow(ctx, "magic-link-ip:"+ipAddress, s.config.Auth.MagicLinkIPRateLimit, window)
+	if err != nil {
+		return "", err
+	}
+	if !allowed {
+		return "", apperrors.NewRateLimitError("muitas solicitações de magic link; tente novamente mais tarde")
+	}
+
+	nonce, err := auth.GenerateRandomToken(oneTimeTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	// Buscar o usuário e gerar o link em segundo plano, junto com o envio, para que nem o conteúdo nem o tempo
+	// da resposta dependam da existência do email
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		user, err := s.userRepo.FindByEmail(ctx, sanitizedEmail)
+		if err != nil {
+			return
+		}
+
+		token, err := s.oneTimeTokens.Issue(ctx, TokenPurposeMagicLink, fmt.Sprintf("%d", user.ID), s.config.Auth.MagicLinkTTL)
+		if err != nil {
+			s.log.Error("Erro ao gerar magic link: %v", err)
+			return
+		}
+
+		// A assinatura vincula o link ao nonce do navegador que o solicitou
+		signedToken := token + "." + auth.Sign(s.config.Auth.MagicLinkSecret, token, nonce)
+
+		link := fmt.Sprintf("%s/magic-link?token=%s", s.config.Server.PublicURL, signedToken)
+		body := fmt.Sprintf("Acesse o link abaixo, no mesmo navegador em que você fez o pedido, para entrar na sua conta:\n\n%s\n\nO link expira em %s e só pode ser usado uma vez. Se você não fez este pedido, ignore este email.", link, s.config.Auth.MagicLinkTTL)
+		if err := s.mailer.Send(ctx, user.Email, "Seu link de acesso", body); err != nil {
+			s.log.Error("Erro ao enviar magic link: %v", err)
+		}
+	}()
+
+	return nonce, nil
+}
+
+// ConsumeMagicLink troca um magic link pelo login do usuário. O link só é aceito junto com o nonce
+// retornado a quem o solicitou, de modo que um email encaminhado não pode ser usado em outro navegador.
+func (s *AuthService) ConsumeMagicLink(ctx context.Context, signedToken, nonce string) (*service.LoginResult, error) {
+	token, signature, ok := strings.Cut(signedToken, ".")
+	if !ok || nonce == "" || !auth.VerifySignature(s.config.Auth.MagicLinkSecret, signature, token, nonce) {
+		return nil, apperrors.NewUnauthorizedError("link inválido ou expirado")
+	}
+
+	userID, err := s.oneTimeTokens.Consume(ctx, TokenPurposeMagicLink, token)
+	if err == ErrOneTimeTokenNotFound {
+		return nil, apperrors.NewUnauthorizedError("link inválido ou expirado")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError("link inválido ou expirado")
+	}
+
+	// Acessar o link comprova a posse do email
+	if !user.IsEmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := s.userRepo.MarkEmailVerified(ctx, user.ID, now); err != nil {
+			return nil, fmt.Errorf("erro ao confirmar email: %w", err)
+		}
+	}
+
+	return s.StartSession(ctx, user, []string{auth.AMREmail})
+}
+
+func (s *AuthService) ChangePassword(ctx context.Context, current *auth.Claims, currentPassword, newPassword string) (*service.TokenPair, error) {
+	userID := current.UserID
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError("token inválido")
+	}
+
+	// Verificar senha atual
+	if err := s.verifyPassword(ctx, user, currentPassword, "senha atual incorreta"); err != nil {
+		return nil, err
+	}
+
+	// Validar nova senha
+	if err := validation.ValidatePassword(newPassword, validation.DefaultPasswordPolicy); err != nil {
+		return nil, apperrors.NewValidationError(err.Error())
+	}
+	if user.CheckPassword(newPassword) {
+		return nil, apperrors.NewValidationError("nova senha deve ser diferente da atual")
+	}
+
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
+		return nil, err
+	}
+
+	// Encerrar as demais sessões; o dispositivo atual continua na sua sessão, com um novo par de tokens
+	session, err := s.sessionRepo.FindByID(ctx, current.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar sessão: %w", err)
+	}
+	if session == nil || session.IsRevoked() {
+		// Tokens anteriores ao registro de sessões: revogar tudo e abrir uma sessão nova
+		if err := s.RevokeUserTokens(ctx, userID); err != nil {
+			return nil, err
+		}
+		session = nil
+	} else {
+		if err := s.RevokeOtherSessions(ctx, userID, session.ID); err != nil {
+			return nil, err
+		}
+		// O access token usado na troca é substituído pelo novo par
+		if err := s.revocations.RevokeToken(ctx, current); err != nil {
+			return nil, fmt.Errorf("erro ao revogar token: %w", err)
+		}
+	}
+
+	return s.issueTokens(ctx, userID, time.Now().Unix(), []string{auth.AMRPassword}, session, "", "")
+}
+
+func (s *AuthService) DeleteAccount(ctx context.Context, current *auth.Claims, password string) (time.Time, error) {
+	userID := current.UserID
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return time.Time{}, apperrors.NewUnauthorizedError("token inválido")
+	}
+
+	// Reautenticação: um token de acesso obtido por terceiros não basta para excluir a conta. Quem entrou por
+	// magic link, passkey ou provedor externo não conhece a senha e comprova a identidade com um login recente.
+	if password != "" {
+		if !user.CheckPassword(password) {
+			return time.Time{}, apperrors.NewUnauthorizedError("senha incorreta")
+		}
+	} else if err := requireRecentAuth(current, s.config.Auth.ReauthMaxAge); err != nil {
+		return time.Time{}, err
+	}
+
+	purgeAt := time.Now().Add(s.config.Auth.AccountDeletionGracePeriod)
+	if _, err := s.userRepo.ScheduleDeletion(ctx, userID, purgeAt); err != nil {
+		return time.Time{}, fmt.Errorf("erro ao excluir conta: %w", err)
+	}
+	if err := s.RevokeUserTokens(ctx, userID); err != nil {
+		return time.Time{}, err
+	}
+
+	return purgeAt, nil
+}
+
+func (s *AuthService) UpdateProfile(ctx context.Context, userID, expectedVersion string, update service.ProfileUpdate) (*entity.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError("token inválido")
+	}
+	if expectedVersion != "" && expectedVersion != user.Version() {
+		return nil, apperrors.NewPreconditionFailedError("o perfil foi alterado; carregue a versão atual e tente novamente")
+	}
+
+	if update.DisplayName != nil {
+		if user.DisplayName, err = validation.ValidateDisplayName(*update.DisplayName); err != nil {
+			return nil, err
+		}
+	}
+	if update.AvatarURL != nil {
+		if user.AvatarURL, err = validation.ValidateAvatarURL(*update.AvatarURL); err != nil {
+			return nil, err
+		}
+	}
+	if update.Locale != nil {
+		if user.Locale, err = validation.ValidateLocale(*update.Locale); err != nil {
+			return nil, err
+		}
+	}
+	if update.Timezone != nil {
+		if user.Timezone, err = validation.ValidateTimezone(*update.Timezone); err != nil {
+			return nil, err
+		}
+	}
+	if update.Metadata != nil {
+		user.Metadata = user.Metadata.Merge(update.Metadata)
+		if err := validation.ValidateMetadata(user.Metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	// A gravação também confere a versão, cobrindo alterações feitas entre a leitura e a escrita
+	updated, err := s.userRepo.UpdateProfile(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao atualizar perfil: %w", err)
+	}
+	if !updated {
+		return nil, apperrors.NewPreconditionFailedError("o perfil foi alterado; carregue a versão atual e tente novamente")
+	}
+
+	return user, nil
+}
+
+func (s *AuthService) RequestEmailChange(ctx context.Context, current *auth.Claims, password, newEmail string) error {
+	userID := current.UserID
+
+	// Limitar os pedidos do usuário antes de qualquer verificação, pois cada um envia emails a um endereço
+	// qualquer e, com a senha, serviria para testá-la
+	allowed, err := s.rateLimits.Allow(ctx, "email-change:"+userID, s.config.Auth.EmailChangeRateLimit, s.config.Auth.EmailChangeRateWindow)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return apperrors.NewRateLimitError("muitos pedidos de troca de email; tente novamente mais tarde")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return apperrors.NewUnauthorizedError("token inválido")
+	}
+
+	// Reautenticação, como na exclusão da conta: a senha ou, sem ela, um login recente
+	if password != "" {
+		if err := s.verifyPassword(ctx, user, password, "senha incorreta"); err != nil {
+			return err
+		}
+	} else if err := requireRecentAuth(current, s.config.Auth.ReauthMaxAge); err != nil {
+		return err
+	}
+
+	sanitizedEmail, err := validation.ValidateEmail(newEmail)
+	if err != nil {
+		return apperrors.NewValidationError("email inválido")
+	}
+	if sanitizedEmail == user.Email {
+		return apperrors.NewValidationError("o novo email deve ser diferente do atual")
+	}
+	exists, err := s.userRepo.ExistsByEmail(ctx, sanitizedEmail)
+	if err != nil {
+		return fmt.Errorf("erro ao verificar email: %w", err)
+	}
+	if exists {
+		// Aceitar o pedido sem registrá-lo, para que a troca não revele quais endereços têm conta; apenas o dono
+		// do endereço é avisado da tentativa
+		go func() {
+			ctx := context.WithoutCancel(ctx)
+			body := "Alguém tentou vincular este endereço de email a outra conta. Como ele já pertence a uma conta, nada foi alterado. Se não foi você, ignore este email."
+			if err := s.mailer.Send(ctx, sanitizedEmail, "Tentativa de uso do seu email", body); err != nil {
+				s.log.Error("Erro ao enviar aviso de email já cadastrado: %v", err)
+			}
+		}()
+		return nil
+	}
+
+	// Um novo pedido substitui o pendente: os tokens anteriores são invalidados pela emissão dos novos
+	ttl := s.config.Auth.EmailVerificationTTL
+	if err := s.emailChanges.Save(ctx, userID, sanitizedEmail, ttl); err != nil {
+		return err
+	}
+	confirmToken, err := s.oneTimeTokens.Issue(ctx, TokenPurposeEmailChange, userID, ttl)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar token de confirmação: %w", err)
+	}
+	cancelToken, err := s.oneTimeTokens.Issue(ctx, TokenPurposeEmailChangeCancel, userID, ttl)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar token de cancelamento: %w", err)
+	}
+
+	confirmLink := fmt.Sprintf("%s/confirm-email-change?token=%s", s.config.Server.PublicURL, confirmToken)
+	confirmBody := fmt.Sprintf("Confirme o novo endereço de email da sua conta acessando o link abaixo:\n\n%s\n\nO link expira em %s.", confirmLink, ttl)
+	cancelLink := fmt.Sprintf("%s/cancel-email-change?token=%s", s.config.Server.PublicURL, cancelToken)
+	noticeBody := fmt.Sprintf("Recebemos um pedido para trocar o email da sua conta para %s. Se não foi você, cancele a troca pelo link abaixo e troque sua senha:\n\n%s\n\nO link expira em %s.", sanitizedEmail, cancelLink, ttl)
+
+	// Enviar em segundo plano para que a resposta não dependa do servidor de email. O pedido já foi salvo: uma
+	// falha no envio não deve recusá-lo, e o usuário pode repeti-lo.
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		if err := s.mailer.Send(ctx, sanitizedEmail, "Confirme seu novo email", confirmBody); err != nil {
+			s.log.Error("Erro ao enviar email de confirmação: %v", err)
+		}
+		if err := s.mailer.Send(ctx, user.Email, "Pedido de troca de email", noticeBody); err != nil {
+			s.log.Error("Erro ao enviar aviso de troca de email: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, current *auth.Claims, token string) (*service.TokenPair, error) {
+	userID := current.UserID
+
+	// O link só confirma a troca na sessão do próprio usuário que a pediu; aberto em outra sessão, o token é
+	// conferido sem ser consumido e continua valendo para o dono
+	subject, err := s.oneTimeTokens.Peek(ctx, TokenPurposeEmailChange, token)
+	if err == ErrOneTimeTokenNotFound {
+		return nil, apperrors.NewValidationError("token de confirmação inválido ou expirado")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao validar token de confirmação: %w", err)
+	}
+	if subject != userID {
+		return nil, apperrors.NewValidationError("token de confirmação inválido ou expirado")
+	}
+	subject, err = s.oneTimeTokens.Consume(ctx, TokenPurposeEmailChange, token)
+	if err == ErrOneTimeTokenNotFound {
+		return nil, apperrors.NewValidationError("token de confirmação inválido ou expirado")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao validar token de confirmação: %w", err)
+	}
+	if subject != userID {
+		return nil, apperrors.NewValidationError("token de confirmação inválido ou expirado")
+	}
+
+	newEmail, err := s.emailChanges.Find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if newEmail == "" {
+		return nil, apperrors.NewValidationError("token de confirmação inválido ou expirado")
+	}
+
+	// O endereço pode ter sido cadastrado por outra conta depois do pedido
+	exists, err := s.userRepo.ExistsByEmail(ctx, newEmail)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar email: %w", err)
+	}
+	if exists {
+		return nil, apperrors.NewConflictError("email já cadastrado")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError("token inválido")
+	}
+	now := time.Now()
+	user.Email = newEmail
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.ChangeEmail(ctx, user.ID, newEmail, now); err != nil {
+		return nil, fmt.Errorf("erro ao trocar email: %w", err)
+	}
+
+	if err := s.emailChanges.Delete(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.oneTimeTokens.Revoke(ctx, TokenPurposeEmailChangeCancel, userID); err != nil {
+		return nil, fmt.Errorf("erro ao revogar token de cancelamento: %w", err)
+	}
+	// O evento não traz os endereços: um hash sem segredo do email anterior poderia ser revertido por força bruta
+	s.securityEvents.Emit(ctx, SecurityEvent{
+		Type:   SecurityEventEmailChanged,
+		UserID: userID,
+	})
+
+	// Encerrar as demais sessões; o dispositivo atual continua em uma sessão nova
+	if err := s.revocations.RevokeUser(ctx, userID); err != nil {
+		return nil, fmt.Errorf("erro ao revogar tokens: %w", err)
+	}
+	session, err := s.createSession(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.sessionRepo.RevokeAllExcept(ctx, userID, session.ID, time.Now()); err != nil {
+		return nil, fmt.Errorf("erro ao encerrar sessões: %w", err)
+	}
+
+	// A troca não autentica o usuário de novo: o dispositivo atual mantém o momento e os métodos do login
+	authTime, amr := current.AuthTime, current.AMR
+	if authTime == 0 {
+		authTime = time.Now().Unix()
+	}
+	return s.issueTokens(ctx, userID, authTime, amr, session, "", "")
+}
+
+func (s *AuthService) CancelEmailChange(ctx context.Context, token string) error {
+	userID, err := s.oneTimeTokens.Consume(ctx, TokenPurposeEmailChangeCancel, token)
+	if err == ErrOneTimeTokenNotFound {
+		return apperrors.NewValidationError("token de cancelamento inválido ou expirado")
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao validar token de cancelamento: %w", err)
+	}
+
+	if err := s.oneTimeTokens.Revoke(ctx, TokenPurposeEmailChange, userID); err != nil {
+		return fmt.Errorf("erro ao revogar token de confirmação: %w", err)
+	}
+	return s.emailChanges.Delete(ctx, userID)
+}
+
+// mfaMethods lista os segundos fatores disponíveis para o usuário
+func (s *AuthService) mfaMethods(ctx context.Context, userID string) ([]string, error) {
+	var methods []string
+
+	factor, err := s.mfaRepo.FindTOTPByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar MFA: %w", err)
+	}
+	if factor != nil && factor.IsConfirmed() {
+		methods = append(methods, "totp")
+	}
+
+	passkeys, err := s.webAuthnRepo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar MFA: %w", err)
+	}
+	if passkeys > 0 {
+		methods = append(methods, "webauthn")
+	}
+
+	return methods, nil
+}
+
+// issueTokens emite o par de tokens da sessão; sem sessão, registra uma sessão nova. Sem familyID, o refresh
+// token inicia uma família nova; com familyID, jti é o membro registrado na rotação.
+func (s *AuthService) issueTokens(ctx context.Context, userID string, authTime int64, amr []string, session *entity.Session, familyID, jti string) (*service.TokenPair, error) {
+	if session == nil {
+		var err error
+		if session, err = s.createSession(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	sessionID := session.ID
+
+	roles, permissions, err := s.authorization(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := s.sessionTenant(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.tokenManager.GenerateTokenWithClaims(&auth.Claims{
+		UserID:      userID,
+		Type:        auth.TokenTypeAccess,
+		AuthTime:    authTime,
+		AMR:         amr,
+		SessionID:   sessionID,
+		TenantID:    tenantID,
+		Roles:       roles,
+		Permissions: permissions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar access token: %w", err)
+	}
+
+	if familyID == "" {
+		if familyID, jti, err = s.families.Start(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	refreshToken, err := s.tokenManager.GenerateTokenWithClaims(&auth.Claims{
+		UserID:         userID,
+		Type:           auth.TokenTypeRefresh,
+		AuthTime:       authTime,
+		AMR:            amr,
+		FamilyID:       familyID,
+		SessionID:      sessionID,
+		StandardClaims: jwt.StandardClaims{Id: jti},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar refresh token: %w", err)
+	}
+
+	return &service.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// authorization retorna os papéis do usuário e a união das permissões, copiados para o access token
+func (s *AuthService) authorization(ctx context.Context, userID string) ([]string, []string, error) {
+	userRoles, err := s.roleRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao buscar papéis do usuário: %w", err)
+	}
+
+	roles := make([]string, 0, len(userRoles))
+	var permissions []string
+	for _, role := range userRoles {
+		roles = append(roles, role.Name)
+		for _, permission := range role.PermissionNames() {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return roles, permissions, nil
+}
+
+// sessionTenant retorna a organização da sessão para a claim tenant_id, desde que o usuário ainda participe dela
+func (s *AuthService) sessionTenant(ctx context.Context, session *entity.Session) (string, error) {
+	if session.OrganizationID == nil {
+		return "", nil
+	}
+	tenantID := strconv.FormatUint(uint64(*session.OrganizationID), 10)
+	membership, err := s.orgRepo.FindMembership(ctx, tenantID, strconv.FormatUint(uint64(session.UserID), 10))
+	if err != nil {
+		return "", fmt.Errorf("erro ao verificar organização: %w", err)
+	}
+	if membership == nil {
+		return "", nil
+	}
+	return tenantID, nil
+}
+
+// membership retorna a participação do usuário na organização; 403 quando ele não participa dela
+func (s *AuthService) membership(ctx context.Context, organizationID, userID string) (*entity.Membership, error) {
+	membership, err := s.orgRepo.FindMembership(ctx, organizationID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar organização: %w", err)
+	}
+	if membership == nil {
+		return nil, apperrors.NewForbiddenError("você não participa desta organização")
+	}
+	return membership, nil
+}
+
+// createSession registra uma sessão com os dados do dispositivo da requisição. A organização da sessão é a
+// escolhida pelo cliente (header X-Organization-ID) ou, sem escolha, a mais antiga do usuário.
+func (s *AuthService) createSession(ctx context.Context, userID string) (*entity.Session, error) {
+	id, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("usuário inválido: %w", err)
+	}
+	sessionID, err := auth.GenerateRandomToken(sessionIDBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	client := service.ClientInfoFromContext(ctx)
+	var organizationID *uint
+	if client.OrganizationID != "" {
+		membership, err := s.membership(ctx, client.OrganizationID, userID)
+		if err != nil {
+			return nil, err
+		}
+		organizationID = &membership.OrganizationID
+	} else {
+		memberships, err := s.orgRepo.FindMembershipsByUserID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar organizações: %w", err)
+		}
+		if len(memberships) > 0 {
+			organizationID = &memberships[0].OrganizationID
+		}
+	}
+
+	now := time.Now()
+	session := &entity.Session{
+		ID:             sessionID,
+		UserID:         uint(id),
+		IPAddress:      client.IPAddress,
+		UserAgent:      client.UserAgent,
+		DeviceName:     client.DeviceName,
+		OrganizationID: organizationID,
+		CreatedAt:      now,
+		LastUsedAt:     now,
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("erro ao registrar sessão: %w", err)
+	}
+	return session, nil
+}
+
+func (s *AuthService) setPassword(ctx context.Context, user *entity.User, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+
+	user.Password = string(hashedPassword)
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, user.Password); err != nil {
+		return fmt.Errorf("erro ao atualizar senha: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AuthService) sendVerificationEmail(ctx context.Context, user *entity.User) error {
+	userID := fmt.Sprintf("%d", user.ID)
+	token, err := s.oneTimeTokens.Issue(ctx, TokenPurposeEmailVerification, userID, s.config.Auth.EmailVerificationTTL)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar token de verificação: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", s.config.Server.PublicURL, token)
+	body := fmt.Sprintf("Confirme seu endereço de email acessando o link abaixo:\n\n%s\n\nO link expira em %s.", link, s.config.Auth.EmailVerificationTTL)
+
+	// Enviar em segundo plano para que a resposta não dependa do servidor de email nem revele, pelo tempo, se
+	// o endereço está cadastrado
+	go func() {
+		if err := s.mailer.Send(context.WithoutCancel(ctx), user.Email, "Confirme seu email", body); err != nil {
+			s.log.Error("Erro ao enviar email de verificação: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// requireRecentAuth exige que o token atual venha de um login feito há no máximo maxAge. A claim auth_time é
+// preservada na renovação, então um refresh token roubado não basta para as operações protegidas.
+func requireRecentAuth(current *auth.Claims, maxAge time.Duration) error {
+	if current == nil || current.AuthTime == 0 || time.Since(time.Unix(current.AuthTime, 0)) > maxAge {
+		return apperrors.NewForbiddenError("esta operação exige um login recente; entre novamente para continuar")
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/pkg/auth"
+)
+
+const bruteForceKeyPrefix = "bruteforce:"
+
+// BruteForceStore implementa auth.AttemptLimiter no Redis, de modo que as falhas e os bloqueios valem para
+// todas as instâncias da API. Cada identificador usa três chaves: a contagem de falhas da janela, a contagem
+// de bloqueios consecutivos e o próprio bloqueio, que expiram sozinhas. Os identificadores derivados
+// (parent:...) com falhas ficam registrados em um conjunto do parent, para que ResetDerived não precise
+// percorrer o keyspace.
+type BruteForceStore struct {
+	redis     *redis.Client
+	namespace string
+	policy    auth.BruteForcePolicy
+}
+
+func NewBruteForceStore(redis *redis.Client, namespace string, policy auth.BruteForcePolicy) *BruteForceStore {
+	return &BruteForceStore{
+		redis:     redis,
+		namespace: namespace,
+		policy:    policy,
+	}
+}
+
+func (s *BruteForceStore) Locked(ctx context.Context, identifier string) (time.Duration, error) {
+	ttl, err := s.redis.PTTL(ctx, s.key(identifier, "locked")).Result()
+	if err != nil {
+		return 0, fmt.Errorf("erro ao verificar bloqueio: %w", err)
+	}
+	// PTTL retorna valores negativos quando a chave não existe
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *BruteForceStore) RecordFailure(ctx context.Context, identifier string) (time.Duration, error) {
+	failuresKey := s.key(identifier, "failures")
+
+	pipe := s.redis.TxPipeline()
+	failures := pipe.Incr(ctx, failuresKey)
+	pipe.ExpireNX(ctx, failuresKey, s.policy.Window)
+	if parent, _, derived := strings.Cut(identifier, ":"); derived {
+		// O conjunto dura tanto quanto a contagem de bloqueios, a chave mais longa de um identificador
+		derivedKey := s.key(parent, "derived")
+		pipe.SAdd(ctx, derivedKey, identifier)
+		pipe.Expire(ctx, derivedKey, max(s.policy.LockoutMemory, s.policy.Window))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("erro ao registrar tentativa: %w", err)
+	}
+	if failures.Val() < int64(s.policy.MaxAttempts) {
+		return 0, nil
+	}
+
+	lockoutsKey := s.key(identifier, "lockouts")
+	pipe = s.redis.TxPipeline()
+	pipe.Del(ctx, failuresKey)
+	lockouts := pipe.Incr(ctx, lockoutsKey)
+	pipe.Expire(ctx, lockoutsKey, s.policy.LockoutMemory)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("erro ao registrar bloqueio: %w", err)
+	}
+
+	block := s.policy.BlockDuration(int(lockouts.Val()))
+	if err := s.redis.Set(ctx, s.key(identifier, "locked"), 1, block).Err(); err != nil {
+		return 0, fmt.Errorf("erro ao registrar bloqueio: %w", err)
+	}
+	return block, nil
+}
+
+func (s *BruteForceStore) Reset(ctx context.Context, identifier string) error {
+	if err := s.redis.Del(ctx, s.keys(identifier)...).Err(); err != nil {
+		return fmt.Errorf("erro ao liberar bloqueios: %w", err)
+	}
+	return nil
+}
+
+func (s *BruteForceStore) ResetDerived(ctx context.Context, parent string) error {
+	derivedKey := s.key(parent, "derived")
+	identifiers, err := s.redis.SMembers(ctx, derivedKey).Result()
+	if err != nil {
+		return fmt.Errorf("erro ao liberar bloqueios: %w", err)
+	}
+
+	keys := []string{derivedKey}
+	for _, identifier := range identifiers {
+		keys = append(keys, s.keys(identifier)...)
+	}
+	if err := s.redis.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("erro ao liberar bloqueios: %w", err)
+	}
+	return nil
+}
+
+func (s *BruteForceStore) keys(identifier string) []string {
+	return []string{s.key(identifier, "failures"), s.key(identifier, "lockouts"), s.key(identifier, "locked")}
+}
+
+func (s *BruteForceStore) key(identifier, kind string) string {
+	return fmt.Sprintf("%s%s:%s:%s", bruteForceKeyPrefix, s.namespace, identifier, kind)
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"auth-template/internal/config"
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/logger"
+	"auth-template/pkg/mailer"
+)
+
+// dataExportRateWindow é a janela do limite de pedidos de exportação por usuário
+const dataExportRateWindow = 24 * time.Hour
+
+// dataExport é o arquivo entregue ao usuário. Cada seção lista apenas metadados: segredos de MFA, chaves
+// públicas das passkeys, hashes de senha, de códigos de recuperação e de chaves de API ficam de fora.
+type dataExport struct {
+	GeneratedAt    time.Time          `json:"generated_at"`
+	Profile        *entity.User       `json:"profile"`
+	Roles          []string           `json:"roles"`
+	Organizations  []exportMembership `json:"organizations"`
+	Sessions       []exportSession    `json:"sessions"`
+	Identities     []exportIdentity   `json:"identities"`
+	MFA            exportMFA          `json:"mfa"`
+	APIKeys        []exportAPIKey     `json:"api_keys"`
+	SecurityEvents []SecurityEvent    `json:"security_events"`
+}
+
+type exportMembership struct {
+	OrganizationID uint      `json:"organization_id"`
+	Name           string    `json:"name"`
+	Role           string    `json:"role"`
+	JoinedAt       time.Time `json:"joined_at"`
+}
+
+// exportSession inclui as sessões revogadas, que formam o histórico de logins
+type exportSession struct {
+	ID         string     `json:"id"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	DeviceName string     `json:"device_name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type exportIdentity struct {
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type exportMFA struct {
+	TOTP                   *exportTOTP     `json:"totp"`
+	RecoveryCodesRemaining int64           `json:"recovery_codes_remaining"`
+	Passkeys               []exportPasskey `json:"passkeys"`
+}
+
+type exportTOTP struct {
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type exportPasskey struct {
+	Name           string     `json:"name"`
+	BackupEligible bool       `json:"backup_eligible"`
+	BackupState    bool       `json:"backup_state"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type exportAPIKey struct {
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type DataExportService struct {
+	userRepo       repository.UserRepository
+	sessionRepo    repository.SessionRepository
+	identityRepo   repository.IdentityRepository
+	mfaRepo        repository.MFARepository
+	webAuthnRepo   repository.WebAuthnRepository
+	apiKeyRepo     repository.APIKeyRepository
+	roleRepo       repository.RoleRepository
+	orgRepo        repository.OrganizationRepository
+	securityEvents *SecurityEvents
+	exports        *DataExportStore
+	rateLimits     *RateLimitStore
+	mailer         mailer.Mailer
+	config         *config.Config
+	log            *logger.Logger
+}
+
+func NewDataExportService(
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	identityRepo repository.IdentityRepository,
+	mfaRepo repository.MFARepository,
+	webAuthnRepo repository.WebAuthnRepository,
+	apiKeyRepo repository.APIKeyRepository,
+	roleRepo repository.RoleRepository,
+	orgRepo repository.OrganizationRepository,
+	securityEvents *SecurityEvents,
+	exports *DataExportStore,
+	rateLimits *RateLimitStore,
+	mailer mailer.Mailer,
+	config *config.Config,
+	log *logger.Logger,
+) service.DataExportService {
+	return &DataExportService{
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		identityRepo:   identityRepo,
+		mfaRepo:        mfaRepo,
+		webAuthnRepo:   webAuthnRepo,
+		apiKeyRepo:     apiKeyRepo,
+		roleRepo:       roleRepo,
+		orgRepo:        orgRepo,
+		securityEvents: securityEvents,
+		exports:        exports,
+		rateLimits:     rateLimits,
+		mailer:         mailer,
+		config:         config,
+		log:            log,
+	}
+}
+
+func (s *DataExportService) RequestExport(ctx context.Context, userID string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return apperrors.NewUnauthorizedError("token inválido")
+	}
+
+	allowed, err := s.rateLimits.Allow(ctx, "data-export:"+userID, s.config.Auth.DataExportRateLimit, dataExportRateWindow)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return apperrors.NewRateLimitError("limite de exportações atingido; tente novamente mais tarde")
+	}
+
+	s.securityEvents.Emit(ctx, SecurityEvent{
+		Type:   SecurityEventDataExportRequested,
+		UserID: userID,
+	})
+
+	// Montar e enviar em segundo plano: a coleta dos dados não deve prender a requisição
+	go func() {
+		if err := s.deliver(context.WithoutCancel(ctx), user); err != nil {
+			s.log.Error("Erro na exportação de dados: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+func (s *DataExportService) deliver(ctx context.Context, user *entity.User) error {
+	archive, err := s.Build(ctx, fmt.Sprintf("%d", user.ID))
+	if err != nil {
+		return err
+	}
+
+	ttl := s.config.Auth.DataExportTTL
+	token, err := s.exports.Save(ctx, archive, ttl)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/account/export?token=%s", s.config.Server.PublicURL, token)
+	body := fmt.Sprintf("A cópia dos seus dados pessoais está pronta. Acesse o link abaixo para baixá-la:\n\n%s\n\nO link expira em %s. Se você não fez este pedido, troque sua senha.", link, ttl)
+	return s.mailer.Send(ctx, user.Email, "Exportação dos seus dados", body)
+}
+
+func (s *DataExportService) Build(ctx context.Context, userID string) ([]byte, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	export := dataExport{
+		GeneratedAt: time.Now(),
+		Profile:     user,
+	}
+
+	roles, err := s.roleRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar papéis: %w", err)
+	}
+	for _, role := range roles {
+		export.Roles = append(export.Roles, role.Name)
+	}
+
+	memberships, err := s.orgRepo.FindMembershipsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar organizações: %w", err)
+	}
+	for _, membership := range memberships {
+		export.Organizations = append(export.Organizations, exportMembership{
+			OrganizationID: membership.OrganizationID,
+			Name:           membership.Organization.Name,
+			Role:           membership.Role,
+			JoinedAt:       membership.CreatedAt,
+		})
+	}
+
+	sessions, err := s.sessionRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar sessões: %w", err)
+	}
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, exportSession{
+			ID:         session.ID,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			DeviceName: session.DeviceName,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			RevokedAt:  session.RevokedAt,
+		})
+	}
+
+	identities, err := s.identityRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar identidades: %w", err)
+	}
+	for _, identity := range identities {
+		export.Identities = append(export.Identities, exportIdentity{
+			Provider:    identity.Provider,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			LastLoginAt: identity.LastLoginAt,
+			CreatedAt:   identity.CreatedAt,
+		})
+	}
+
+	if export.MFA, err = s.mfaMetadata(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := s.apiKeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar chaves de API: %w", err)
+	}
+	for _, key := range apiKeys {
+		export.APIKeys = append(export.APIKeys, exportAPIKey{
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.ScopeList(),
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+			CreatedAt:  key.CreatedAt,
+		})
+	}
+
+	if export.SecurityEvents, err = s.securityEvents.FindByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	archive, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar exportação: %w", err)
+	}
+	return archive, nil
+}
+
+func (s *DataExportService) mfaMetadata(ctx context.Context, userID string) (exportMFA, error) {
+	var mfa exportMFA
+
+	factor, err := s.mfaRepo.FindTOTPByUserID(ctx, userID)
+	if err != nil {
+		return mfa, fmt.Errorf("erro ao buscar MFA: %w", err)
+	}
+	if factor != nil {
+		mfa.TOTP = &exportTOTP{
+			ConfirmedAt: factor.ConfirmedAt,
+			CreatedAt:   factor.CreatedAt,
+		}
+	}
+
+	if mfa.RecoveryCodesRemaining, err = s.mfaRepo.CountUnusedRecoveryCodes(ctx, userID); err != nil {
+		return mfa, fmt.Errorf("erro ao buscar códigos de recuperação: %w", err)
+	}
+
+	passkeys, err := s.webAuthnRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return mfa, fmt.Errorf("erro ao buscar passkeys: %w", err)
+	}
+	for _, passkey := range passkeys {
+		mfa.Passkeys = append(mfa.Passkeys, exportPasskey{
+			Name:           passkey.Name,
+			BackupEligible: passkey.BackupEligible,
+			BackupState:    passkey.BackupState,
+			LastUsedAt:     passkey.LastUsedAt,
+			CreatedAt:      passkey.CreatedAt,
+		})
+	}
+
+	return mfa, nil
+}
+
+func (s *DataExportService) Download(ctx context.Context, token string) ([]byte, error) {
+	archive, err := s.exports.Load(ctx, token)
+	if err == ErrDataExportNotFound {
+		return nil, apperrors.NewNotFoundError("link de exportação inválido ou expirado")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/pkg/auth"
+)
+
+const (
+	dataExportKeyPrefix  = "export:"
+	dataExportTokenBytes = 32
+)
+
+// ErrDataExportNotFound indica que o link de exportação não existe ou expirou
+var ErrDataExportNotFound = errors.New("exportação não encontrada ou expirada")
+
+// DataExportStore guarda os arquivos de exportação de dados no Redis até o link expirar. O arquivo é indexado
+// pelo hash do token do link, que não é persistido em claro.
+type DataExportStore struct {
+	redis *redis.Client
+}
+
+func NewDataExportStore(redis *redis.Client) *DataExportStore {
+	return &DataExportStore{
+		redis: redis,
+	}
+}
+
+// Save guarda o arquivo e retorna o token do link de download
+func (s *DataExportStore) Save(ctx context.Context, archive []byte, ttl time.Duration) (string, error) {
+	token, err := auth.GenerateRandomToken(dataExportTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.redis.Set(ctx, dataExportKeyPrefix+auth.HashToken(token), archive, ttl).Err(); err != nil {
+		return "", fmt.Errorf("erro ao armazenar exportação: %w", err)
+	}
+	return token, nil
+}
+
+// Load retorna o arquivo do token; o link pode ser usado mais de uma vez até expirar
+func (s *DataExportStore) Load(ctx context.Context, token string) ([]byte, error) {
+	archive, err := s.redis.Get(ctx, dataExportKeyPrefix+auth.HashToken(token)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrDataExportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar exportação: %w", err)
+	}
+	return archive, nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const emailChangeKeyPrefix = "email-change:"
+
+// EmailChangeStore guarda o novo endereço da troca de email pendente de cada usuário até a confirmação.
+// Cada usuário tem no máximo uma troca pendente; um novo pedido substitui o anterior.
+type EmailChangeStore struct {
+	redis *redis.Client
+}
+
+func NewEmailChangeStore(redis *redis.Client) *EmailChangeStore {
+	return &EmailChangeStore{
+		redis: redis,
+	}
+}
+
+func (s *EmailChangeStore) Save(ctx context.Context, userID, newEmail string, ttl time.Duration) error {
+	if err := s.redis.Set(ctx, emailChangeKeyPrefix+userID, newEmail, ttl).Err(); err != nil {
+		return fmt.Errorf("erro ao registrar troca de email: %w", err)
+	}
+	return nil
+}
+
+// Find retorna o novo endereço pendente; vazio quando não há troca pendente
+func (s *EmailChangeStore) Find(ctx context.Context, userID string) (string, error) {
+	newEmail, err := s.redis.Get(ctx, emailChangeKeyPrefix+userID).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar troca de email: %w", err)
+	}
+	return newEmail, nil
+}
+
+func (s *EmailChangeStore) Delete(ctx context.Context, userID string) error {
+	if err := s.redis.Del(ctx, emailChangeKeyPrefix+userID).Err(); err != nil {
+		return fmt.Errorf("erro ao remover troca de email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"auth-template/internal/config"
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
+	"auth-template/pkg/logger"
+	"auth-template/pkg/mailer"
+	"auth-template/pkg/validation"
+)
+
+const invitationTokenBytes = 32
+
+type InvitationService struct {
+	config         *config.Config
+	invitationRepo repository.InvitationRepository
+	orgRepo        repository.OrganizationRepository
+	userRepo       repository.UserRepository
+	authService    service.AuthService
+	mailer         mailer.Mailer
+	log            *logger.Logger
+}
+
+func NewInvitationService(
+	cfg *config.Config,
+	invitationRepo repository.InvitationRepository,
+	orgRepo repository.OrganizationRepository,
+	userRepo repository.UserRepository,
+	authService service.AuthService,
+	mailer mailer.Mailer,
+	log *logger.Logger,
+) service.InvitationService {
+	return &InvitationService{
+		config:         cfg,
+		invitationRepo: invitationRepo,
+		orgRepo:        orgRepo,
+		userRepo:       userRepo,
+		authService:    authService,
+		mailer:         mailer,
+		log:            log,
+	}
+}
+
+// CreateInvitation convida o email para a organização e envia o link do convite. Um convite pendente para
+// o mesmo email é substituído; sem papel informado, vale o papel padrão da configuração. O link só é
+// enviado ao convidado: quem convida nunca o recebe.
+func (s *InvitationService) CreateInvitation(ctx context.Context, tenantID, actorID, email, role string) (*entity.Invitation, error) {
+	sanitizedEmail, err := validation.ValidateEmail(email)
+	if err != nil {
+		return nil, apperrors.NewValidationError("email inválido")
+	}
+	if role == "" {
+		role = s.config.Organization.InvitationDefaultRole
+	}
+	if !entity.IsValidOrganizationRole(role) {
+		return nil, apperrors.NewValidationError("papel inválido")
+	}
+
+	actor, err := s.manager(ctx, tenantID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if role == entity.OrganizationRoleOwner && actor.Role != entity.OrganizationRoleOwner {
+		return nil, apperrors.NewForbiddenError("apenas donos convidam novos donos")
+	}
+
+	if user, err := s.userRepo.FindByEmail(ctx, sanitizedEmail); err == nil {
+		member, err := s.orgRepo.FindMembership(ctx, tenantID, strconv.FormatUint(uint64(user.ID), 10))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao verificar membro: %w", err)
+		}
+		if member != nil {
+			return nil, apperrors.NewConflictError("o usuário já participa da organização")
+		}
+	}
+
+	token, err := auth.GenerateRandomToken(invitationTokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	invitation := &entity.Invitation{
+		OrganizationID: actor.OrganizationID,
+		Email:          sanitizedEmail,
+		Role:           role,
+		TokenHash:      auth.HashToken(token),
+		InvitedBy:      &actor.UserID,
+		ExpiresAt:      time.Now().Add(s.config.Organization.InvitationTTL),
+	}
+	if err := s.invitationRepo.DeletePendingByEmail(ctx, tenantID, sanitizedEmail); err != nil {
+		return nil, fmt.Errorf("erro ao substituir convite anterior: %w", err)
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("erro ao criar convite: %w", err)
+	}
+
+	link := fmt.Sprintf("%s/invitations/accept?token=%s", s.config.Server.PublicURL, s.signToken(token, invitation.ExpiresAt))
+	body := fmt.Sprintf("Você foi convidado para participar de uma organização. Acesse o link abaixo para aceitar o convite:\n\n%s\n\nO convite expira em %s.", link, s.config.Organization.InvitationTTL)
+
+	// Uma falha no envio não desfaz o convite; quem convidou pode criá-lo de novo para reenviar o link
+	go func() {
+		if err := s.mailer.Send(context.WithoutCancel(ctx), sanitizedEmail, "Convite para organização", body); err != nil {
+			s.log.Error("Erro ao enviar convite: %v", err)
+		}
+	}()
+
+	return invitation, nil
+}
+
+func (s *InvitationService) ListInvitations(ctx context.Context, tenantID, actorID string) ([]entity.Invitation, error) {
+	if _, err := s.manager(ctx, tenantID, actorID); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.invitationRepo.FindPending(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar convites: %w", err)
+	}
+	return invitations, nil
+}
+
+func (s *InvitationService) RevokeInvitation(ctx context.Context, tenantID, actorID, id string) error {
+	if _, err := s.manager(ctx, tenantID, actorID); err != nil {
+		return err
+	}
+
+	deleted, err := s.invitationRepo.DeletePending(ctx, tenantID, id)
+	if err != nil {
+		return fmt.Errorf("erro ao revogar convite: %w", err)
+	}
+	if !deleted {
+		return apperrors.NewNotFoundError("convite não encontrado")
+	}
+	return nil
+}
+
+func (s *InvitationService) AcceptInvitation(ctx context.Context, token, userID string) (*entity.Membership, error) {
+	invitation, err := s.pendingInvitation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError("token inválido")
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, apperrors.NewForbiddenError("este convite foi enviado para outro email")
+	}
+
+	member, err := s.orgRepo.FindMembership(ctx, strconv.FormatUint(uint64(invitation.OrganizationID), 10), userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar membro: %w", err)
+	}
+	if member != nil {
+		return nil, apperrors.NewConflictError("você já participa desta organização")
+	}
+
+	// O convite não confirma o email da conta: o link passa pelas mãos de quem convida a organização, e não
+	// só do dono do endereço. A confirmação continua sendo feita pelo email de verificação.
+	return s.accept(ctx, invitation, user.ID)
+}
+
+// RegisterWithInvitation cadastra o convidado pelo fluxo normal de registro, com o email ainda não verificado
+// e o envio do email de verificação, e o vincula à organização do convite
+func (s *InvitationService) RegisterWithInvitation(ctx context.Context, token, password string) (*entity.Membership, error) {
+	invitation, err := s.pendingInvitation(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(ctx, invitation.Email)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar email: %w", err)
+	}
+	if exists {
+		return nil, apperrors.NewConflictError("email já cadastrado; entre na conta para aceitar o convite")
+	}
+
+	user, err := s.authService.Register(ctx, invitation.Email, password)
+	if err != nil {
+		return nil, err
+	}
+	return s.accept(ctx, invitation, user.ID)
+}
+
+// manager retorna a participação de quem faz a requisição, exigindo o papel de dono ou administrador
+func (s *InvitationService) manager(ctx context.Context, tenantID, actorID string) (*entity.Membership, error) {
+	actor, err := findActor(ctx, s.orgRepo, tenantID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanManageMembers() {
+		return nil, apperrors.NewForbiddenError("apenas donos e administradores gerenciam convites")
+	}
+	return actor, nil
+}
+
+// pendingInvitation valida a assinatura e a expiração do link e retorna o convite, se ainda pendente
+func (s *InvitationService) pendingInvitation(ctx context.Context, signedToken string) (*entity.Invitation, error) {
+	token, ok := s.verifyToken(signedToken)
+	if !ok {
+		return nil, apperrors.NewNotFoundError("convite inválido ou expirado")
+	}
+
+	invitation, err := s.invitationRepo.FindByTokenHash(ctx, auth.HashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar convite: %w", err)
+	}
+	if invitation == nil || !invitation.IsPending(time.Now()) {
+		return nil, apperrors.NewNotFoundError("convite inválido ou expirado")
+	}
+	return invitation, nil
+}
+
+func (s *InvitationService) accept(ctx context.Context, invitation *entity.Invitation, userID uint) (*entity.Membership, error) {
+	err := s.invitationRepo.Accept(ctx, invitation, userID)
+	if errors.Is(err, repository.ErrInvitationUnavailable) {
+		return nil, apperrors.NewNotFoundError("convite inválido ou expirado")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao aceitar convite: %w", err)
+	}
+
+	return &entity.Membership{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userID,
+		Organization:   invitation.Organization,
+		Role:           invitation.Role,
+		CreatedAt:      *invitation.AcceptedAt,
+	}, nil
+}
+
+// signToken assina o token junto com a expiração do convite: <token>.<expiração unix>.<assinatura>.
+// Links adulterados ou vencidos são recusados antes de qualquer consulta ao banco.
+func (s *InvitationService) signToken(token string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return token + "." + expires + "." + auth.Sign(s.config.Organization.InvitationSecret, token, expires)
+}
+
+func (s *InvitationService) verifyToken(signedToken string) (string, bool) {
+	parts := strings.Split(signedToken, ".")
+	if len(parts) != 3 || !auth.VerifySignature(s.config.Organization.InvitationSecret, parts[2], parts[0], parts[1]) {
+		return "", false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || !time.Now().Before(time.Unix(expires, 0)) {
+		return "", false
+	}
+	return parts[0], true
+}
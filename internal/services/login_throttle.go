@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/internal/config"
+	apperrors "auth-template/internal/errors"
+	"auth-template/pkg/auth"
+)
+
+const (
+	// LoginLockoutStoreMemory guarda as tentativas na própria instância; serve apenas a um único nó
+	LoginLockoutStoreMemory = "memory"
+
+	// loginLockoutMemory é por quanto tempo os bloqueios contam como reincidência para dobrar o próximo
+	loginLockoutMemory = 24 * time.Hour
+)
+
+// LoginThrottle protege o login por senha contra força bruta em dois níveis: por conta e IP, com limite
+// menor, contra um mesmo cliente insistindo em uma conta; e por conta, contra tentativas distribuídas entre
+// vários IPs. O login bem-sucedido zera as falhas do cliente e da conta.
+type LoginThrottle struct {
+	accounts auth.AttemptLimiter
+	clients  auth.AttemptLimiter
+}
+
+// NewLoginThrottle guarda as tentativas no Redis, compartilhadas entre as réplicas, ou em memória quando
+// AUTH_LOGIN_LOCKOUT_STORE=memory
+func NewLoginThrottle(cfg *config.Config, redis *redis.Client) *LoginThrottle {
+	accountPolicy := auth.BruteForcePolicy{
+		MaxAttempts:   cfg.Auth.LoginAccountMaxAttempts,
+		Window:        cfg.Auth.LoginAttemptWindow,
+		BlockTime:     cfg.Auth.LoginLockout,
+		MaxBlockTime:  cfg.Auth.LoginMaxLockout,
+		LockoutMemory: loginLockoutMemory,
+	}
+	clientPolicy := accountPolicy
+	clientPolicy.MaxAttempts = cfg.Auth.LoginMaxAttempts
+
+	if cfg.Auth.LoginLockoutStore == LoginLockoutStoreMemory {
+		return &LoginThrottle{
+			accounts: auth.NewBruteForceProtector(accountPolicy),
+			clients:  auth.NewBruteForceProtector(clientPolicy),
+		}
+	}
+	return &LoginThrottle{
+		accounts: NewBruteForceStore(redis, "login-account", accountPolicy),
+		clients:  NewBruteForceStore(redis, "login-client", clientPolicy),
+	}
+}
+
+// Check recusa o login quando a conta está bloqueada (423) ou o cliente está bloqueado para ela (429)
+func (t *LoginThrottle) Check(ctx context.Context, email, ipAddress string) error {
+	account := loginAccount(email)
+
+	remaining, err := t.accounts.Locked(ctx, account)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return accountLockedError(remaining)
+	}
+
+	remaining, err = t.clients.Locked(ctx, loginClient(account, ipAddress))
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return clientLockedError(remaining)
+	}
+	return nil
+}
+
+// Failure registra uma senha incorreta; quando a falha inicia um bloqueio, retorna o erro dele
+func (t *LoginThrottle) Failure(ctx context.Context, email, ipAddress string) error {
+	account := loginAccount(email)
+
+	block, err := t.accounts.RecordFailure(ctx, account)
+	if err != nil {
+		return err
+	}
+	if block > 0 {
+		return accountLockedError(block)
+	}
+
+	block, err = t.clients.RecordFailure(ctx, loginClient(account, ipAddress))
+	if err != nil {
+		return err
+	}
+	if block > 0 {
+		return clientLockedError(block)
+	}
+	return nil
+}
+
+// Success zera as falhas da conta e do cliente após um login bem-sucedido
+func (t *LoginThrottle) Success(ctx context.Context, email, ipAddress string) error {
+	account := loginAccount(email)
+	if err := t.accounts.Reset(ctx, account); err != nil {
+		return err
+	}
+	return t.clients.Reset(ctx, loginClient(account, ipAddress))
+}
+
+// Unlock libera a conta e todos os clientes bloqueados para ela (desbloqueio pela administração)
+func (t *LoginThrottle) Unlock(ctx context.Context, email string) error {
+	account := loginAccount(email)
+	if err := t.accounts.Reset(ctx, account); err != nil {
+		return err
+	}
+	return t.clients.ResetDerived(ctx, account)
+}
+
+// loginAccount identifica a conta pelo hash do email normalizado, inclusive para emails não cadastrados
+func loginAccount(email string) string {
+	return auth.HashToken(strings.ToLower(strings.TrimSpace(email)))
+}
+
+func loginClient(account, ipAddress string) string {
+	return account + ":" + ipAddress
+}
+
+func accountLockedError(retryAfter time.Duration) error {
+	return apperrors.NewLockedError("conta temporariamente bloqueada por excesso de tentativas", retryAfter)
+}
+
+func clientLockedError(retryAfter time.Duration) error {
+	return apperrors.NewRateLimitError("muitas tentativas de login; tente novamente mais tarde").WithRetryAfter(retryAfter)
+}
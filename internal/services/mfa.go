@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"auth-template/internal/config"
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 8
+	totpAllowedSkew   = 1
+)
+
+type MFAService struct {
+	userRepo    repository.UserRepository
+	mfaRepo     repository.MFARepository
+	authService service.AuthService
+	challenges  *MFAChallengeStore
+	throttle    *MFAThrottle
+	encryptor   *auth.Encryptor
+	config      *config.Config
+}
+
+func NewMFAService(
+	userRepo repository.UserRepository,
+	mfaRepo repository.MFARepository,
+	authService service.AuthService,
+	challenges *MFAChallengeStore,
+	throttle *MFAThrottle,
+	encryptor *auth.Encryptor,
+	config *config.Config,
+) service.MFAService {
+	return &MFAService{
+		userRepo:    userRepo,
+		mfaRepo:     mfaRepo,
+		authService: authService,
+		challenges:  challenges,
+		throttle:    throttle,
+		encryptor:   encryptor,
+		config:      config,
+	}
+}
+
+func (s *MFAService) SetupTOTP(ctx context.Context, current *auth.Claims) (*service.TOTPSetup, error) {
+	// Quem obteve apenas um token da sessão não pode cadastrar um segundo fator próprio na conta
+	if err := requireRecentAuth(current, s.config.Auth.ReauthMaxAge); err != nil {
+		return nil, err
+	}
+	userID := current.UserID
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError("token inválido")
+	}
+
+	factor, err := s.mfaRepo.FindTOTPByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar fator TOTP: %w", err)
+	}
+	if factor != nil && factor.IsConfirmed() {
+		return nil, apperrors.NewConflictError("autenticação em dois fatores já está ativa")
+	}
+
+	// Gerar novo segredo (substitui um cadastro pendente)
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.encryptor.Encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao cifrar segredo TOTP: %w", err)
+	}
+
+	if factor == nil {
+		factor = &entity.TOTPFactor{UserID: user.ID}
+	}
+	factor.SecretEncrypted = encrypted
+	factor.LastUsedStep = 0
+
+	if err := s.mfaRepo.SaveTOTP(ctx, factor); err != nil {
+		return nil, fmt.Errorf("erro ao salvar fator TOTP: %w", err)
+	}
+
+	return &service.TOTPSetup{
+		Secret: secret,
+		URI:    auth.TOTPURI(s.config.MFA.Issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *MFAService) ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	factor, err := s.mfaRepo.FindTOTPByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar fator TOTP: %w", err)
+	}
+	if factor == nil {
+		return nil, apperrors.NewNotFoundError("cadastro de autenticação em dois fatores não iniciado")
+	}
+	if factor.IsConfirmed() {
+		return nil, apperrors.NewConflictError("autenticação em dois fatores já está ativa")
+	}
+
+	ok, err := s.verifyCode(ctx, factor, code, false)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apperrors.NewValidationError("código inválido")
+	}
+
+	// Ativar o fator
+	now := time.Now()
+	factor.ConfirmedAt = &now
+	if err := s.mfaRepo.SaveTOTP(ctx, factor); err != nil {
+		return nil, fmt.Errorf("erro ao ativar fator TOTP: %w", err)
+	}
+
+	return s.generateRecoveryCodes(ctx, factor.UserID)
+}
+
+func (s *MFAService) DisableTOTP(ctx context.Context, current *auth.Claims, code string) error {
+	// O código do autenticador não basta: quem o observou não deve conseguir retirar o segundo fator
+	if err := requireRecentAuth(current, s.config.Auth.ReauthMaxAge); err != nil {
+		return err
+	}
+	userID := current.UserID
+
+	factor, err := s.confirmedFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.verifyCode(ctx, factor, code, true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperrors.NewValidationError("código inválido")
+	}
+
+	if err := s.mfaRepo.DeleteTOTP(ctx, userID); err != nil {
+		return fmt.Errorf("erro ao desativar fator TOTP: %w", err)
+	}
+	return nil
+}
+
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	factor, err := s.confirmedFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.verifyCode(ctx, factor, code, false)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, apperrors.NewValidationError("código inválido")
+	}
+
+	return s.generateRecoveryCodes(ctx, factor.UserID)
+}
+
+func (s *MFAService) VerifyLogin(ctx context.Context, mfaToken, code string) (*service.TokenPair, error) {
+	challenge, err := s.challenges.Get(ctx, mfaToken)
+	if err == ErrMFAChallengeNotFound {
+		return nil, apperrors.NewUnauthorizedError("desafio MFA inválido ou expirado")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	factor, err := s.confirmedFactor(ctx, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.verifyCode(ctx, factor, code, true)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.challenges.RegisterFailure(ctx, mfaToken); err != nil {
+			return nil, err
+		}
+		return nil, apperrors.NewUnauthorizedError("código inválido")
+	}
+
+	// Consumir o desafio; apenas uma requisição pode concluí-lo
+	consumed, err := s.challenges.Consume(ctx, mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, apperrors.NewUnauthorizedError("desafio MFA inválido ou expirado")
+	}
+
+	return s.authService.CompleteLogin(ctx, challenge.UserID, append(challenge.AMR, auth.AMROTP, auth.AMRMultiFactor))
+}
+
+func (s *MFAService) confirmedFactor(ctx context.Context, userID string) (*entity.TOTPFactor, error) {
+	factor, err := s.mfaRepo.FindTOTPByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar fator TOTP: %w", err)
+	}
+	if factor == nil || !factor.IsConfirmed() {
+		return nil, apperrors.NewNotFoundError("autenticação em dois fatores não está ativa")
+	}
+	return factor, nil
+}
+
+// verifyCode valida um código TOTP ou, com allowRecovery, alternativamente um código de recuperação. As falhas
+// contam no limite do usuário, somado entre todos os desafios; bloqueado, o código nem é conferido.
+func (s *MFAService) verifyCode(ctx context.Context, factor *entity.TOTPFactor, code string, allowRecovery bool) (bool, error) {
+	userID := fmt.Sprintf("%d", factor.UserID)
+	if err := s.throttle.Check(ctx, userID); err != nil {
+		return false, err
+	}
+
+	ok, err := s.verifyTOTP(ctx, factor, code)
+	if err != nil {
+		return false, err
+	}
+	if !ok && allowRecovery {
+		ok, err = s.mfaRepo.UseRecoveryCode(ctx, userID, auth.HashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return false, fmt.Errorf("erro ao validar código de recuperação: %w", err)
+		}
+	}
+
+	if !ok {
+		return false, s.throttle.Failure(ctx, userID)
+	}
+	return true, s.throttle.Success(ctx, userID)
+}
+
+// verifyTOTP valida o código e impede a reutilização de um código já aceito
+func (s *MFAService) verifyTOTP(ctx context.Context, factor *entity.TOTPFactor, code string) (bool, error) {
+	secret, err := s.encryptor.Decrypt(factor.SecretEncrypted)
+	if err != nil {
+		return false, fmt.Errorf("erro ao decifrar segredo TOTP: %w", err)
+	}
+
+	step, ok := auth.ValidateTOTPCode(secret, code, time.Now(), totpAllowedSkew)
+	if !ok || step <= factor.LastUsedStep {
+		return false, nil
+	}
+
+	// A gravação condicional garante que, entre requisições simultâneas com o mesmo código, só uma o aceite
+	used, err := s.mfaRepo.UseTOTPStep(ctx, factor.ID, step)
+	if err != nil {
+		return false, fmt.Errorf("erro ao atualizar fator TOTP: %w", err)
+	}
+	if !used {
+		return false, nil
+	}
+	factor.LastUsedStep = step
+	return true, nil
+}
+
+func (s *MFAService) generateRecoveryCodes(ctx context.Context, userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]entity.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, entity.RecoveryCode{
+			UserID:   userID,
+			CodeHash: auth.HashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, userID, records); err != nil {
+		return nil, fmt.Errorf("erro ao salvar códigos de recuperação: %w", err)
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode gera um código em base32 dividido em dois grupos para facilitar a digitação (ex: abcde-fghij)
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("erro ao gerar código de recuperação: %w", err)
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
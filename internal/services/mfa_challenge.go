@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/pkg/auth"
+)
+
+const (
+	mfaChallengeKeyPrefix         = "mfa:challenge:"
+	mfaChallengeAttemptsKeyPrefix = "mfa:challenge:attempts:"
+	mfaChallengeTokenBytes        = 32
+)
+
+// ErrMFAChallengeNotFound indica que o desafio não existe, expirou ou excedeu as tentativas
+var ErrMFAChallengeNotFound = errors.New("desafio MFA não encontrado ou expirado")
+
+// MFAChallenge é o login pendente do segundo fator. AMR contém os métodos já usados (ex: senha).
+type MFAChallenge struct {
+	UserID string   `json:"user_id"`
+	AMR    []string `json:"amr"`
+}
+
+// MFAChallengeStore guarda os desafios emitidos entre a validação da senha e a do segundo fator
+type MFAChallengeStore struct {
+	redis       *redis.Client
+	ttl         time.Duration
+	maxAttempts int
+}
+
+func NewMFAChallengeStore(redis *redis.Client, ttl time.Duration, maxAttempts int) *MFAChallengeStore {
+	return &MFAChallengeStore{
+		redis:       redis,
+		ttl:         ttl,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Create emite um novo desafio para o usuário e retorna o token em claro
+func (s *MFAChallengeStore) Create(ctx context.Context, userID string, amr []string) (string, error) {
+	token, err := auth.GenerateRandomToken(mfaChallengeTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(&MFAChallenge{UserID: userID, AMR: amr})
+	if err != nil {
+		return "", fmt.Errorf("erro ao serializar desafio MFA: %w", err)
+	}
+
+	key := fmt.Sprintf("%s%s", mfaChallengeKeyPrefix, auth.HashToken(token))
+	if err := s.redis.Set(ctx, key, data, s.ttl).Err(); err != nil {
+		return "", fmt.Errorf("erro ao armazenar desafio MFA: %w", err)
+	}
+	return token, nil
+}
+
+// Get retorna o desafio sem consumi-lo
+func (s *MFAChallengeStore) Get(ctx context.Context, token string) (*MFAChallenge, error) {
+	key := fmt.Sprintf("%s%s", mfaChallengeKeyPrefix, auth.HashToken(token))
+	data, err := s.redis.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMFAChallengeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar desafio MFA: %w", err)
+	}
+
+	var challenge MFAChallenge
+	if err := json.Unmarshal(data, &challenge); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar desafio MFA: %w", err)
+	}
+	return &challenge, nil
+}
+
+// RegisterFailure contabiliza uma tentativa inválida e descarta o desafio ao atingir o limite
+func (s *MFAChallengeStore) RegisterFailure(ctx context.Context, token string) error {
+	hash := auth.HashToken(token)
+	attemptsKey := fmt.Sprintf("%s%s", mfaChallengeAttemptsKeyPrefix, hash)
+
+	attempts, err := s.redis.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		return fmt.Errorf("erro ao registrar tentativa MFA: %w", err)
+	}
+	if attempts == 1 {
+		s.redis.Expire(ctx, attemptsKey, s.ttl)
+	}
+
+	if attempts >= int64(s.maxAttempts) {
+		_, err := s.Consume(ctx, token)
+		return err
+	}
+	return nil
+}
+
+// Consume remove o desafio e indica se ele ainda existia, garantindo uso único em requisições concorrentes
+func (s *MFAChallengeStore) Consume(ctx context.Context, token string) (bool, error) {
+	hash := auth.HashToken(token)
+	deleted, err := s.redis.Del(ctx, fmt.Sprintf("%s%s", mfaChallengeKeyPrefix, hash)).Result()
+	if err != nil {
+		return false, fmt.Errorf("erro ao remover desafio MFA: %w", err)
+	}
+	s.redis.Del(ctx, fmt.Sprintf("%s%s", mfaChallengeAttemptsKeyPrefix, hash))
+	return deleted > 0, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/internal/config"
+	apperrors "auth-template/internal/errors"
+	"auth-template/pkg/auth"
+)
+
+// MFAThrottle limita as falhas de código do segundo fator por usuário. O limite de cada desafio não basta:
+// quem conhece a senha abre um desafio novo a cada login, e o login bem-sucedido zera o LoginThrottle.
+// A janela e a duração dos bloqueios seguem as do login por senha.
+type MFAThrottle struct {
+	users auth.AttemptLimiter
+}
+
+func NewMFAThrottle(cfg *config.Config, redis *redis.Client) *MFAThrottle {
+	policy := auth.BruteForcePolicy{
+		MaxAttempts:   cfg.MFA.UserMaxAttempts,
+		Window:        cfg.Auth.LoginAttemptWindow,
+		BlockTime:     cfg.Auth.LoginLockout,
+		MaxBlockTime:  cfg.Auth.LoginMaxLockout,
+		LockoutMemory: loginLockoutMemory,
+	}
+
+	if cfg.Auth.LoginLockoutStore == LoginLockoutStoreMemory {
+		return &MFAThrottle{users: auth.NewBruteForceProtector(policy)}
+	}
+	return &MFAThrottle{users: NewBruteForceStore(redis, "mfa-user", policy)}
+}
+
+// Check recusa a verificação enquanto o segundo fator do usuário está bloqueado
+func (t *MFAThrottle) Check(ctx context.Context, userID string) error {
+	remaining, err := t.users.Locked(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return mfaLockedError(remaining)
+	}
+	return nil
+}
+
+// Failure registra um código incorreto; quando a falha inicia um bloqueio, retorna o erro dele
+func (t *MFAThrottle) Failure(ctx context.Context, userID string) error {
+	block, err := t.users.RecordFailure(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if block > 0 {
+		return mfaLockedError(block)
+	}
+	return nil
+}
+
+// Success zera as falhas do usuário após um código aceito
+func (t *MFAThrottle) Success(ctx context.Context, userID string) error {
+	return t.users.Reset(ctx, userID)
+}
+
+func mfaLockedError(retryAfter time.Duration) error {
+	return apperrors.NewLockedError("verificação em dois fatores temporariamente bloqueada por excesso de tentativas", retryAfter)
+}
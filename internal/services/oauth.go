@@ -0,0 +1,505 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"auth-template/internal/config"
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
+)
+
+const (
+	oauthClientIDBytes     = 16
+	oauthClientSecretBytes = 32
+	oauthTokenTypeBearer   = "Bearer"
+)
+
+// oauthGrant é a autorização que fundamenta os tokens emitidos a um cliente
+type oauthGrant struct {
+	UserID   string
+	Scope    string
+	AuthTime int64
+	AMR      []string
+	Nonce    string
+	// SessionID é a sessão de primeira parte em que o usuário consentiu; encerrada, os tokens do cliente
+	// deixam de valer
+	SessionID string
+	// FamilyID e RefreshTokenID identificam o próximo refresh token de uma família já existente
+	FamilyID       string
+	RefreshTokenID string
+}
+
+type OAuthService struct {
+	clientRepo     repository.OAuthClientRepository
+	userRepo       repository.UserRepository
+	sessionRepo    repository.SessionRepository
+	authService    service.AuthService
+	codes          *OAuthCodeStore
+	tokenManager   *auth.TokenManager
+	tokenBlacklist *TokenBlacklist
+	families       *RefreshTokenFamilyStore
+	securityEvents *SecurityEvents
+	idTokenKeys    *auth.Keyring
+	config         *config.Config
+}
+
+func NewOAuthService(
+	clientRepo repository.OAuthClientRepository,
+	userRepo repository.UserRepository,
+	sessionRepo repository.SessionRepository,
+	authService service.AuthService,
+	codes *OAuthCodeStore,
+	tokenManager *auth.TokenManager,
+	tokenBlacklist *TokenBlacklist,
+	families *RefreshTokenFamilyStore,
+	securityEvents *SecurityEvents,
+	idTokenKeys *auth.Keyring,
+	config *config.Config,
+) service.OAuthService {
+	return &OAuthService{
+		clientRepo:     clientRepo,
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		authService:    authService,
+		codes:          codes,
+		tokenManager:   tokenManager,
+		tokenBlacklist: tokenBlacklist,
+		families:       families,
+		securityEvents: securityEvents,
+		idTokenKeys:    idTokenKeys,
+		config:         config,
+	}
+}
+
+func (s *OAuthService) RegisterClient(ctx context.Context, registration *service.OAuthClientRegistration) (*entity.OAuthClient, string, error) {
+	if strings.TrimSpace(registration.Name) == "" {
+		return nil, "", apperrors.NewValidationError("nome do cliente é obrigatório")
+	}
+	if registration.Type != entity.OAuthClientConfidential && registration.Type != entity.OAuthClientPublic {
+		return nil, "", apperrors.NewValidationError("tipo de cliente inválido")
+	}
+
+	for _, grant := range registration.GrantTypes {
+		switch grant {
+		case entity.GrantTypeAuthorizationCode, entity.GrantTypeRefreshToken:
+		case entity.GrantTypeClientCredentials:
+			if registration.Type != entity.OAuthClientConfidential {
+				return nil, "", apperrors.NewValidationError("client_credentials exige um cliente confidencial")
+			}
+		default:
+			return nil, "", apperrors.NewValidationError(fmt.Sprintf("grant_type não suportado: %s", grant))
+		}
+	}
+
+	for _, redirectURI := range append(registration.RedirectURIs, registration.PostLogoutRedirectURIs...) {
+		parsed, err := url.Parse(redirectURI)
+		if err != nil || !parsed.IsAbs() || parsed.Fragment != "" {
+			return nil, "", apperrors.NewValidationError(fmt.Sprintf("redirect_uri inválido: %s", redirectURI))
+		}
+	}
+
+	clientID, err := auth.GenerateRandomToken(oauthClientIDBytes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := &entity.OAuthClient{
+		ID:                     clientID,
+		Name:                   strings.TrimSpace(registration.Name),
+		Type:                   registration.Type,
+		RedirectURIs:           strings.Join(registration.RedirectURIs, " "),
+		PostLogoutRedirectURIs: strings.Join(registration.PostLogoutRedirectURIs, " "),
+		Scopes:                 strings.Join(registration.Scopes, " "),
+		GrantTypes:             strings.Join(registration.GrantTypes, " "),
+	}
+
+	// Apenas o hash do segredo é armazenado; o valor é exibido uma única vez no cadastro
+	var secret string
+	if client.IsConfidential() {
+		secret, err = auth.GenerateRandomToken(oauthClientSecretBytes)
+		if err != nil {
+			return nil, "", err
+		}
+		client.SecretHash = auth.HashToken(secret)
+	}
+
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return nil, "", fmt.Errorf("erro ao criar cliente OAuth: %w", err)
+	}
+
+	return client, secret, nil
+}
+
+func (s *OAuthService) BeginAuthorization(ctx context.Context, req *service.AuthorizationRequest) (string, error) {
+	if err := s.validateAuthorizationRequest(ctx, req); err != nil {
+		return s.authorizationErrorRedirect(req, err)
+	}
+
+	loginURL, err := url.Parse(s.config.OAuth.LoginURL)
+	if err != nil {
+		return "", fmt.Errorf("OAUTH_LOGIN_URL inválida: %w", err)
+	}
+	loginURL.RawQuery = url.Values{
+		"response_type":         {req.ResponseType},
+		"client_id":             {req.ClientID},
+		"redirect_uri":          {req.RedirectURI},
+		"scope":                 {req.Scope},
+		"state":                 {req.State},
+		"code_challenge":        {req.CodeChallenge},
+		"code_challenge_method": {req.CodeChallengeMethod},
+		"nonce":                 {req.Nonce},
+	}.Encode()
+	return loginURL.String(), nil
+}
+
+// validateAuthorizationRequest retorna AppError quando o cliente ou o redirect_uri são inválidos e OAuthError
+// para os demais erros. Em caso de sucesso, req.Scope passa a conter o escopo efetivamente concedido.
+func (s *OAuthService) validateAuthorizationRequest(ctx context.Context, req *service.AuthorizationRequest) error {
+	client, err := s.clientRepo.FindByID(ctx, req.ClientID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar cliente OAuth: %w", err)
+	}
+	if client == nil {
+		return apperrors.NewValidationError("client_id inválido")
+	}
+
+	// Sem um redirect_uri registrado não há para onde devolver o erro com segurança
+	if !client.AllowsRedirectURI(req.RedirectURI) {
+		return apperrors.NewValidationError("redirect_uri não registrado para o cliente")
+	}
+
+	if req.ResponseType != "code" {
+		return apperrors.NewOAuthError(apperrors.OAuthUnsupportedResponseType, "apenas response_type=code é suportado")
+	}
+	if !client.AllowsGrant(entity.GrantTypeAuthorizationCode) {
+		return apperrors.NewOAuthError(apperrors.OAuthUnauthorizedClient, "cliente não autorizado a usar authorization_code")
+	}
+
+	// PKCE é obrigatório para todos os clientes, confidenciais ou públicos (OAuth 2.1)
+	if req.CodeChallenge == "" {
+		return apperrors.NewOAuthError(apperrors.OAuthInvalidRequest, "code_challenge é obrigatório")
+	}
+	if req.CodeChallengeMethod != auth.PKCEMethodS256 {
+		return apperrors.NewOAuthError(apperrors.OAuthInvalidRequest, "code_challenge_method deve ser S256")
+	}
+
+	scope, err := resolveScope(client.ScopeList(), req.Scope)
+	if err != nil {
+		return err
+	}
+	req.Scope = scope
+
+	return nil
+}
+
+func (s *OAuthService) Authorize(ctx context.Context, session *auth.Claims, req *service.AuthorizationRequest, approved bool) (string, error) {
+	if err := s.validateAuthorizationRequest(ctx, req); err != nil {
+		return s.authorizationErrorRedirect(req, err)
+	}
+	if !approved {
+		return s.authorizationErrorRedirect(req, apperrors.NewOAuthError(apperrors.OAuthAccessDenied, "o usuário negou o acesso"))
+	}
+
+	code, err := s.codes.Save(ctx, &oauthAuthorizationCode{
+		ClientID:      req.ClientID,
+		UserID:        session.UserID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		CodeChallenge: req.CodeChallenge,
+		Nonce:         req.Nonce,
+		AuthTime:      session.AuthTime,
+		AMR:           session.AMR,
+		SessionID:     session.SessionID,
+		IssuedAtMs:    time.Now().UnixMilli(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return s.authorizationRedirect(req.RedirectURI, url.Values{"code": {code}}, req.State), nil
+}
+
+func (s *OAuthService) Token(ctx context.Context, req *service.TokenRequest) (*service.OAuthTokenResponse, error) {
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case entity.GrantTypeAuthorizationCode, entity.GrantTypeRefreshToken, entity.GrantTypeClientCredentials:
+		if !client.AllowsGrant(req.GrantType) {
+			return nil, apperrors.NewOAuthError(apperrors.OAuthUnauthorizedClient, "cliente não autorizado a usar este grant_type")
+		}
+	default:
+		return nil, apperrors.NewOAuthError(apperrors.OAuthUnsupportedGrantType, "grant_type não suportado")
+	}
+
+	switch req.GrantType {
+	case entity.GrantTypeAuthorizationCode:
+		return s.exchangeAuthorizationCode(ctx, client, req)
+	case entity.GrantTypeRefreshToken:
+		return s.exchangeRefreshToken(ctx, client, req)
+	default:
+		return s.clientCredentials(ctx, client, req)
+	}
+}
+
+func (s *OAuthService) exchangeAuthorizationCode(ctx context.Context, client *entity.OAuthClient, req *service.TokenRequest) (*service.OAuthTokenResponse, error) {
+	authorization, err := s.codes.Consume(ctx, req.Code)
+	if err == ErrOAuthCodeNotFound {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidGrant, "código inválido ou expirado")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if authorization.ClientID != client.ID || authorization.RedirectURI != req.RedirectURI {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidGrant, "código inválido ou expirado")
+	}
+	if !auth.VerifyPKCE(req.CodeVerifier, authorization.CodeChallenge) {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidGrant, "code_verifier inválido")
+	}
+
+	// Entre o consentimento e a troca, a sessão pode ter sido encerrada, os tokens do usuário revogados (ex: troca
+	// de senha) ou a conta excluída ou desativada
+	revoked, err := s.isGrantRevoked(ctx, &auth.Claims{
+		UserID:     authorization.UserID,
+		SessionID:  authorization.SessionID,
+		IssuedAtMs: authorization.IssuedAtMs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidGrant, "código inválido ou expirado")
+	}
+	user, err := s.userRepo.FindByID(ctx, authorization.UserID)
+	if err != nil || user.IsDisabled() {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidGrant, "código inválido ou expirado")
+	}
+
+	return s.issueTokens(ctx, client, &oauthGrant{
+		UserID:    authorization.UserID,
+		Scope:     authorization.Scope,
+		AuthTime:  authorization.AuthTime,
+		AMR:       authorization.AMR,
+		Nonce:     authorization.Nonce,
+		SessionID: authorization.SessionID,
+	}, client.AllowsGrant(entity.GrantTypeRefreshToken))
+}
+
+// exchangeRefreshToken rotaciona o refresh token: o token apresentado é invalidado e um novo par é emitido.
+// O escopo pode ser reduzido, nunca ampliado.
+func (s *OAuthService) exchangeRefreshToken(ctx context.Context, client *entity.OAuthClient, req *service.TokenRequest) (*service.OAuthTokenResponse, error) {
+	claims, err := s.tokenManager.ValidateToken(req.RefreshToken, auth.TokenTypeRefresh)
+	if err != nil || claims.ClientID != client.ID {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidGrant, "refresh token inválido")
+	}
+
+	// A revogação dos tokens do usuário (ex: troca de senha) e o encerramento da sessão em que ele consentiu
+	// também encerram as autorizações delegadas
+	revoked, err := s.isGrantRevoked(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidGrant, "refresh token inválido")
+	}
+
+	scope, err := resolveScope(strings.Fields(claims.Scope), req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// Um refresh token já rotacionado encerra a família inteira (OAuth 2.1, seção 4.3.1)
+	familyID, jti, err := rotateRefreshToken(ctx, s.families, s.tokenBlacklist, s.securityEvents, req.RefreshToken, claims)
+	if err == ErrRefreshTokenReuse || err == ErrRefreshFamilyRevoked {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidGrant, "refresh token inválido")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, client, &oauthGrant{
+		UserID:         claims.UserID,
+		Scope:          scope,
+		AuthTime:       claims.AuthTime,
+		AMR:            claims.AMR,
+		SessionID:      claims.SessionID,
+		FamilyID:       familyID,
+		RefreshTokenID: jti,
+	}, true)
+}
+
+// isGrantRevoked verifica, como na renovação de primeira parte, a revogação pelo jti, pela sessão e pela marca do
+// usuário e, para os tokens ligados a uma sessão, se ela continua ativa
+func (s *OAuthService) isGrantRevoked(ctx context.Context, claims *auth.Claims) (bool, error) {
+	revoked, err := s.tokenBlacklist.IsTokenRevoked(ctx, claims)
+	if err != nil || revoked || claims.SessionID == "" {
+		return revoked, err
+	}
+
+	session, err := s.sessionRepo.FindByID(ctx, claims.SessionID)
+	if err != nil {
+		return false, fmt.Errorf("erro ao buscar sessão: %w", err)
+	}
+	return session == nil || session.IsRevoked() || fmt.Sprintf("%d", session.UserID) != claims.UserID, nil
+}
+
+// clientCredentials emite um access token em nome do próprio cliente, sem usuário e sem refresh token
+func (s *OAuthService) clientCredentials(ctx context.Context, client *entity.OAuthClient, req *service.TokenRequest) (*service.OAuthTokenResponse, error) {
+	if !client.IsConfidential() {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthUnauthorizedClient, "client_credentials exige um cliente confidencial")
+	}
+
+	scope, err := resolveScope(client.ScopeList(), req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, client, &oauthGrant{Scope: scope}, false)
+}
+
+// authenticateClient identifica o cliente. Clientes confidenciais devem apresentar o segredo;
+// clientes públicos são identificados apenas pelo client_id e dependem do PKCE.
+func (s *OAuthService) authenticateClient(ctx context.Context, clientID, clientSecret string) (*entity.OAuthClient, error) {
+	if clientID == "" {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidClient, "cliente não autenticado")
+	}
+
+	client, err := s.clientRepo.FindByID(ctx, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar cliente OAuth: %w", err)
+	}
+	if client == nil {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidClient, "cliente não autenticado")
+	}
+
+	if client.IsConfidential() {
+		if subtle.ConstantTimeCompare([]byte(auth.HashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+			return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidClient, "cliente não autenticado")
+		}
+	}
+
+	return client, nil
+}
+
+// issueTokens emite o access token e, conforme o caso, o refresh token e o ID token (escopo openid)
+func (s *OAuthService) issueTokens(ctx context.Context, client *entity.OAuthClient, grant *oauthGrant, withRefresh bool) (*service.OAuthTokenResponse, error) {
+	accessToken, err := s.tokenManager.GenerateTokenWithClaims(&auth.Claims{
+		UserID:    grant.UserID,
+		Type:      auth.TokenTypeAccess,
+		ClientID:  client.ID,
+		Scope:     grant.Scope,
+		AuthTime:  grant.AuthTime,
+		AMR:       grant.AMR,
+		SessionID: grant.SessionID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar access token: %w", err)
+	}
+
+	response := &service.OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   oauthTokenTypeBearer,
+		ExpiresIn:   int64(s.tokenManager.TTL(auth.TokenTypeAccess).Seconds()),
+		Scope:       grant.Scope,
+	}
+
+	if withRefresh {
+		familyID, jti := grant.FamilyID, grant.RefreshTokenID
+		if familyID == "" {
+			if familyID, jti, err = s.families.Start(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		response.RefreshToken, err = s.tokenManager.GenerateTokenWithClaims(&auth.Claims{
+			UserID:         grant.UserID,
+			Type:           auth.TokenTypeRefresh,
+			ClientID:       client.ID,
+			Scope:          grant.Scope,
+			AuthTime:       grant.AuthTime,
+			AMR:            grant.AMR,
+			SessionID:      grant.SessionID,
+			FamilyID:       familyID,
+			StandardClaims: jwt.StandardClaims{Id: jti},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao gerar refresh token: %w", err)
+		}
+	}
+
+	if grant.UserID != "" && hasScope(grant.Scope, oidcScopeOpenID) {
+		response.IDToken, err = s.issueIDToken(ctx, client, grant)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// resolveScope valida o escopo solicitado contra os permitidos. Sem escopo solicitado, todos os permitidos
+// são concedidos.
+func resolveScope(allowed []string, requested string) (string, error) {
+	if strings.TrimSpace(requested) == "" {
+		return strings.Join(allowed, " "), nil
+	}
+
+	permitted := make(map[string]bool, len(allowed))
+	for _, scope := range allowed {
+		permitted[scope] = true
+	}
+
+	var granted []string
+	seen := make(map[string]bool)
+	for _, scope := range strings.Fields(requested) {
+		if !permitted[scope] {
+			return "", apperrors.NewOAuthError(apperrors.OAuthInvalidScope, fmt.Sprintf("escopo não permitido: %s", scope))
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			granted = append(granted, scope)
+		}
+	}
+	return strings.Join(granted, " "), nil
+}
+
+// authorizationErrorRedirect devolve os erros OAuth ao cliente pelo redirect_uri; os demais são retornados
+func (s *OAuthService) authorizationErrorRedirect(req *service.AuthorizationRequest, err error) (string, error) {
+	oauthErr, ok := err.(*apperrors.OAuthError)
+	if !ok {
+		return "", err
+	}
+	return s.authorizationRedirect(req.RedirectURI, url.Values{
+		"error":             {oauthErr.Code},
+		"error_description": {oauthErr.Description},
+	}, req.State), nil
+}
+
+// authorizationRedirect monta a URL de retorno ao cliente preservando a query já registrada no redirect_uri.
+// O parâmetro iss identifica este servidor, protegendo clientes com mais de um provedor (RFC 9207).
+func (s *OAuthService) authorizationRedirect(redirectURI string, params url.Values, state string) string {
+	redirect, _ := url.Parse(redirectURI)
+	query := redirect.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("iss", s.config.OAuth.Issuer)
+	if state != "" {
+		query.Set("state", state)
+	}
+	redirect.RawQuery = query.Encode()
+	return redirect.String()
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/pkg/auth"
+)
+
+const (
+	oauthCodeKeyPrefix = "oauth:code:"
+	oauthCodeBytes     = 32
+)
+
+// ErrOAuthCodeNotFound indica que o código de autorização não existe, expirou ou já foi usado
+var ErrOAuthCodeNotFound = errors.New("código de autorização não encontrado ou expirado")
+
+// oauthAuthorizationCode guarda a autorização concedida pelo usuário até a troca do código por tokens
+type oauthAuthorizationCode struct {
+	ClientID      string   `json:"client_id"`
+	UserID        string   `json:"user_id"`
+	RedirectURI   string   `json:"redirect_uri"`
+	Scope         string   `json:"scope"`
+	CodeChallenge string   `json:"code_challenge"`
+	Nonce         string   `json:"nonce,omitempty"`
+	AuthTime      int64    `json:"auth_time,omitempty"`
+	AMR           []string `json:"amr,omitempty"`
+	SessionID     string   `json:"sid,omitempty"`
+	// IssuedAtMs é o instante da autorização, comparado à marca de revogação do usuário na troca do código
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
+}
+
+// OAuthCodeStore armazena os códigos de autorização no Redis, indexados pelo hash do código
+type OAuthCodeStore struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+func NewOAuthCodeStore(redis *redis.Client, ttl time.Duration) *OAuthCodeStore {
+	return &OAuthCodeStore{
+		redis: redis,
+		ttl:   ttl,
+	}
+}
+
+// Save armazena a autorização e retorna o código a ser entregue ao cliente
+func (s *OAuthCodeStore) Save(ctx context.Context, authorization *oauthAuthorizationCode) (string, error) {
+	code, err := auth.GenerateRandomToken(oauthCodeBytes)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(authorization)
+	if err != nil {
+		return "", fmt.Errorf("erro ao serializar código de autorização: %w", err)
+	}
+
+	key := fmt.Sprintf("%s%s", oauthCodeKeyPrefix, auth.HashToken(code))
+	if err := s.redis.Set(ctx, key, data, s.ttl).Err(); err != nil {
+		return "", fmt.Errorf("erro ao armazenar código de autorização: %w", err)
+	}
+	return code, nil
+}
+
+// Consume recupera e remove a autorização, garantindo que cada código seja trocado uma única vez
+func (s *OAuthCodeStore) Consume(ctx context.Context, code string) (*oauthAuthorizationCode, error) {
+	key := fmt.Sprintf("%s%s", oauthCodeKeyPrefix, auth.HashToken(code))
+	data, err := s.redis.GetDel(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrOAuthCodeNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar código de autorização: %w", err)
+	}
+
+	var authorization oauthAuthorizationCode
+	if err := json.Unmarshal(data, &authorization); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar código de autorização: %w", err)
+	}
+	return &authorization, nil
+}
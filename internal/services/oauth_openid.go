@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
+)
+
+const (
+	oidcScopeOpenID = "openid"
+	oidcScopeEmail  = "email"
+)
+
+// idTokenClaims são as claims do ID token emitido aos clientes (OpenID Connect Core, seção 2)
+type idTokenClaims struct {
+	Nonce         string   `json:"nonce,omitempty"`
+	AuthTime      int64    `json:"auth_time,omitempty"`
+	AMR           []string `json:"amr,omitempty"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified *bool    `json:"email_verified,omitempty"`
+	jwt.StandardClaims
+}
+
+func (s *OAuthService) issueIDToken(ctx context.Context, client *entity.OAuthClient, grant *oauthGrant) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, grant.UserID)
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+
+	jti, err := auth.GenerateRandomToken(tokenIDBytes)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &idTokenClaims{
+		Nonce:    grant.Nonce,
+		AuthTime: grant.AuthTime,
+		AMR:      grant.AMR,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Issuer:    s.config.OAuth.Issuer,
+			Subject:   grant.UserID,
+			Audience:  client.ID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(s.config.OAuth.IDTokenTTL).Unix(),
+		},
+	}
+	if hasScope(grant.Scope, oidcScopeEmail) {
+		verified := user.IsEmailVerified()
+		claims.Email = user.Email
+		claims.EmailVerified = &verified
+	}
+
+	idToken, err := s.idTokenKeys.Sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("erro ao assinar ID token: %w", err)
+	}
+	return idToken, nil
+}
+
+// UserInfo retorna as claims do usuário dono de um access token delegado com o escopo openid
+func (s *OAuthService) UserInfo(ctx context.Context, accessToken string) (*service.UserInfo, error) {
+	claims, err := s.tokenManager.ValidateToken(accessToken, auth.TokenTypeAccess)
+	if err != nil || claims.ClientID == "" || claims.UserID == "" {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidToken, "token inválido")
+	}
+
+	// As mesmas revogações do AuthMiddleware: pelo jti, pela sessão e pela marca do usuário
+	revoked, err := s.tokenBlacklist.IsTokenRevoked(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidToken, "token inválido")
+	}
+
+	if !hasScope(claims.Scope, oidcScopeOpenID) {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInsufficientScope, "o token não possui o escopo openid")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, apperrors.NewOAuthError(apperrors.OAuthInvalidToken, "token inválido")
+	}
+
+	info := &service.UserInfo{Subject: claims.UserID}
+	if hasScope(claims.Scope, oidcScopeEmail) {
+		verified := user.IsEmailVerified()
+		info.Email = user.Email
+		info.EmailVerified = &verified
+	}
+	return info, nil
+}
+
+func (s *OAuthService) Discovery() *service.OpenIDConfiguration {
+	issuer := s.config.OAuth.Issuer
+	return &service.OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserInfoEndpoint:                  issuer + "/oauth/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		EndSessionEndpoint:                issuer + "/oauth/logout",
+		ScopesSupported:                   []string{oidcScopeOpenID, oidcScopeEmail},
+		ResponseTypesSupported:            []string{"code"},
+		ResponseModesSupported:            []string{"query"},
+		GrantTypesSupported:               []string{entity.GrantTypeAuthorizationCode, entity.GrantTypeRefreshToken, entity.GrantTypeClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{s.idTokenKeys.Algorithm()},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{auth.PKCEMethodS256},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "amr", "email", "email_verified"},
+		AuthorizationResponseIssParameter: true,
+	}
+}
+
+// JWKS reúne as chaves públicas dos access tokens e dos ID tokens, que podem ser a mesma chave
+func (s *OAuthService) JWKS() auth.JWKSet {
+	jwks := s.tokenManager.JWKS()
+	for _, key := range s.idTokenKeys.JWKS().Keys {
+		if _, ok := jwks.Key(key.Kid); !ok {
+			jwks.Keys = append(jwks.Keys, key)
+		}
+	}
+	return jwks
+}
+
+func (s *OAuthService) BeginLogout(ctx context.Context, req *service.LogoutRequest) (string, error) {
+	if err := s.validateLogoutRequest(ctx, req); err != nil {
+		return "", err
+	}
+
+	logoutURL, err := url.Parse(s.config.OAuth.LogoutURL)
+	if err != nil {
+		return "", fmt.Errorf("OAUTH_LOGOUT_URL inválida: %w", err)
+	}
+	params := url.Values{}
+	for key, value := range map[string]string{
+		"id_token_hint":            req.IDTokenHint,
+		"client_id":                req.ClientID,
+		"post_logout_redirect_uri": req.PostLogoutRedirectURI,
+		"state":                    req.State,
+	} {
+		if value != "" {
+			params.Set(key, value)
+		}
+	}
+	logoutURL.RawQuery = params.Encode()
+	return logoutURL.String(), nil
+}
+
+func (s *OAuthService) Logout(ctx context.Context, req *service.LogoutRequest, refreshToken string) (string, error) {
+	if err := s.validateLogoutRequest(ctx, req); err != nil {
+		return "", err
+	}
+
+	// Um refresh token já inválido não impede o retorno ao cliente: a sessão já está encerrada
+	if refreshToken != "" {
+		if err := s.authService.Logout(ctx, refreshToken, ""); err != nil {
+			if _, ok := err.(*apperrors.AppError); !ok {
+				return "", err
+			}
+		}
+	}
+
+	if req.PostLogoutRedirectURI == "" {
+		return "", nil
+	}
+
+	redirect, _ := url.Parse(req.PostLogoutRedirectURI)
+	if req.State != "" {
+		query := redirect.Query()
+		query.Set("state", req.State)
+		redirect.RawQuery = query.Encode()
+	}
+	return redirect.String(), nil
+}
+
+// validateLogoutRequest identifica o cliente pelo client_id ou pelo id_token_hint e exige que o
+// post_logout_redirect_uri esteja registrado, impedindo que o logout sirva de redirecionamento aberto
+func (s *OAuthService) validateLogoutRequest(ctx context.Context, req *service.LogoutRequest) error {
+	clientID := req.ClientID
+
+	if req.IDTokenHint != "" {
+		var claims idTokenClaims
+		err := s.idTokenKeys.Parse(req.IDTokenHint, &claims)
+		// O ID token costuma estar expirado quando o usuário sai; a assinatura continua sendo exigida
+		if ve, ok := err.(*jwt.ValidationError); err != nil && !(ok && ve.Errors == jwt.ValidationErrorExpired) {
+			return apperrors.NewValidationError("id_token_hint inválido")
+		}
+		if claims.Issuer != s.config.OAuth.Issuer {
+			return apperrors.NewValidationError("id_token_hint inválido")
+		}
+		if clientID != "" && claims.Audience != clientID {
+			return apperrors.NewValidationError("id_token_hint não pertence ao cliente")
+		}
+		clientID = claims.Audience
+	}
+
+	if req.PostLogoutRedirectURI == "" {
+		return nil
+	}
+	if clientID == "" {
+		return apperrors.NewValidationError("client_id ou id_token_hint é obrigatório com post_logout_redirect_uri")
+	}
+
+	client, err := s.clientRepo.FindByID(ctx, clientID)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar cliente OAuth: %w", err)
+	}
+	if client == nil {
+		return apperrors.NewValidationError("client_id inválido")
+	}
+	if !client.AllowsPostLogoutRedirectURI(req.PostLogoutRedirectURI) {
+		return apperrors.NewValidationError("post_logout_redirect_uri não registrado para o cliente")
+	}
+	return nil
+}
+
+// hasScope informa se o escopo (lista separada por espaço) contém o valor
+func hasScope(scope, value string) bool {
+	for _, s := range strings.Fields(scope) {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"auth-template/internal/config"
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
+	"auth-template/pkg/logger"
+	"auth-template/pkg/oidc"
+	"auth-template/pkg/validation"
+)
+
+const oidcNonceBytes = 32
+
+type OIDCService struct {
+	providers    map[string]*oidc.Provider
+	userRepo     repository.UserRepository
+	identityRepo repository.IdentityRepository
+	roleRepo     repository.RoleRepository
+	authService  service.AuthService
+	states       *OIDCStateStore
+	log          *logger.Logger
+}
+
+func NewOIDCService(
+	cfg *config.Config,
+	userRepo repository.UserRepository,
+	identityRepo repository.IdentityRepository,
+	roleRepo repository.RoleRepository,
+	authService service.AuthService,
+	states *OIDCStateStore,
+	log *logger.Logger,
+) service.OIDCService {
+	providers := make(map[string]*oidc.Provider, len(cfg.OIDC.Providers))
+	for _, p := range cfg.OIDC.Providers {
+		providers[p.Name] = oidc.NewProvider(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, nil)
+	}
+
+	return &OIDCService{
+		providers:    providers,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		roleRepo:     roleRepo,
+		authService:  authService,
+		states:       states,
+		log:          log,
+	}
+}
+
+// Providers retorna os nomes dos provedores configurados, para que o cliente exiba os botões de login
+func (s *OIDCService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginLogin gera state, nonce e code_verifier (PKCE) e retorna a URL de autorização do provedor, junto com o
+// vínculo que prende o state ao navegador que iniciou o login
+func (s *OIDCService) BeginLogin(ctx context.Context, providerName string) (*service.OIDCAuthorization, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, apperrors.NewNotFoundError("provedor não encontrado")
+	}
+
+	nonce, err := auth.GenerateRandomToken(oidcNonceBytes)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := auth.GeneratePKCEVerifier()
+	if err != nil {
+		return nil, err
+	}
+	binding, err := auth.GenerateRandomToken(oidcNonceBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.states.Save(ctx, &oidcAuthorization{
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		BindingHash:  auth.HashToken(binding),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, auth.PKCEChallenge(verifier))
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.OIDCAuthorization{
+		AuthorizationURL: authURL,
+		State:            state,
+		Binding:          binding,
+	}, nil
+}
+
+// FinishLogin troca o código pelo ID token, valida-o e autentica o usuário vinculado à identidade externa. O
+// state só é aceito com o vínculo do navegador que iniciou a autorização: sem ele, um code e state obtidos por
+// outra pessoa poderiam ser concluídos no navegador da vítima e entrar na conta de quem os obteve.
+func (s *OIDCService) FinishLogin(ctx context.Context, providerName, code, state, binding string) (*service.LoginResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, apperrors.NewNotFoundError("provedor não encontrado")
+	}
+
+	authorization, err := s.states.Consume(ctx, state)
+	if err == ErrOIDCStateNotFound {
+		return nil, apperrors.NewValidationError("state inválido ou expirado")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if authorization.Provider != providerName {
+		return nil, apperrors.NewValidationError("state inválido ou expirado")
+	}
+	if binding == "" || subtle.ConstantTimeCompare([]byte(auth.HashToken(binding)), []byte(authorization.BindingHash)) != 1 {
+		return nil, apperrors.NewValidationError("state inválido ou expirado")
+	}
+
+	// O detalhe do erro do provedor fica apenas no log
+	token, err := provider.Exchange(ctx, code, authorization.CodeVerifier)
+	if err != nil {
+		s.log.Error("Erro na troca do código com o provedor %s: %v", providerName, err)
+		return nil, apperrors.NewUnauthorizedError("não foi possível autenticar com o provedor")
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, authorization.Nonce)
+	if err != nil {
+		s.log.Error("ID token recusado do provedor %s: %v", providerName, err)
+		return nil, apperrors.NewUnauthorizedError("não foi possível autenticar com o provedor")
+	}
+
+	user, err := s.resolveUser(ctx, providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authService.StartSession(ctx, user, []string{auth.AMRFederated})
+}
+
+func (s *OIDCService) ListIdentities(ctx context.Context, userID string) ([]entity.UserIdentity, error) {
+	identities, err := s.identityRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar identidades: %w", err)
+	}
+	return identities, nil
+}
+
+// resolveUser encontra o usuário da identidade externa, vinculando-a a uma conta existente com o mesmo
+// email ou criando uma nova conta. O vínculo automático só ocorre quando o provedor e a conta local
+// atestam o email, evitando que um cadastro prévio com o email de outra pessoa capture a identidade. Contas no
+// prazo de carência da exclusão também são encontradas, para que o login pelo provedor as restaure como o login
+// por senha.
+func (s *OIDCService) resolveUser(ctx context.Context, providerName string, claims *oidc.IDTokenClaims) (*entity.User, error) {
+	now := time.Now()
+
+	identity, err := s.identityRepo.FindByProviderSubject(ctx, providerName, claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar identidade: %w", err)
+	}
+	if identity != nil {
+		user, err := s.userRepo.FindByIDWithDeleted(ctx, fmt.Sprintf("%d", identity.UserID))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+		}
+		if user == nil || (user.DeletedAt.Valid && !user.IsPendingDeletion()) {
+			return nil, apperrors.NewUnauthorizedError("conta vinculada não encontrada")
+		}
+
+		identity.Email = claims.Email
+		identity.LastLoginAt = &now
+		if err := s.identityRepo.Update(ctx, identity); err != nil {
+			return nil, fmt.Errorf("erro ao atualizar identidade: %w", err)
+		}
+		return user, nil
+	}
+
+	email, err := validation.ValidateEmail(claims.Email)
+	if err != nil {
+		return nil, apperrors.NewValidationError("o provedor não informou um email válido")
+	}
+
+	identity = &entity.UserIdentity{
+		Provider:    providerName,
+		Subject:     claims.Subject,
+		Email:       email,
+		LastLoginAt: &now,
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar email: %w", err)
+	}
+	if exists {
+		// ExistsByEmail também conta as contas excluídas: a conta ainda restaurável é buscada como no login por
+		// senha, e as demais continuam ocupando o endereço até a remoção definitiva
+		user, err := s.userRepo.FindByEmail(ctx, email)
+		if err != nil {
+			user, err = s.userRepo.FindPendingDeletionByEmail(ctx, email)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+			}
+			if user == nil {
+				return nil, apperrors.NewConflictError("email já cadastrado")
+			}
+		}
+		if !claims.EmailVerified || !user.IsEmailVerified() {
+			return nil, apperrors.NewConflictError("email já cadastrado; entre com sua senha e confirme o email antes de usar este provedor")
+		}
+
+		identity.UserID = user.ID
+		if err := s.identityRepo.Create(ctx, identity); err != nil {
+			return nil, fmt.Errorf("erro ao vincular identidade: %w", err)
+		}
+		return user, nil
+	}
+
+	// Conta nova: a senha aleatória não é revelada; o usuário pode defini-la pela recuperação de senha
+	password, err := auth.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+
+	user := &entity.User{
+		Email:    email,
+		Password: string(hashedPassword),
+	}
+	if claims.EmailVerified {
+		user.EmailVerifiedAt = &now
+	}
+
+	if err := s.identityRepo.CreateWithUser(ctx, user, identity); err != nil {
+		return nil, fmt.Errorf("erro ao criar usuário: %w", err)
+	}
+	if err := s.roleRepo.AssignByName(ctx, user.ID, entity.RoleUser); err != nil {
+		return nil, fmt.Errorf("erro ao atribuir papel padrão: %w", err)
+	}
+	return user, nil
+}
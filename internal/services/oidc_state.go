@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/pkg/auth"
+)
+
+const (
+	oidcStateKeyPrefix = "oidc:state:"
+	oidcStateBytes     = 32
+)
+
+// ErrOIDCStateNotFound indica que o state não existe, expirou ou já foi usado
+var ErrOIDCStateNotFound = errors.New("state OIDC não encontrado ou expirado")
+
+// oidcAuthorization guarda os segredos de uma autorização em andamento com um provedor externo
+type oidcAuthorization struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	// BindingHash é o hash do valor guardado no cookie do navegador que iniciou a autorização
+	BindingHash string `json:"binding_hash"`
+}
+
+// OIDCStateStore armazena as autorizações OIDC pendentes no Redis, indexadas pelo hash do state
+type OIDCStateStore struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+func NewOIDCStateStore(redis *redis.Client, ttl time.Duration) *OIDCStateStore {
+	return &OIDCStateStore{
+		redis: redis,
+		ttl:   ttl,
+	}
+}
+
+// Save armazena a autorização e retorna o state a ser enviado ao provedor
+func (s *OIDCStateStore) Save(ctx context.Context, authorization *oidcAuthorization) (string, error) {
+	state, err := auth.GenerateRandomToken(oidcStateBytes)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(authorization)
+	if err != nil {
+		return "", fmt.Errorf("erro ao serializar state OIDC: %w", err)
+	}
+
+	key := fmt.Sprintf("%s%s", oidcStateKeyPrefix, auth.HashToken(state))
+	if err := s.redis.Set(ctx, key, data, s.ttl).Err(); err != nil {
+		return "", fmt.Errorf("erro ao armazenar state OIDC: %w", err)
+	}
+	return state, nil
+}
+
+// Consume recupera e remove a autorização, garantindo que cada state seja usado uma única vez
+func (s *OIDCStateStore) Consume(ctx context.Context, state string) (*oidcAuthorization, error) {
+	key := fmt.Sprintf("%s%s", oidcStateKeyPrefix, auth.HashToken(state))
+	data, err := s.redis.GetDel(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrOIDCStateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar state OIDC: %w", err)
+	}
+
+	var authorization oidcAuthorization
+	if err := json.Unmarshal(data, &authorization); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar state OIDC: %w", err)
+	}
+	return &authorization, nil
+}
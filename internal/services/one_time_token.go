@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/pkg/auth"
+)
+
+const (
+	oneTimeTokenKeyPrefix = "ott:"
+	oneTimeTokenBytes     = 32
+)
+
+// TokenPurpose identifica o uso de um token de uso único
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+	TokenPurposeMagicLink         TokenPurpose = "magic_link"
+	TokenPurposeEmailChange       TokenPurpose = "email_change"
+	TokenPurposeEmailChangeCancel TokenPurpose = "email_change_cancel"
+)
+
+// ErrOneTimeTokenNotFound indica que o token não existe, expirou ou já foi usado
+var ErrOneTimeTokenNotFound = errors.New("token não encontrado ou expirado")
+
+// OneTimeTokenStore armazena tokens de uso único com expiração no Redis.
+// Apenas o hash do token é persistido e cada subject possui no máximo um token ativo por finalidade.
+type OneTimeTokenStore struct {
+	redis *redis.Client
+}
+
+func NewOneTimeTokenStore(redis *redis.Client) *OneTimeTokenStore {
+	return &OneTimeTokenStore{
+		redis: redis,
+	}
+}
+
+// Issue gera um novo token para o subject, invalidando o anterior, e retorna o valor em claro
+func (s *OneTimeTokenStore) Issue(ctx context.Context, purpose TokenPurpose, subject string, ttl time.Duration) (string, error) {
+	token, err := auth.GenerateRandomToken(oneTimeTokenBytes)
+	if err != nil {
+		return "", err
+	}
+	hash := auth.HashToken(token)
+
+	subjectKey := s.subjectKey(purpose, subject)
+	previous, err := s.redis.Get(ctx, subjectKey).Result()
+	if err != nil && err != redis.Nil {
+		return "", fmt.Errorf("erro ao buscar token anterior: %w", err)
+	}
+
+	pipe := s.redis.TxPipeline()
+	if previous != "" {
+		pipe.Del(ctx, s.tokenKey(purpose, previous))
+	}
+	pipe.Set(ctx, s.tokenKey(purpose, hash), subject, ttl)
+	pipe.Set(ctx, subjectKey, hash, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("erro ao armazenar token: %w", err)
+	}
+
+	return token, nil
+}
+
+// Consume valida e remove o token, retornando o subject associado
+func (s *OneTimeTokenStore) Consume(ctx context.Context, purpose TokenPurpose, token string) (string, error) {
+	subject, err := s.redis.GetDel(ctx, s.tokenKey(purpose, auth.HashToken(token))).Result()
+	if err == redis.Nil {
+		return "", ErrOneTimeTokenNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao consumir token: %w", err)
+	}
+
+	if err := s.redis.Del(ctx, s.subjectKey(purpose, subject)).Err(); err != nil {
+		return "", fmt.Errorf("erro ao remover token: %w", err)
+	}
+
+	return subject, nil
+}
+
+// Peek retorna o subject do token sem consumi-lo, para conferir a quem ele pertence antes do Consume
+func (s *OneTimeTokenStore) Peek(ctx context.Context, purpose TokenPurpose, token string) (string, error) {
+	subject, err := s.redis.Get(ctx, s.tokenKey(purpose, auth.HashToken(token))).Result()
+	if err == redis.Nil {
+		return "", ErrOneTimeTokenNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("erro ao validar token: %w", err)
+	}
+	return subject, nil
+}
+
+// Revoke remove o token ativo do subject, se existir
+func (s *OneTimeTokenStore) Revoke(ctx context.Context, purpose TokenPurpose, subject string) error {
+	subjectKey := s.subjectKey(purpose, subject)
+	hash, err := s.redis.GetDel(ctx, subjectKey).Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao revogar token: %w", err)
+	}
+	return s.redis.Del(ctx, s.tokenKey(purpose, hash)).Err()
+}
+
+func (s *OneTimeTokenStore) tokenKey(purpose TokenPurpose, hash string) string {
+	return fmt.Sprintf("%s%s:%s", oneTimeTokenKeyPrefix, purpose, hash)
+}
+
+func (s *OneTimeTokenStore) subjectKey(purpose TokenPurpose, subject string) string {
+	return fmt.Sprintf("%s%s:subject:%s", oneTimeTokenKeyPrefix, purpose, subject)
+}
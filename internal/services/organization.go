@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/validation"
+)
+
+const maxOrganizationNameLength = 100
+
+type OrganizationService struct {
+	orgRepo repository.OrganizationRepository
+}
+
+func NewOrganizationService(orgRepo repository.OrganizationRepository) service.OrganizationService {
+	return &OrganizationService{
+		orgRepo: orgRepo,
+	}
+}
+
+func (s *OrganizationService) CreateOrganization(ctx context.Context, userID, name string) (*entity.Organization, error) {
+	name = validation.SanitizeString(name)
+	if name == "" {
+		return nil, apperrors.NewValidationError("nome da organização é obrigatório")
+	}
+	if len(name) > maxOrganizationNameLength {
+		return nil, apperrors.NewValidationError("nome da organização muito longo")
+	}
+
+	ownerID, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("ID de usuário inválido: %w", err)
+	}
+
+	organization := &entity.Organization{Name: name}
+	if err := s.orgRepo.CreateWithOwner(ctx, organization, uint(ownerID)); err != nil {
+		return nil, fmt.Errorf("erro ao criar organização: %w", err)
+	}
+	return organization, nil
+}
+
+func (s *OrganizationService) ListOrganizations(ctx context.Context, userID string) ([]entity.Membership, error) {
+	memberships, err := s.orgRepo.FindMembershipsByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar organizações: %w", err)
+	}
+	return memberships, nil
+}
+
+func (s *OrganizationService) ListMembers(ctx context.Context, tenantID, actorID string) ([]entity.Membership, error) {
+	if _, err := s.actor(ctx, tenantID, actorID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.orgRepo.FindMembers(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar membros: %w", err)
+	}
+	return members, nil
+}
+
+func (s *OrganizationService) UpdateMemberRole(ctx context.Context, tenantID, actorID, userID, role string) error {
+	if !entity.IsValidOrganizationRole(role) {
+		return apperrors.NewValidationError("papel inválido")
+	}
+
+	actor, err := s.actor(ctx, tenantID, actorID)
+	if err != nil {
+		return err
+	}
+	if !actor.CanManageMembers() {
+		return apperrors.NewForbiddenError("apenas donos e administradores gerenciam membros")
+	}
+
+	member, err := s.member(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	// Apenas donos concedem ou retiram o papel de dono
+	if (role == entity.OrganizationRoleOwner || member.Role == entity.OrganizationRoleOwner) && actor.Role != entity.OrganizationRoleOwner {
+		return apperrors.NewForbiddenError("apenas donos alteram o papel de dono")
+	}
+	if member.Role == entity.OrganizationRoleOwner && role != entity.OrganizationRoleOwner {
+		if err := s.ensureAnotherOwner(ctx, tenantID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.orgRepo.UpdateMemberRole(ctx, tenantID, userID, role); err != nil {
+		return fmt.Errorf("erro ao alterar papel do membro: %w", err)
+	}
+	return nil
+}
+
+func (s *OrganizationService) RemoveMember(ctx context.Context, tenantID, actorID, userID string) error {
+	actor, err := s.actor(ctx, tenantID, actorID)
+	if err != nil {
+		return err
+	}
+	if actorID != userID && !actor.CanManageMembers() {
+		return apperrors.NewForbiddenError("apenas donos e administradores gerenciam membros")
+	}
+
+	member, err := s.member(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role == entity.OrganizationRoleOwner {
+		if actor.Role != entity.OrganizationRoleOwner {
+			return apperrors.NewForbiddenError("apenas donos removem donos")
+		}
+		if err := s.ensureAnotherOwner(ctx, tenantID); err != nil {
+			return err
+		}
+	}
+
+	removed, err := s.orgRepo.RemoveMember(ctx, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao remover membro: %w", err)
+	}
+	if !removed {
+		return apperrors.NewNotFoundError("membro não encontrado")
+	}
+	return nil
+}
+
+// actor retorna a participação de quem faz a requisição na organização do token
+func (s *OrganizationService) actor(ctx context.Context, tenantID, actorID string) (*entity.Membership, error) {
+	return findActor(ctx, s.orgRepo, tenantID, actorID)
+}
+
+func (s *OrganizationService) member(ctx context.Context, tenantID, userID string) (*entity.Membership, error) {
+	membership, err := s.orgRepo.FindMembership(ctx, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar membro: %w", err)
+	}
+	if membership == nil {
+		return nil, apperrors.NewNotFoundError("membro não encontrado")
+	}
+	return membership, nil
+}
+
+// ensureAnotherOwner impede que a organização fique sem dono
+func (s *OrganizationService) ensureAnotherOwner(ctx context.Context, tenantID string) error {
+	owners, err := s.orgRepo.CountMembersWithRole(ctx, tenantID, entity.OrganizationRoleOwner)
+	if err != nil {
+		return fmt.Errorf("erro ao contar donos: %w", err)
+	}
+	if owners <= 1 {
+		return apperrors.NewConflictError("a organização precisa de ao menos um dono")
+	}
+	return nil
+}
+
+// findActor retorna a participação de actorID na organização tenantID; 403 quando não há organização
+// selecionada ou o usuário não participa dela
+func findActor(ctx context.Context, orgRepo repository.OrganizationRepository, tenantID, actorID string) (*entity.Membership, error) {
+	if tenantID == "" {
+		return nil, apperrors.NewForbiddenError("nenhuma organização selecionada")
+	}
+	membership, err := orgRepo.FindMembership(ctx, tenantID, actorID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao verificar organização: %w", err)
+	}
+	if membership == nil {
+		return nil, apperrors.NewForbiddenError("você não participa desta organização")
+	}
+	return membership, nil
+}
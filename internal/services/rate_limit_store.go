@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const rateLimitKeyPrefix = "ratelimit:"
+
+// RateLimitStore limita ações por chave arbitrária (ex: endereço de email) com janelas fixas no Redis,
+// compartilhadas entre todas as instâncias da API
+type RateLimitStore struct {
+	redis *redis.Client
+}
+
+func NewRateLimitStore(redis *redis.Client) *RateLimitStore {
+	return &RateLimitStore{
+		redis: redis,
+	}
+}
+
+// Allow registra uma tentativa e informa se ela ainda está dentro do limite da janela
+func (s *RateLimitStore) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	redisKey := fmt.Sprintf("%s%s", rateLimitKeyPrefix, key)
+
+	pipe := s.redis.TxPipeline()
+	count := pipe.Incr(ctx, redisKey)
+	pipe.ExpireNX(ctx, redisKey, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("erro ao registrar tentativa: %w", err)
+	}
+
+	return count.Val() <= int64(limit), nil
+}
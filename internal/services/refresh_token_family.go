@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/pkg/auth"
+)
+
+const (
+	refreshFamilyKeyPrefix = "refresh:family:"
+	refreshFamilyRevoked   = "revoked"
+	tokenIDBytes           = 16
+)
+
+var (
+	// ErrRefreshTokenReuse indica que um refresh token já rotacionado foi apresentado novamente
+	ErrRefreshTokenReuse = errors.New("refresh token reutilizado")
+	// ErrRefreshFamilyRevoked indica uma família encerrada (logout ou reuso) ou expirada
+	ErrRefreshFamilyRevoked = errors.New("família de refresh tokens encerrada")
+)
+
+// rotateRefreshFamilyScript troca o membro atual da família (ARGV[1]) pelo próximo (ARGV[2]).
+// Retorna 1 na rotação, -1 quando um membro anterior é apresentado (a família é encerrada)
+// e 0 para famílias encerradas ou expiradas.
+var rotateRefreshFamilyScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current or current == ARGV[3] then
+	return 0
+end
+if current ~= ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[3], 'PX', ARGV[4])
+	return -1
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[4])
+return 1
+`)
+
+// RefreshTokenFamilyStore rastreia as famílias de refresh tokens: todos os tokens obtidos por rotação a partir
+// de um login pertencem à mesma família (family_id) e apenas o último emitido (jti) é válido. Apresentar um
+// token anterior significa que ele foi copiado, e a família inteira é encerrada.
+type RefreshTokenFamilyStore struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+func NewRefreshTokenFamilyStore(redis *redis.Client, ttl time.Duration) *RefreshTokenFamilyStore {
+	return &RefreshTokenFamilyStore{
+		redis: redis,
+		ttl:   ttl,
+	}
+}
+
+// Start cria uma família e retorna seu identificador e o jti do primeiro refresh token
+func (s *RefreshTokenFamilyStore) Start(ctx context.Context) (familyID, jti string, err error) {
+	if familyID, err = auth.GenerateRandomToken(tokenIDBytes); err != nil {
+		return "", "", err
+	}
+	if jti, err = auth.GenerateRandomToken(tokenIDBytes); err != nil {
+		return "", "", err
+	}
+
+	if err := s.redis.Set(ctx, refreshFamilyKeyPrefix+familyID, jti, s.ttl).Err(); err != nil {
+		return "", "", fmt.Errorf("erro ao registrar família de refresh tokens: %w", err)
+	}
+	return familyID, jti, nil
+}
+
+// Rotate substitui o membro jti pelo próximo da família e retorna o jti do novo refresh token
+func (s *RefreshTokenFamilyStore) Rotate(ctx context.Context, familyID, jti string) (string, error) {
+	next, err := auth.GenerateRandomToken(tokenIDBytes)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := rotateRefreshFamilyScript.Run(ctx, s.redis, []string{refreshFamilyKeyPrefix + familyID},
+		jti, next, refreshFamilyRevoked, s.ttl.Milliseconds()).Int()
+	if err != nil {
+		return "", fmt.Errorf("erro ao rotacionar refresh token: %w", err)
+	}
+
+	switch result {
+	case 1:
+		return next, nil
+	case -1:
+		return "", ErrRefreshTokenReuse
+	default:
+		return "", ErrRefreshFamilyRevoked
+	}
+}
+
+// Revoke encerra a família; seus refresh tokens deixam de ser aceitos
+func (s *RefreshTokenFamilyStore) Revoke(ctx context.Context, familyID string) error {
+	if err := s.redis.Set(ctx, refreshFamilyKeyPrefix+familyID, refreshFamilyRevoked, s.ttl).Err(); err != nil {
+		return fmt.Errorf("erro ao encerrar família de refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// rotateRefreshToken invalida o refresh token apresentado e retorna a família e o jti do seu substituto. O reuso
+// de um token já rotacionado gera um evento de segurança. Tokens emitidos antes das famílias são invalidados pela
+// blacklist e dão origem a uma família nova.
+func rotateRefreshToken(
+	ctx context.Context,
+	families *RefreshTokenFamilyStore,
+	blacklist *TokenBlacklist,
+	events *SecurityEvents,
+	refreshToken string,
+	claims *auth.Claims,
+) (familyID, jti string, err error) {
+	if claims.FamilyID == "" {
+		blacklisted, err := blacklist.IsBlacklisted(ctx, refreshToken)
+		if err != nil {
+			return "", "", fmt.Errorf("erro ao verificar token: %w", err)
+		}
+		if blacklisted {
+			return "", "", ErrRefreshFamilyRevoked
+		}
+		if err := blacklist.Add(ctx, refreshToken, families.ttl); err != nil {
+			return "", "", fmt.Errorf("erro ao invalidar token: %w", err)
+		}
+		return families.Start(ctx)
+	}
+
+	jti, err = families.Rotate(ctx, claims.FamilyID, claims.Id)
+	if err == ErrRefreshTokenReuse {
+		events.Emit(ctx, SecurityEvent{
+			Type:     SecurityEventRefreshTokenReuse,
+			UserID:   claims.UserID,
+			ClientID: claims.ClientID,
+			Details:  map[string]string{"family_id": claims.FamilyID, "jti": claims.Id},
+		})
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return claims.FamilyID, jti, nil
+}
+
+// revokeRefreshToken encerra a família do refresh token (ou, para tokens sem família, o próprio token)
+func revokeRefreshToken(ctx context.Context, families *RefreshTokenFamilyStore, blacklist *TokenBlacklist, refreshToken string, claims *auth.Claims) error {
+	if claims.FamilyID != "" {
+		return families.Revoke(ctx, claims.FamilyID)
+	}
+	if err := blacklist.Add(ctx, refreshToken, families.ttl); err != nil {
+		return fmt.Errorf("erro ao invalidar token: %w", err)
+	}
+	return nil
+}
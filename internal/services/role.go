@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+)
+
+type RoleService struct {
+	roleRepo repository.RoleRepository
+	userRepo repository.UserRepository
+}
+
+func NewRoleService(roleRepo repository.RoleRepository, userRepo repository.UserRepository) service.RoleService {
+	return &RoleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+	}
+}
+
+func (s *RoleService) ListRoles(ctx context.Context) ([]entity.Role, error) {
+	roles, err := s.roleRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar papéis: %w", err)
+	}
+	return roles, nil
+}
+
+func (s *RoleService) GetUserRoles(ctx context.Context, userID string) ([]entity.Role, error) {
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, apperrors.NewNotFoundError("usuário não encontrado")
+	}
+
+	roles, err := s.roleRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar papéis do usuário: %w", err)
+	}
+	return roles, nil
+}
+
+func (s *RoleService) AssignRole(ctx context.Context, userID, roleName string) error {
+	role, err := s.findRole(ctx, userID, roleName)
+	if err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.Assign(ctx, userID, role.ID); err != nil {
+		return fmt.Errorf("erro ao atribuir papel: %w", err)
+	}
+	return nil
+}
+
+func (s *RoleService) RemoveRole(ctx context.Context, userID, roleName string) error {
+	role, err := s.findRole(ctx, userID, roleName)
+	if err != nil {
+		return err
+	}
+
+	if role.Name == entity.RoleAdmin {
+		count, err := s.roleRepo.CountUsers(ctx, role.ID)
+		if err != nil {
+			return fmt.Errorf("erro ao contar administradores: %w", err)
+		}
+		if count <= 1 {
+			return apperrors.NewConflictError("não é possível remover o último administrador")
+		}
+	}
+
+	removed, err := s.roleRepo.Remove(ctx, userID, role.ID)
+	if err != nil {
+		return fmt.Errorf("erro ao remover papel: %w", err)
+	}
+	if !removed {
+		return apperrors.NewNotFoundError("o usuário não tem este papel")
+	}
+	return nil
+}
+
+func (s *RoleService) findRole(ctx context.Context, userID, roleName string) (*entity.Role, error) {
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, apperrors.NewNotFoundError("usuário não encontrado")
+	}
+
+	role, err := s.roleRepo.FindByName(ctx, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar papel: %w", err)
+	}
+	if role == nil {
+		return nil, apperrors.NewNotFoundError("papel não encontrado")
+	}
+	return role, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/pkg/logger"
+)
+
+const (
+	securityEventsStream = "security:events"
+	// securityEventsMaxLen limita o stream aos eventos mais recentes (corte aproximado)
+	securityEventsMaxLen = 10000
+)
+
+// Tipos de eventos de segurança
+const (
+	SecurityEventRefreshTokenReuse   = "refresh_token_reuse"
+	SecurityEventAccountPurged       = "account_purged"
+	SecurityEventDataExportRequested = "data_export_requested"
+	SecurityEventEmailChanged        = "email_changed"
+	SecurityEventPasskeyAdded        = "passkey_added"
+	SecurityEventPasskeyRemoved      = "passkey_removed"
+)
+
+// SecurityEvent é uma ocorrência relevante para auditoria e resposta a incidentes
+type SecurityEvent struct {
+	Type       string            `json:"type"`
+	UserID     string            `json:"user_id,omitempty"`
+	ClientID   string            `json:"client_id,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
+
+// SecurityEvents publica eventos de segurança no log e no stream security:events do Redis,
+// de onde podem ser consumidos por ferramentas de auditoria e alertas
+type SecurityEvents struct {
+	redis *redis.Client
+	log   *logger.Logger
+}
+
+func NewSecurityEvents(redis *redis.Client, log *logger.Logger) *SecurityEvents {
+	return &SecurityEvents{
+		redis: redis,
+		log:   log,
+	}
+}
+
+// Emit registra o evento. Falhas na publicação são apenas registradas: o evento não deve
+// interromper a requisição que o originou.
+func (e *SecurityEvents) Emit(ctx context.Context, event SecurityEvent) {
+	event.OccurredAt = time.Now()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		e.log.Error("Erro ao serializar evento de segurança: %v", err)
+		return
+	}
+	e.log.Warn("Evento de segurança: %s", data)
+
+	err = e.redis.XAdd(ctx, &redis.XAddArgs{
+		Stream: securityEventsStream,
+		MaxLen: securityEventsMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":  event.Type,
+			"event": string(data),
+		},
+	}).Err()
+	if err != nil {
+		e.log.Error("Erro ao publicar evento de segurança: %v", err)
+	}
+}
+
+// DeleteByUser remove do stream os eventos do usuário, para que não sobrevivam à remoção da conta
+func (e *SecurityEvents) DeleteByUser(ctx context.Context, userID string) error {
+	messages, err := e.redis.XRange(ctx, securityEventsStream, "-", "+").Result()
+	if err != nil {
+		return fmt.Errorf("erro ao buscar eventos de segurança: %w", err)
+	}
+
+	var ids []string
+	for _, message := range messages {
+		data, _ := message.Values["event"].(string)
+		var event SecurityEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+		if event.UserID == userID {
+			ids = append(ids, message.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := e.redis.XDel(ctx, securityEventsStream, ids...).Err(); err != nil {
+		return fmt.Errorf("erro ao remover eventos de segurança: %w", err)
+	}
+	return nil
+}
+
+// FindByUser retorna os eventos do usuário ainda mantidos no stream, do mais antigo para o mais recente
+func (e *SecurityEvents) FindByUser(ctx context.Context, userID string) ([]SecurityEvent, error) {
+	messages, err := e.redis.XRange(ctx, securityEventsStream, "-", "+").Result()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar eventos de segurança: %w", err)
+	}
+
+	var events []SecurityEvent
+	for _, message := range messages {
+		data, _ := message.Values["event"].(string)
+		var event SecurityEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
+		if event.UserID == userID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
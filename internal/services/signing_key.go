@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/internal/config"
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
+	"auth-template/pkg/logger"
+)
+
+const signingKeyRotationLockPrefix = "signing_keys:rotation:"
+
+var signingKeyUses = []entity.SigningKeyUse{entity.SigningKeyAccess, entity.SigningKeyRefresh}
+
+type SigningKeyService struct {
+	repo         repository.SigningKeyRepository
+	tokenManager *auth.TokenManager
+	encryptor    *auth.Encryptor
+	redis        *redis.Client
+	config       *config.Config
+	log          *logger.Logger
+	// envKids guarda o kid das chaves configuradas por variáveis de ambiente até a primeira sincronização
+	envKids map[entity.SigningKeyUse]string
+}
+
+func NewSigningKeyService(
+	repo repository.SigningKeyRepository,
+	tokenManager *auth.TokenManager,
+	encryptor *auth.Encryptor,
+	redis *redis.Client,
+	config *config.Config,
+	log *logger.Logger,
+) service.SigningKeyService {
+	return &SigningKeyService{
+		repo:         repo,
+		tokenManager: tokenManager,
+		encryptor:    encryptor,
+		redis:        redis,
+		config:       config,
+		log:          log,
+		envKids: map[entity.SigningKeyUse]string{
+			entity.SigningKeyAccess:  tokenManager.Keys(auth.TokenTypeAccess).Active().Kid(),
+			entity.SigningKeyRefresh: tokenManager.Keys(auth.TokenTypeRefresh).Active().Kid(),
+		},
+	}
+}
+
+func (s *SigningKeyService) Sync(ctx context.Context) error {
+	keys, err := s.repo.FindUsable(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar chaves de assinatura: %w", err)
+	}
+
+	byUse := make(map[entity.SigningKeyUse][]entity.SigningKey)
+	for _, key := range keys {
+		byUse[key.Use] = append(byUse[key.Use], key)
+	}
+
+	for _, use := range signingKeyUses {
+		if err := s.syncKeyring(ctx, use, byUse[use]); err != nil {
+			return err
+		}
+	}
+
+	all, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar chaves de assinatura: %w", err)
+	}
+	s.warnIgnoredEnvKeys(all)
+	s.limitLegacy(all)
+	return nil
+}
+
+// warnIgnoredEnvKeys avisa, na primeira sincronização, que as chaves de JWT_ACCESS_SECRET, JWT_ALGORITHM,
+// JWT_PRIVATE_KEY_FILE ou JWT_REFRESH_SECRET foram alteradas depois de inaugurar o keyring e não são usadas
+func (s *SigningKeyService) warnIgnoredEnvKeys(keys []entity.SigningKey) {
+	if s.envKids == nil {
+		return
+	}
+	for use, kid := range s.envKids {
+		found := false
+		for _, key := range keys {
+			if key.Use == use && key.ID == kid {
+				found = true
+			}
+		}
+		if !found {
+			s.log.Warn("A chave de %s tokens das variáveis de ambiente (%s) não está no banco e será ignorada; use make signing-keys para rotacionar", use, kid)
+		}
+	}
+	s.envKids = nil
+}
+
+// limitLegacy encerra a aceitação dos tokens sem kid um TTL depois da primeira chave registrada, quando o último
+// token emitido antes do keyring expirou, mesmo que a instância tenha sido iniciada depois disso
+func (s *SigningKeyService) limitLegacy(keys []entity.SigningKey) {
+	first := make(map[entity.SigningKeyUse]time.Time)
+	for _, key := range keys {
+		if at, ok := first[key.Use]; !ok || key.ActivatesAt.Before(at) {
+			first[key.Use] = key.ActivatesAt
+		}
+	}
+	for use, at := range first {
+		tokenType := signingKeyTokenType(use)
+		s.tokenManager.Keys(tokenType).LimitLegacy(at.Add(s.tokenManager.TTL(tokenType)))
+	}
+}
+
+// syncKeyring recarrega o keyring de um tipo de token. As chaves chegam ordenadas pela ativação.
+func (s *SigningKeyService) syncKeyring(ctx context.Context, use entity.SigningKeyUse, keys []entity.SigningKey) error {
+	keyring := s.tokenManager.Keys(signingKeyTokenType(use))
+	now := time.Now()
+
+	if len(keys) == 0 {
+		// Primeira execução: a chave configurada por variáveis de ambiente inaugura o keyring. Outra instância
+		// pode ter cadastrado a sua ao mesmo tempo; o keyring é montado com o que ficou no banco.
+		if _, err := s.store(ctx, use, keyring.Active(), now); err != nil {
+			return err
+		}
+		usable, err := s.repo.FindUsable(ctx)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar chaves de assinatura: %w", err)
+		}
+		for _, key := range usable {
+			if key.Use == use {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	active := activeSigningKey(keys, now)
+	if active < 0 {
+		return fmt.Errorf("nenhuma chave de %s tokens está ativa", use)
+	}
+
+	var (
+		activeSigner *auth.Signer
+		verifyOnly   []*auth.Signer
+	)
+	ttl := s.tokenManager.TTL(signingKeyTokenType(use))
+	for i, key := range keys {
+		// Uma chave substituída deixa de ser necessária quando expira o último token que ela assinou
+		if i < active && now.After(keys[i+1].ActivatesAt.Add(ttl)) {
+			if err := s.repo.Retire(ctx, key.ID, now); err != nil {
+				return fmt.Errorf("erro ao aposentar chave de assinatura: %w", err)
+			}
+			s.log.Info("Chave de assinatura %s (%s) aposentada", key.ID, use)
+			continue
+		}
+
+		signer, err := s.decode(&key)
+		if err != nil {
+			return err
+		}
+		if i == active {
+			activeSigner = signer
+		} else {
+			verifyOnly = append(verifyOnly, signer)
+		}
+	}
+
+	if activeSigner.Kid() != keyring.Active().Kid() {
+		s.log.Info("Chave de assinatura %s (%s) ativada", activeSigner.Kid(), use)
+	}
+	keyring.Replace(activeSigner, verifyOnly...)
+	return nil
+}
+
+func (s *SigningKeyService) Rotate(ctx context.Context, use entity.SigningKeyUse, algorithm string, activatesAt time.Time) (*entity.SigningKey, error) {
+	if use != entity.SigningKeyAccess && use != entity.SigningKeyRefresh {
+		return nil, apperrors.NewValidationError("uso da chave deve ser access ou refresh")
+	}
+	switch algorithm {
+	case jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg(), jwt.SigningMethodEdDSA.Alg():
+	default:
+		return nil, apperrors.NewValidationError("algoritmo deve ser HS256, RS256, ES256 ou EdDSA")
+	}
+
+	signer, err := auth.GenerateSigner(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return s.store(ctx, use, signer, activatesAt)
+}
+
+func (s *SigningKeyService) Retire(ctx context.Context, kid string) error {
+	key, err := s.repo.FindByID(ctx, kid)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar chave de assinatura: %w", err)
+	}
+	if key == nil {
+		return apperrors.NewNotFoundError("chave não encontrada")
+	}
+	if key.RetiredAt != nil {
+		return nil
+	}
+
+	keys, err := s.repo.FindUsable(ctx)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar chaves de assinatura: %w", err)
+	}
+	var sameUse []entity.SigningKey
+	for _, k := range keys {
+		if k.Use == key.Use {
+			sameUse = append(sameUse, k)
+		}
+	}
+	if active := activeSigningKey(sameUse, time.Now()); active >= 0 && sameUse[active].ID == kid {
+		return apperrors.NewConflictError("a chave está ativa; faça a rotação antes de aposentá-la")
+	}
+
+	if err := s.repo.Retire(ctx, kid, time.Now()); err != nil {
+		return fmt.Errorf("erro ao aposentar chave de assinatura: %w", err)
+	}
+	return nil
+}
+
+func (s *SigningKeyService) List(ctx context.Context) ([]entity.SigningKey, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *SigningKeyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Auth.KeySyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.rotateIfDue(ctx); err != nil {
+			s.log.Error("Erro na rotação das chaves de assinatura: %v", err)
+		}
+		if err := s.Sync(ctx); err != nil {
+			s.log.Error("Erro ao sincronizar as chaves de assinatura: %v", err)
+		}
+	}
+}
+
+// rotateIfDue cadastra uma chave nova quando a mais recente completa o intervalo de rotação. A chave é publicada
+// JWT_KEY_PUBLISH_DELAY antes de assinar, para que os verificadores atualizem o JWKS em cache a tempo.
+func (s *SigningKeyService) rotateIfDue(ctx context.Context) error {
+	interval := s.config.Auth.KeyRotationInterval
+	if interval <= 0 {
+		return nil
+	}
+
+	for _, use := range signingKeyUses {
+		// Apenas uma instância avalia a rotação de cada keyring por vez
+		locked, err := s.redis.SetNX(ctx, signingKeyRotationLockPrefix+string(use), true, s.config.Auth.KeySyncInterval).Result()
+		if err != nil {
+			return fmt.Errorf("erro ao obter lock de rotação: %w", err)
+		}
+		if !locked {
+			continue
+		}
+
+		keys, err := s.repo.FindUsable(ctx)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar chaves de assinatura: %w", err)
+		}
+		var newest *entity.SigningKey
+		for i := range keys {
+			if keys[i].Use == use {
+				newest = &keys[i]
+			}
+		}
+		if newest == nil {
+			continue
+		}
+
+		activatesAt := newest.ActivatesAt.Add(interval)
+		if activatesAt.After(time.Now().Add(s.config.Auth.KeyPublishDelay)) {
+			continue
+		}
+		if now := time.Now(); activatesAt.Before(now) {
+			activatesAt = now.Add(s.config.Auth.KeyPublishDelay)
+		}
+
+		algorithm := jwt.SigningMethodHS256.Alg()
+		if use == entity.SigningKeyAccess {
+			algorithm = s.config.Auth.JWTAlgorithm
+		}
+		key, err := s.Rotate(ctx, use, algorithm, activatesAt)
+		if err != nil {
+			return err
+		}
+		s.log.Info("Chave de assinatura %s (%s) criada; ativação em %s", key.ID, use, key.ActivatesAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (s *SigningKeyService) store(ctx context.Context, use entity.SigningKeyUse, signer *auth.Signer, activatesAt time.Time) (*entity.SigningKey, error) {
+	encoded, err := signer.EncodePrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.encryptor.Encrypt(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao cifrar chave de assinatura: %w", err)
+	}
+
+	key := &entity.SigningKey{
+		ID:          signer.Kid(),
+		Use:         use,
+		Algorithm:   signer.Algorithm(),
+		PrivateKey:  encrypted,
+		ActivatesAt: activatesAt,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, fmt.Errorf("erro ao salvar chave de assinatura: %w", err)
+	}
+	return key, nil
+}
+
+func (s *SigningKeyService) decode(key *entity.SigningKey) (*auth.Signer, error) {
+	encoded, err := s.encryptor.Decrypt(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decifrar chave de assinatura %s: %w", key.ID, err)
+	}
+	signer, err := auth.DecodeSigner(key.Algorithm, encoded)
+	if err != nil {
+		return nil, fmt.Errorf("chave de assinatura %s inválida: %w", key.ID, err)
+	}
+	if signer.Kid() != key.ID {
+		return nil, fmt.Errorf("kid da chave de assinatura %s não confere", key.ID)
+	}
+	return signer, nil
+}
+
+// activeSigningKey retorna o índice da chave mais recente já ativada, ou -1
+func activeSigningKey(keys []entity.SigningKey, now time.Time) int {
+	active := -1
+	for i, key := range keys {
+		if !key.ActivatesAt.After(now) {
+			active = i
+		}
+	}
+	return active
+}
+
+func signingKeyTokenType(use entity.SigningKeyUse) auth.TokenType {
+	if use == entity.SigningKeyRefresh {
+		return auth.TokenTypeRefresh
+	}
+	return auth.TokenTypeAccess
+}
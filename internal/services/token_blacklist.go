@@ -3,13 +3,21 @@ package services
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"auth-template/pkg/auth"
 )
 
 const (
-	blacklistKeyPrefix = "blacklist:token:"
+	blacklistKeyPrefix        = "blacklist:token:"
+	blacklistUserKeyPrefix    = "blacklist:user:"
+	blacklistTokenIDKeyPrefix = "blacklist:jti:"
+	blacklistSessionKeyPrefix = "blacklist:session:"
+	// watermarkMillisThreshold separa as marcas de revogação em segundos (anteriores) das gravadas em milissegundos
+	watermarkMillisThreshold = 1_000_000_000_000
 )
 
 type TokenBlacklist struct {
@@ -43,3 +51,85 @@ func (b *TokenBlacklist) Remove(ctx context.Context, token string) error {
 	key := fmt.Sprintf("%s%s", blacklistKeyPrefix, token)
 	return b.redis.Del(ctx, key).Err()
 }
+
+// RevokeUserTokens invalida todos os tokens do usuário emitidos antes do momento atual. A marca é gravada em
+// milissegundos. O TTL deve ser o do token de maior duração para que a marca expire junto com eles.
+func (b *TokenBlacklist) RevokeUserTokens(ctx context.Context, userID string, ttl time.Duration) error {
+	key := fmt.Sprintf("%s%s", blacklistUserKeyPrefix, userID)
+	return b.redis.Set(ctx, key, time.Now().UnixMilli(), ttl).Err()
+}
+
+// IsUserTokenRevoked verifica se o token foi invalidado por RevokeUserTokens
+func (b *TokenBlacklist) IsUserTokenRevoked(ctx context.Context, claims *auth.Claims) (bool, error) {
+	key := fmt.Sprintf("%s%s", blacklistUserKeyPrefix, claims.UserID)
+	value, err := b.redis.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar revogação do usuário: %w", err)
+	}
+	return issuedBeforeWatermark(claims, value)
+}
+
+// RevokeTokenID invalida o token com o jti informado até a sua expiração
+func (b *TokenBlacklist) RevokeTokenID(ctx context.Context, jti string, expiresAt int64) error {
+	ttl := time.Until(time.Unix(expiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+	return b.redis.Set(ctx, blacklistTokenIDKeyPrefix+jti, "revoked", ttl).Err()
+}
+
+// RevokeSession invalida os tokens com a claim sid informada. O TTL deve ser o do token de maior duração
+// que carrega a sessão.
+func (b *TokenBlacklist) RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	return b.redis.Set(ctx, blacklistSessionKeyPrefix+sessionID, "revoked", ttl).Err()
+}
+
+// IsTokenRevoked verifica, em uma única consulta, se o token foi invalidado pelo jti, pela sessão ou
+// pela marca de revogação do usuário
+func (b *TokenBlacklist) IsTokenRevoked(ctx context.Context, claims *auth.Claims) (bool, error) {
+	keys := []string{blacklistUserKeyPrefix + claims.UserID}
+	if claims.Id != "" {
+		keys = append(keys, blacklistTokenIDKeyPrefix+claims.Id)
+	}
+	if claims.SessionID != "" {
+		keys = append(keys, blacklistSessionKeyPrefix+claims.SessionID)
+	}
+
+	values, err := b.redis.MGet(ctx, keys...).Result()
+	if err != nil {
+		return false, fmt.Errorf("erro ao verificar token na blacklist: %w", err)
+	}
+
+	for _, value := range values[1:] {
+		if value != nil {
+			return true, nil
+		}
+	}
+
+	watermark, ok := values[0].(string)
+	if !ok {
+		return false, nil
+	}
+	return issuedBeforeWatermark(claims, watermark)
+}
+
+// issuedBeforeWatermark compara a emissão do token com a marca de revogação do usuário, em milissegundos. Marcas
+// gravadas em segundos por versões anteriores são convertidas. Tokens sem iat_ms emitidos no mesmo segundo da
+// revogação são considerados anteriores a ela.
+func issuedBeforeWatermark(claims *auth.Claims, watermark string) (bool, error) {
+	revokedAt, err := strconv.ParseInt(watermark, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("marca de revogação inválida: %w", err)
+	}
+	if revokedAt < watermarkMillisThreshold {
+		revokedAt *= 1000
+	}
+
+	if claims.IssuedAtMs != 0 {
+		return claims.IssuedAtMs < revokedAt, nil
+	}
+	return claims.IssuedAt*1000 <= revokedAt, nil
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
+	"auth-template/pkg/validation"
+)
+
+const (
+	defaultUsersPerPage = 20
+	maxUsersPerPage     = 100
+	// adminMetadataMaxAttempts limita as novas tentativas da gravação dos metadados após alterações concorrentes
+	adminMetadataMaxAttempts = 5
+)
+
+type UserAdminService struct {
+	userRepo      repository.UserRepository
+	roleRepo      repository.RoleRepository
+	sessionRepo   repository.SessionRepository
+	apiKeyRepo    repository.APIKeyRepository
+	authService   service.AuthService
+	loginThrottle *LoginThrottle
+}
+
+func NewUserAdminService(
+	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
+	sessionRepo repository.SessionRepository,
+	apiKeyRepo repository.APIKeyRepository,
+	authService service.AuthService,
+	loginThrottle *LoginThrottle,
+) service.UserAdminService {
+	return &UserAdminService{
+		userRepo:      userRepo,
+		roleRepo:      roleRepo,
+		sessionRepo:   sessionRepo,
+		apiKeyRepo:    apiKeyRepo,
+		authService:   authService,
+		loginThrottle: loginThrottle,
+	}
+}
+
+func (s *UserAdminService) ListUsers(ctx context.Context, query service.UserQuery) ([]entity.User, int64, error) {
+	switch query.Status {
+	case "", entity.UserStatusActive, entity.UserStatusDisabled, entity.UserStatusDeleted:
+	default:
+		return nil, 0, apperrors.NewValidationError("situação inválida")
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PerPage < 1 {
+		query.PerPage = defaultUsersPerPage
+	}
+	if query.PerPage > maxUsersPerPage {
+		query.PerPage = maxUsersPerPage
+	}
+
+	users, total, err := s.userRepo.List(ctx, repository.UserFilter{
+		Email:        query.Email,
+		Status:       query.Status,
+		CreatedFrom:  query.CreatedFrom,
+		CreatedUntil: query.CreatedUntil,
+		Offset:       (query.Page - 1) * query.PerPage,
+		Limit:        query.PerPage,
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("erro ao listar usuários: %w", err)
+	}
+	return users, total, nil
+}
+
+func (s *UserAdminService) GetUser(ctx context.Context, userID string) (*service.UserDetails, error) {
+	user, err := s.userRepo.FindByIDWithDeleted(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar usuário: %w", err)
+	}
+	if user == nil {
+		return nil, apperrors.NewNotFoundError("usuário não encontrado")
+	}
+
+	roles, err := s.roleRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar papéis: %w", err)
+	}
+	sessions, err := s.sessionRepo.FindActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar sessões: %w", err)
+	}
+
+	details := &service.UserDetails{
+		User:     user,
+		Roles:    make([]string, 0, len(roles)),
+		Sessions: sessions,
+	}
+	for _, role := range roles {
+		details.Roles = append(details.Roles, role.Name)
+	}
+	return details, nil
+}
+
+func (s *UserAdminService) DisableUser(ctx context.Context, actorID, userID string) error {
+	if actorID == userID {
+		return apperrors.NewConflictError("não é possível desativar a própria conta")
+	}
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsDisabled() {
+		now := time.Now()
+		user.DisabledAt = &now
+		if err := s.userRepo.SetDisabledAt(ctx, user.ID, &now); err != nil {
+			return fmt.Errorf("erro ao desativar usuário: %w", err)
+		}
+	}
+	// As chaves de API são mantidas para a reativação, mas deixam de autenticar enquanto a conta estiver desativada
+	return s.authService.RevokeUserTokens(ctx, userID)
+}
+
+func (s *UserAdminService) EnableUser(ctx context.Context, userID string) error {
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsDisabled() {
+		return nil
+	}
+
+	user.DisabledAt = nil
+	if err := s.userRepo.SetDisabledAt(ctx, user.ID, nil); err != nil {
+		return fmt.Errorf("erro ao reativar usuário: %w", err)
+	}
+	return nil
+}
+
+func (s *UserAdminService) ForceLogout(ctx context.Context, userID string) error {
+	if _, err := s.user(ctx, userID); err != nil {
+		return err
+	}
+	return s.revokeAccess(ctx, userID)
+}
+
+func (s *UserAdminService) ForcePasswordReset(ctx context.Context, userID string) error {
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// A senha atual deixa de funcionar: o hash passa a ser de um valor aleatório que ninguém conhece
+	unusable, err := auth.GenerateRandomToken(oneTimeTokenBytes)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(unusable), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("erro ao gerar hash da senha: %w", err)
+	}
+	user.Password = string(hashedPassword)
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, user.Password); err != nil {
+		return fmt.Errorf("erro ao invalidar senha: %w", err)
+	}
+
+	if err := s.revokeAccess(ctx, userID); err != nil {
+		return err
+	}
+	return s.authService.SendPasswordResetEmail(ctx, user)
+}
+
+// UpdateAdminMetadata mescla o patch aos metadados lidos e só grava se eles não mudaram desde a leitura. Em caso
+// de alteração concorrente, a mescla é refeita sobre os valores novos, para que nenhuma das alterações se perca.
+func (s *UserAdminService) UpdateAdminMetadata(ctx context.Context, userID string, patch map[string]interface{}) (entity.Metadata, error) {
+	for attempt := 0; attempt < adminMetadataMaxAttempts; attempt++ {
+		user, err := s.user(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		metadata := user.AdminMetadata.Merge(patch)
+		if err := validation.ValidateMetadata(metadata); err != nil {
+			return nil, err
+		}
+		updated, err := s.userRepo.UpdateAdminMetadata(ctx, userID, user.AdminMetadata, metadata)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao atualizar metadados: %w", err)
+		}
+		if updated {
+			return metadata, nil
+		}
+	}
+	return nil, apperrors.NewConflictError("os metadados foram alterados por outra requisição; tente novamente")
+}
+
+func (s *UserAdminService) UnlockLogin(ctx context.Context, userID string) error {
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.loginThrottle.Unlock(ctx, user.Email); err != nil {
+		return fmt.Errorf("erro ao desbloquear login: %w", err)
+	}
+	return nil
+}
+
+func (s *UserAdminService) DeleteUser(ctx context.Context, actorID, userID string) error {
+	if actorID == userID {
+		return apperrors.NewConflictError("não é possível excluir a própria conta")
+	}
+
+	deleted, err := s.userRepo.Delete(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao excluir usuário: %w", err)
+	}
+	if !deleted {
+		return apperrors.NewNotFoundError("usuário não encontrado")
+	}
+	return s.authService.RevokeUserTokens(ctx, userID)
+}
+
+func (s *UserAdminService) RestoreUser(ctx context.Context, userID string) error {
+	restored, err := s.userRepo.Restore(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("erro ao restaurar usuário: %w", err)
+	}
+	if !restored {
+		return apperrors.NewNotFoundError("usuário excluído não encontrado")
+	}
+	return nil
+}
+
+// user busca um usuário não excluído
+func (s *UserAdminService) user(ctx context.Context, userID string) (*entity.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("usuário não encontrado")
+	}
+	return user, nil
+}
+
+// revokeAccess encerra as sessões e os tokens do usuário e remove as chaves de API, que não dependem das
+// sessões e continuariam autenticando
+func (s *UserAdminService) revokeAccess(ctx context.Context, userID string) error {
+	if err := s.authService.RevokeUserTokens(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.apiKeyRepo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("erro ao revogar chaves de API: %w", err)
+	}
+	return nil
+}
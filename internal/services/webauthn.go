@@ -0,0 +1,455 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+
+	"auth-template/internal/config"
+	"auth-template/internal/entity"
+	apperrors "auth-template/internal/errors"
+	"auth-template/internal/interfaces/repository"
+	"auth-template/internal/interfaces/service"
+	"auth-template/pkg/auth"
+	"auth-template/pkg/logger"
+	"auth-template/pkg/mailer"
+	"auth-template/pkg/validation"
+)
+
+const maxWebAuthnCredentialNameLength = 100
+
+type WebAuthnService struct {
+	webAuthn       *webauthn.WebAuthn
+	userRepo       repository.UserRepository
+	webAuthnRepo   repository.WebAuthnRepository
+	authService    service.AuthService
+	sessions       *WebAuthnSessionStore
+	mfaChallenges  *MFAChallengeStore
+	securityEvents *SecurityEvents
+	mailer         mailer.Mailer
+	config         *config.Config
+	log            *logger.Logger
+}
+
+func NewWebAuthnService(
+	webAuthn *webauthn.WebAuthn,
+	userRepo repository.UserRepository,
+	webAuthnRepo repository.WebAuthnRepository,
+	authService service.AuthService,
+	sessions *WebAuthnSessionStore,
+	mfaChallenges *MFAChallengeStore,
+	securityEvents *SecurityEvents,
+	mailer mailer.Mailer,
+	config *config.Config,
+	log *logger.Logger,
+) service.WebAuthnService {
+	return &WebAuthnService{
+		webAuthn:       webAuthn,
+		userRepo:       userRepo,
+		webAuthnRepo:   webAuthnRepo,
+		authService:    authService,
+		sessions:       sessions,
+		mfaChallenges:  mfaChallenges,
+		securityEvents: securityEvents,
+		mailer:         mailer,
+		config:         config,
+		log:            log,
+	}
+}
+
+// NewWebAuthn cria o relying party a partir da configuração
+func NewWebAuthn(cfg *config.Config) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthn.RPID,
+		RPDisplayName: cfg.WebAuthn.RPDisplayName,
+		RPOrigins:     cfg.WebAuthn.RPOrigins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: cfg.WebAuthn.CeremonyTTL,
+			},
+			Registration: webauthn.TimeoutConfig{
+				Enforce: true,
+				Timeout: cfg.WebAuthn.CeremonyTTL,
+			},
+		},
+	})
+}
+
+func (s *WebAuthnService) BeginRegistration(ctx context.Context, current *auth.Claims, name string) (*service.WebAuthnCeremony, error) {
+	// Quem obteve apenas um token da sessão não pode cadastrar um autenticador próprio na conta
+	if err := requireRecentAuth(current, s.config.Auth.ReauthMaxAge); err != nil {
+		return nil, err
+	}
+	userID := current.UserID
+
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError("token inválido")
+	}
+
+	name = validation.SanitizeString(name)
+	if len(name) > maxWebAuthnCredentialNameLength {
+		return nil, apperrors.NewValidationError("nome da credencial muito longo")
+	}
+
+	// Impedir o registro duplicado do mesmo autenticador
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, credential := range user.WebAuthnCredentials() {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(exclusions),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar registro WebAuthn: %w", err)
+	}
+
+	ceremonyID, err := s.sessions.Save(ctx, &webAuthnCeremony{
+		Session: *session,
+		UserID:  userID,
+		Name:    name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.WebAuthnCeremony{
+		CeremonyID: ceremonyID,
+		Options:    creation,
+	}, nil
+}
+
+func (s *WebAuthnService) FinishRegistration(ctx context.Context, userID, ceremonyID string, body io.Reader) (*entity.WebAuthnCredential, error) {
+	ceremony, err := s.consumeCeremony(ctx, ceremonyID)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.UserID != userID {
+		return nil, apperrors.NewValidationError("cerimônia WebAuthn inválida ou expirada")
+	}
+
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError("token inválido")
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return nil, apperrors.NewValidationError("resposta WebAuthn inválida")
+	}
+
+	credential, err := s.webAuthn.CreateCredential(user, ceremony.Session, parsed)
+	if err != nil {
+		return nil, apperrors.NewValidationError("não foi possível validar a credencial")
+	}
+
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	record := &entity.WebAuthnCredential{
+		UserID:          user.user.ID,
+		Name:            ceremony.Name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+	}
+
+	if err := s.webAuthnRepo.Create(ctx, record); err != nil {
+		return nil, fmt.Errorf("erro ao salvar credencial WebAuthn: %w", err)
+	}
+
+	s.securityEvents.Emit(ctx, SecurityEvent{
+		Type:    SecurityEventPasskeyAdded,
+		UserID:  userID,
+		Details: map[string]string{"credential_id": fmt.Sprintf("%d", record.ID)},
+	})
+	s.notify(ctx, user.user.Email, "Nova passkey cadastrada", fmt.Sprintf("A passkey \"%s\" foi cadastrada na sua conta. Se não foi você, remova-a e troque sua senha imediatamente.", record.Name))
+
+	return record, nil
+}
+
+// BeginLogin inicia uma autenticação por passkey. Com mfaToken a passkey conclui um login
+// iniciado com senha; sem ele o login é sem senha, por credencial descoberta, e exige verificação
+// do usuário no autenticador.
+func (s *WebAuthnService) BeginLogin(ctx context.Context, mfaToken string) (*service.WebAuthnCeremony, error) {
+	var (
+		assertion *protocol.CredentialAssertion
+		session   *webauthn.SessionData
+		ceremony  webAuthnCeremony
+		err       error
+	)
+
+	if mfaToken != "" {
+		challenge, err := s.mfaChallenges.Get(ctx, mfaToken)
+		if err == ErrMFAChallengeNotFound {
+			return nil, apperrors.NewUnauthorizedError("desafio MFA inválido ou expirado")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := s.loadLoginUser(ctx, challenge.UserID)
+		if err != nil {
+			return nil, apperrors.NewUnauthorizedError("desafio MFA inválido ou expirado")
+		}
+		if len(user.credentials) == 0 {
+			return nil, apperrors.NewNotFoundError("nenhuma passkey cadastrada")
+		}
+
+		assertion, session, err = s.webAuthn.BeginLogin(user, webauthn.WithUserVerification(protocol.VerificationPreferred))
+		if err != nil {
+			return nil, fmt.Errorf("erro ao iniciar autenticação WebAuthn: %w", err)
+		}
+		ceremony.UserID = challenge.UserID
+		ceremony.MFAToken = mfaToken
+		ceremony.AMR = challenge.AMR
+	} else {
+		// Sem a lista de credenciais (allowCredentials) a resposta não revela quais emails estão cadastrados
+		// nem quais passkeys eles têm
+		assertion, session, err = s.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("erro ao iniciar autenticação WebAuthn: %w", err)
+	}
+
+	ceremony.Session = *session
+	ceremonyID, err := s.sessions.Save(ctx, &ceremony)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service.WebAuthnCeremony{
+		CeremonyID: ceremonyID,
+		Options:    assertion,
+	}, nil
+}
+
+func (s *WebAuthnService) FinishLogin(ctx context.Context, ceremonyID string, body io.Reader) (*service.TokenPair, error) {
+	ceremony, err := s.consumeCeremony(ctx, ceremonyID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, apperrors.NewValidationError("resposta WebAuthn inválida")
+	}
+
+	var (
+		user       *webAuthnUser
+		credential *webauthn.Credential
+	)
+
+	if ceremony.UserID != "" {
+		user, err = s.loadLoginUser(ctx, ceremony.UserID)
+		if err != nil {
+			return nil, apperrors.NewUnauthorizedError("credencial inválida")
+		}
+		credential, err = s.webAuthn.ValidateLogin(user, ceremony.Session, parsed)
+	} else {
+		credential, err = s.webAuthn.ValidateDiscoverableLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+			user, err = s.loadLoginUser(ctx, string(userHandle))
+			return user, err
+		}, ceremony.Session, parsed)
+	}
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError("credencial inválida")
+	}
+
+	if credential.Authenticator.CloneWarning {
+		return nil, apperrors.NewUnauthorizedError("possível clonagem do autenticador detectada")
+	}
+
+	// Atualizar contador de assinaturas e último uso
+	record, err := s.webAuthnRepo.FindByCredentialID(ctx, credential.ID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar credencial WebAuthn: %w", err)
+	}
+	if record == nil || record.UserID != user.user.ID {
+		return nil, apperrors.NewUnauthorizedError("credencial inválida")
+	}
+
+	now := time.Now()
+	record.SignCount = credential.Authenticator.SignCount
+	record.BackupState = credential.Flags.BackupState
+	record.LastUsedAt = &now
+	if err := s.webAuthnRepo.Update(ctx, record); err != nil {
+		return nil, fmt.Errorf("erro ao atualizar credencial WebAuthn: %w", err)
+	}
+
+	userID := fmt.Sprintf("%d", user.user.ID)
+
+	// Quando usada como segundo fator, a passkey conclui o desafio emitido no login com senha
+	amr := []string{auth.AMRHardwareKey}
+	if ceremony.MFAToken != "" {
+		consumed, err := s.mfaChallenges.Consume(ctx, ceremony.MFAToken)
+		if err != nil {
+			return nil, err
+		}
+		if !consumed {
+			return nil, apperrors.NewUnauthorizedError("desafio MFA inválido ou expirado")
+		}
+		amr = append(ceremony.AMR, auth.AMRHardwareKey, auth.AMRMultiFactor)
+	}
+
+	return s.authService.CompleteLogin(ctx, userID, amr)
+}
+
+func (s *WebAuthnService) ListCredentials(ctx context.Context, userID string) ([]entity.WebAuthnCredential, error) {
+	credentials, err := s.webAuthnRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar credenciais WebAuthn: %w", err)
+	}
+	return credentials, nil
+}
+
+func (s *WebAuthnService) DeleteCredential(ctx context.Context, current *auth.Claims, id string) error {
+	if err := requireRecentAuth(current, s.config.Auth.ReauthMaxAge); err != nil {
+		return err
+	}
+	userID := current.UserID
+
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return apperrors.NewUnauthorizedError("token inválido")
+	}
+	var name string
+	for _, credential := range user.credentials {
+		if fmt.Sprintf("%d", credential.ID) == id {
+			name = credential.Name
+		}
+	}
+
+	deleted, err := s.webAuthnRepo.Delete(ctx, userID, id)
+	if err != nil {
+		return fmt.Errorf("erro ao remover credencial WebAuthn: %w", err)
+	}
+	if !deleted {
+		return apperrors.NewNotFoundError("credencial não encontrada")
+	}
+
+	s.securityEvents.Emit(ctx, SecurityEvent{
+		Type:    SecurityEventPasskeyRemoved,
+		UserID:  userID,
+		Details: map[string]string{"credential_id": id},
+	})
+	s.notify(ctx, user.user.Email, "Passkey removida", fmt.Sprintf("A passkey \"%s\" foi removida da sua conta. Se não foi você, troque sua senha imediatamente.", name))
+	return nil
+}
+
+// notify avisa o usuário de uma alteração nas suas passkeys. O envio é feito em segundo plano: a alteração
+// já foi aplicada e não deve falhar por causa do email.
+func (s *WebAuthnService) notify(ctx context.Context, email, subject, body string) {
+	go func() {
+		if err := s.mailer.Send(context.WithoutCancel(ctx), email, subject, body); err != nil {
+			s.log.Error("Erro ao enviar aviso de passkey: %v", err)
+		}
+	}()
+}
+
+func (s *WebAuthnService) consumeCeremony(ctx context.Context, ceremonyID string) (*webAuthnCeremony, error) {
+	ceremony, err := s.sessions.Consume(ctx, ceremonyID)
+	if err == ErrWebAuthnSessionNotFound {
+		return nil, apperrors.NewValidationError("cerimônia WebAuthn inválida ou expirada")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ceremony, nil
+}
+
+func (s *WebAuthnService) loadUser(ctx context.Context, userID string) (*webAuthnUser, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.withCredentials(ctx, user)
+}
+
+// loadLoginUser inclui as contas excluídas: quem está no prazo de carência pode restaurá-la concluindo o login,
+// e as demais são recusadas por AuthService.CompleteLogin
+func (s *WebAuthnService) loadLoginUser(ctx context.Context, userID string) (*webAuthnUser, error) {
+	user, err := s.userRepo.FindByIDWithDeleted(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("usuário %s não encontrado", userID)
+	}
+	return s.withCredentials(ctx, user)
+}
+
+func (s *WebAuthnService) withCredentials(ctx context.Context, user *entity.User) (*webAuthnUser, error) {
+	credentials, err := s.webAuthnRepo.FindByUserID(ctx, fmt.Sprintf("%d", user.ID))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar credenciais WebAuthn: %w", err)
+	}
+	return &webAuthnUser{
+		user:        user,
+		credentials: credentials,
+	}, nil
+}
+
+// webAuthnUser adapta entity.User à interface webauthn.User. O user handle é o ID do usuário.
+type webAuthnUser struct {
+	user        *entity.User
+	credentials []entity.WebAuthnCredential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return []byte(fmt.Sprintf("%d", u.user.ID))
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnIcon() string {
+	return ""
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.credentials))
+	for _, c := range u.credentials {
+		transports := make([]protocol.AuthenticatorTransport, 0)
+		for _, transport := range c.TransportList() {
+			transports = append(transports, protocol.AuthenticatorTransport(transport))
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Transport:       transports,
+			Flags: webauthn.CredentialFlags{
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		})
+	}
+	return credentials
+}
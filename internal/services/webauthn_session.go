@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/redis/go-redis/v9"
+
+	"auth-template/pkg/auth"
+)
+
+const (
+	webAuthnSessionKeyPrefix = "webauthn:session:"
+	webAuthnCeremonyIDBytes  = 32
+)
+
+// ErrWebAuthnSessionNotFound indica que a cerimônia não existe, expirou ou já foi concluída
+var ErrWebAuthnSessionNotFound = errors.New("cerimônia WebAuthn não encontrada ou expirada")
+
+// webAuthnCeremony guarda o estado de uma cerimônia de registro ou autenticação entre begin e finish
+type webAuthnCeremony struct {
+	Session  webauthn.SessionData `json:"session"`
+	UserID   string               `json:"user_id,omitempty"`
+	MFAToken string               `json:"mfa_token,omitempty"`
+	AMR      []string             `json:"amr,omitempty"`
+	Name     string               `json:"name,omitempty"`
+}
+
+// WebAuthnSessionStore armazena os desafios das cerimônias WebAuthn no Redis com TTL
+type WebAuthnSessionStore struct {
+	redis *redis.Client
+	ttl   time.Duration
+}
+
+func NewWebAuthnSessionStore(redis *redis.Client, ttl time.Duration) *WebAuthnSessionStore {
+	return &WebAuthnSessionStore{
+		redis: redis,
+		ttl:   ttl,
+	}
+}
+
+// Save armazena a cerimônia e retorna o identificador que o cliente deve enviar no finish
+func (s *WebAuthnSessionStore) Save(ctx context.Context, ceremony *webAuthnCeremony) (string, error) {
+	id, err := auth.GenerateRandomToken(webAuthnCeremonyIDBytes)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(ceremony)
+	if err != nil {
+		return "", fmt.Errorf("erro ao serializar cerimônia WebAuthn: %w", err)
+	}
+
+	key := fmt.Sprintf("%s%s", webAuthnSessionKeyPrefix, auth.HashToken(id))
+	if err := s.redis.Set(ctx, key, data, s.ttl).Err(); err != nil {
+		return "", fmt.Errorf("erro ao armazenar cerimônia WebAuthn: %w", err)
+	}
+	return id, nil
+}
+
+// Consume recupera e remove a cerimônia, garantindo que cada desafio seja usado uma única vez
+func (s *WebAuthnSessionStore) Consume(ctx context.Context, id string) (*webAuthnCeremony, error) {
+	key := fmt.Sprintf("%s%s", webAuthnSessionKeyPrefix, auth.HashToken(id))
+	data, err := s.redis.GetDel(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrWebAuthnSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar cerimônia WebAuthn: %w", err)
+	}
+
+	var ceremony webAuthnCeremony
+	if err := json.Unmarshal(data, &ceremony); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar cerimônia WebAuthn: %w", err)
+	}
+	return &ceremony, nil
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const (
+	// APIKeyPrefix inicia todas as chaves de API e as distingue dos JWTs no header Authorization
+	APIKeyPrefix = "atk"
+
+	apiKeyLookupBytes = 6
+	apiKeySecretBytes = 32
+)
+
+// GenerateAPIKey gera uma chave de API no formato <prefixo>_<segredo>. O prefixo é público e serve para
+// localizar a chave no banco; apenas o hash do segredo deve ser armazenado.
+func GenerateAPIKey() (key, prefix, secret string, err error) {
+	lookup := make([]byte, apiKeyLookupBytes)
+	if _, err := rand.Read(lookup); err != nil {
+		return "", "", "", fmt.Errorf("erro ao gerar chave de API: %w", err)
+	}
+	secret, err = GenerateRandomToken(apiKeySecretBytes)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	prefix = APIKeyPrefix + hex.EncodeToString(lookup)
+	return prefix + "_" + secret, prefix, secret, nil
+}
+
+// IsAPIKey indica se o token apresentado tem o formato de uma chave de API
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// ParseAPIKey separa o prefixo e o segredo de uma chave de API
+func ParseAPIKey(key string) (prefix, secret string, ok bool) {
+	if !IsAPIKey(key) {
+		return "", "", false
+	}
+	prefix, secret, ok = strings.Cut(key, "_")
+	if !ok || prefix == "" || secret == "" {
+		return "", "", false
+	}
+	return prefix, secret, true
+}
+
+// VerifyAPIKeySecret compara, em tempo constante, o segredo com o hash armazenado
+func VerifyAPIKeySecret(secret, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashToken(secret)), []byte(hash)) == 1
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, secret, err := GenerateAPIKey()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(prefix, APIKeyPrefix))
+	assert.Equal(t, prefix+"_"+secret, key)
+
+	parsedPrefix, parsedSecret, ok := ParseAPIKey(key)
+	assert.True(t, ok)
+	assert.Equal(t, prefix, parsedPrefix)
+	assert.Equal(t, secret, parsedSecret)
+	assert.True(t, VerifyAPIKeySecret(parsedSecret, HashToken(secret)))
+	assert.False(t, VerifyAPIKeySecret(parsedSecret+"x", HashToken(secret)))
+}
+
+func TestParseAPIKey(t *testing.T) {
+	for _, key := range []string{"", "eyJhbGciOiJIUzI1NiJ9.e30.sig", APIKeyPrefix + "0011", APIKeyPrefix + "0011_"} {
+		_, _, ok := ParseAPIKey(key)
+		assert.False(t, ok, key)
+	}
+
+	// O segredo em base64 URL-safe pode conter "_"; apenas o primeiro separa o prefixo
+	prefix, secret, ok := ParseAPIKey(APIKeyPrefix + "0011_ab_cd")
+	assert.True(t, ok)
+	assert.Equal(t, APIKeyPrefix+"0011", prefix)
+	assert.Equal(t, "ab_cd", secret)
+}
@@ -1,82 +1,156 @@
 package auth
 
 import (
+	"context"
+	"strings"
 	"sync"
 	"time"
 )
 
+// AttemptLimiter acompanha as tentativas malsucedidas por identificador (ex: conta, ou conta e IP) e bloqueia
+// o identificador ao atingir o limite da política
+type AttemptLimiter interface {
+	// Locked retorna quanto falta para o fim do bloqueio do identificador; zero quando não está bloqueado
+	Locked(ctx context.Context, identifier string) (time.Duration, error)
+	// RecordFailure registra uma falha e retorna a duração do bloqueio iniciado por ela; zero quando não bloqueou
+	RecordFailure(ctx context.Context, identifier string) (time.Duration, error)
+	// Reset libera apenas o identificador, zerando falhas e bloqueios
+	Reset(ctx context.Context, identifier string) error
+	// ResetDerived libera os identificadores derivados de parent (parent + ":" + ...), sem tocar no próprio parent
+	ResetDerived(ctx context.Context, parent string) error
+}
+
+// BruteForcePolicy define quantas falhas dentro de Window bloqueiam o identificador. O primeiro bloqueio dura
+// BlockTime e cada bloqueio seguinte dobra, até MaxBlockTime; a contagem de bloqueios é esquecida após
+// LockoutMemory sem novos bloqueios.
+type BruteForcePolicy struct {
+	MaxAttempts   int
+	Window        time.Duration
+	BlockTime     time.Duration
+	MaxBlockTime  time.Duration
+	LockoutMemory time.Duration
+}
+
+// BlockDuration retorna a duração do n-ésimo bloqueio consecutivo
+func (p BruteForcePolicy) BlockDuration(lockouts int) time.Duration {
+	block := p.BlockTime
+	for i := 1; i < lockouts && block < p.MaxBlockTime; i++ {
+		block *= 2
+	}
+	if p.MaxBlockTime > 0 && block > p.MaxBlockTime {
+		block = p.MaxBlockTime
+	}
+	return block
+}
+
+// BruteForceProtector guarda as tentativas em memória. Serve a uma única instância (ex: desenvolvimento);
+// com várias réplicas, cada uma teria a própria contagem.
 type BruteForceProtector struct {
 	attempts     sync.Map
-	maxAttempts  int
-	blockTime    time.Duration
+	policy       BruteForcePolicy
+	mu           sync.Mutex
 	cleanupTimer *time.Ticker
 }
 
 type attemptInfo struct {
-	count     int
-	lastTry   time.Time
-	blockedAt time.Time
+	count       int
+	firstTry    time.Time
+	lockouts    int
+	lastLockout time.Time
+	lockedUntil time.Time
 }
 
-func NewBruteForceProtector(maxAttempts int, blockTime time.Duration) *BruteForceProtector {
+func NewBruteForceProtector(policy BruteForcePolicy) *BruteForceProtector {
 	protector := &BruteForceProtector{
-		maxAttempts:  maxAttempts,
-		blockTime:    blockTime,
+		policy:       policy,
 		cleanupTimer: time.NewTicker(time.Hour),
 	}
 	go protector.cleanup()
 	return protector
 }
 
-func (p *BruteForceProtector) RecordAttempt(identifier string) bool {
-	now := time.Now()
-
-	// Carregar ou criar informações de tentativa
-	value, _ := p.attempts.LoadOrStore(identifier, &attemptInfo{
-		lastTry: now,
-	})
-	info := value.(*attemptInfo)
+func (p *BruteForceProtector) Locked(ctx context.Context, identifier string) (time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	// Verificar se está bloqueado
-	if !info.blockedAt.IsZero() && now.Sub(info.blockedAt) < p.blockTime {
-		return false
+	value, ok := p.attempts.Load(identifier)
+	if !ok {
+		return 0, nil
+	}
+	if remaining := time.Until(value.(*attemptInfo).lockedUntil); remaining > 0 {
+		return remaining, nil
 	}
+	return 0, nil
+}
+
+func (p *BruteForceProtector) RecordFailure(ctx context.Context, identifier string) (time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	value, _ := p.attempts.LoadOrStore(identifier, &attemptInfo{firstTry: now})
+	info := value.(*attemptInfo)
 
-	// Resetar contagem se passou muito tempo desde a última tentativa
-	if now.Sub(info.lastTry) > time.Hour {
+	// Recomeçar a contagem quando a janela passou
+	if now.Sub(info.firstTry) > p.policy.Window {
 		info.count = 0
-		info.blockedAt = time.Time{}
+		info.firstTry = now
+	}
+	if info.lockouts > 0 && now.Sub(info.lastLockout) > p.policy.LockoutMemory {
+		info.lockouts = 0
 	}
 
-	// Incrementar contagem
 	info.count++
-	info.lastTry = now
-
-	// Bloquear se excedeu tentativas
-	if info.count >= p.maxAttempts {
-		info.blockedAt = now
-		return false
+	if info.count < p.policy.MaxAttempts {
+		return 0, nil
 	}
 
-	return true
+	// Bloquear, com duração crescente a cada bloqueio consecutivo
+	info.count = 0
+	info.firstTry = now
+	info.lockouts++
+	info.lastLockout = now
+	block := p.policy.BlockDuration(info.lockouts)
+	info.lockedUntil = now.Add(block)
+	return block, nil
 }
 
-func (p *BruteForceProtector) Reset(identifier string) {
+func (p *BruteForceProtector) Reset(ctx context.Context, identifier string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	p.attempts.Delete(identifier)
+	return nil
+}
+
+func (p *BruteForceProtector) ResetDerived(ctx context.Context, parent string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.attempts.Range(func(key, _ interface{}) bool {
+		if strings.HasPrefix(key.(string), parent+":") {
+			p.attempts.Delete(key)
+		}
+		return true
+	})
+	return nil
 }
 
 func (p *BruteForceProtector) cleanup() {
 	for range p.cleanupTimer.C {
+		p.mu.Lock()
 		now := time.Now()
 		p.attempts.Range(func(key, value interface{}) bool {
 			if info, ok := value.(*attemptInfo); ok {
 				// Limpar tentativas antigas
-				if now.Sub(info.lastTry) > time.Hour*24 {
+				if now.After(info.lockedUntil) && now.Sub(info.firstTry) > p.policy.Window &&
+					now.Sub(info.lastLockout) > p.policy.LockoutMemory {
 					p.attempts.Delete(key)
 				}
 			}
 			return true
 		})
+		p.mu.Unlock()
 	}
 }
 
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBruteForcePolicyBlockDuration(t *testing.T) {
+	policy := BruteForcePolicy{BlockTime: time.Minute, MaxBlockTime: 5 * time.Minute}
+
+	assert.Equal(t, time.Minute, policy.BlockDuration(1))
+	assert.Equal(t, 2*time.Minute, policy.BlockDuration(2))
+	assert.Equal(t, 4*time.Minute, policy.BlockDuration(3))
+	assert.Equal(t, 5*time.Minute, policy.BlockDuration(4))
+	assert.Equal(t, 5*time.Minute, policy.BlockDuration(50))
+}
+
+func TestBruteForceProtector(t *testing.T) {
+	ctx := context.Background()
+	protector := NewBruteForceProtector(BruteForcePolicy{
+		MaxAttempts:   3,
+		Window:        time.Minute,
+		BlockTime:     time.Minute,
+		MaxBlockTime:  time.Hour,
+		LockoutMemory: time.Hour,
+	})
+	defer protector.Close()
+
+	for i := 0; i < 2; i++ {
+		block, err := protector.RecordFailure(ctx, "conta:ip")
+		assert.NoError(t, err)
+		assert.Zero(t, block)
+	}
+	block, _ := protector.RecordFailure(ctx, "conta:ip")
+	assert.Equal(t, time.Minute, block)
+	remaining, _ := protector.Locked(ctx, "conta:ip")
+	assert.Greater(t, remaining, 59*time.Second)
+
+	// Outros identificadores não são afetados
+	remaining, _ = protector.Locked(ctx, "outra:ip")
+	assert.Zero(t, remaining)
+
+	// O reset do identificador pai não libera os derivados; ResetDerived libera
+	assert.NoError(t, protector.Reset(ctx, "conta"))
+	remaining, _ = protector.Locked(ctx, "conta:ip")
+	assert.Greater(t, remaining, time.Duration(0))
+	assert.NoError(t, protector.ResetDerived(ctx, "conta"))
+	remaining, _ = protector.Locked(ctx, "conta:ip")
+	assert.Zero(t, remaining)
+}
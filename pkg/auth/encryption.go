@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// Encryptor cifra segredos persistidos no banco (ex: segredos TOTP) com AES-256-GCM
+type Encryptor struct {
+	aead cipher.AEAD
+}
+
+// NewEncryptor cria um Encryptor derivando uma chave de 256 bits a partir do segredo configurado
+func NewEncryptor(secret string) (*Encryptor, error) {
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar cifra: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar cifra: %w", err)
+	}
+
+	return &Encryptor{
+		aead: aead,
+	}, nil
+}
+
+// Encrypt cifra o texto e retorna nonce+ciphertext codificados em base64
+func (e *Encryptor) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("erro ao gerar nonce: %w", err)
+	}
+
+	sealed := e.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decifra um valor produzido por Encrypt
+func (e *Encryptor) Decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("valor cifrado inválido: %w", err)
+	}
+
+	nonceSize := e.aead.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("valor cifrado inválido")
+	}
+
+	plaintext, err := e.aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("erro ao decifrar valor: %w", err)
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Keyring reúne a chave ativa, que assina os novos tokens, e as chaves aceitas apenas na verificação,
+// escolhidas pelo kid do token. O conteúdo pode ser trocado em execução: tokens assinados por uma chave
+// anterior continuam válidos enquanto ela permanecer no keyring.
+type Keyring struct {
+	mu     sync.RWMutex
+	active *Signer
+	keys   map[string]*Signer
+	// legacy verifica, até legacyUntil, os tokens sem kid emitidos antes do keyring
+	legacy      *Signer
+	legacyUntil time.Time
+}
+
+func NewKeyring(active *Signer, verifyOnly ...*Signer) *Keyring {
+	k := &Keyring{}
+	k.Replace(active, verifyOnly...)
+	return k
+}
+
+// Replace substitui a chave ativa e as chaves de verificação
+func (k *Keyring) Replace(active *Signer, verifyOnly ...*Signer) {
+	keys := make(map[string]*Signer, len(verifyOnly)+1)
+	for _, signer := range verifyOnly {
+		keys[signer.Kid()] = signer
+	}
+	keys[active.Kid()] = active
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.active = active
+	k.keys = keys
+}
+
+// SetLegacy aceita, até until, os tokens sem kid assinados com o segredo HS256 usado antes do keyring, para que
+// os tokens em circulação na atualização continuem válidos. Apenas chaves HMAC são aceitas.
+func (k *Keyring) SetLegacy(signer *Signer, until time.Time) error {
+	if signer.Algorithm() != jwt.SigningMethodHS256.Alg() {
+		return fmt.Errorf("a chave dos tokens sem kid deve ser HS256, recebido %s", signer.Algorithm())
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.legacy = signer
+	k.legacyUntil = until
+	return nil
+}
+
+// LimitLegacy antecipa para until o fim da aceitação dos tokens sem kid, se ele for anterior ao atual
+func (k *Keyring) LimitLegacy(until time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if until.Before(k.legacyUntil) {
+		k.legacyUntil = until
+	}
+}
+
+// Active retorna a chave que assina os novos tokens
+func (k *Keyring) Active() *Signer {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+// Algorithm retorna o algoritmo da chave ativa
+func (k *Keyring) Algorithm() string {
+	return k.Active().Algorithm()
+}
+
+// Sign assina as claims com a chave ativa
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	return k.Active().Sign(claims)
+}
+
+// Parse verifica o token com a chave indicada pelo kid; tokens sem kid usam a chave legada, se ainda aceita.
+// Os erros de validação das claims (ex: expiração) são retornados como *jwt.ValidationError.
+func (k *Keyring) Parse(tokenString string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, hasKid := token.Header["kid"].(string)
+
+		k.mu.RLock()
+		signer, ok := k.keys[kid]
+		if !hasKid {
+			signer, ok = k.legacy, k.legacy != nil && time.Now().Before(k.legacyUntil)
+		}
+		k.mu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("kid desconhecido: %v", token.Header["kid"])
+		}
+		return signer.verificationKey(token)
+	})
+	return err
+}
+
+// JWKS retorna as chaves públicas de todas as chaves assimétricas do keyring, a ativa primeiro
+func (k *Keyring) JWKS() JWKSet {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	jwks := k.active.JWKS()
+	for kid, signer := range k.keys {
+		if kid != k.active.Kid() {
+			jwks.Keys = append(jwks.Keys, signer.JWKS().Keys...)
+		}
+	}
+	return jwks
+}
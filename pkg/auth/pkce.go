@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+)
+
+// PKCEMethodS256 é o único método de PKCE (RFC 7636) aceito; "plain" não é suportado
+const PKCEMethodS256 = "S256"
+
+// GeneratePKCEVerifier gera um code_verifier aleatório (43 caracteres, 256 bits de entropia)
+func GeneratePKCEVerifier() (string, error) {
+	return GenerateRandomToken(32)
+}
+
+// PKCEChallenge calcula o code_challenge S256 de um code_verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPKCE verifica se o code_verifier corresponde ao code_challenge S256
+func VerifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(PKCEChallenge(verifier)), []byte(challenge)) == 1
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// Sign calcula a assinatura HMAC-SHA256 (base64 URL-safe) das partes informadas
+func Sign(secret string, parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, part := range parts {
+		mac.Write([]byte(part))
+		// Separador evita que partes diferentes produzam a mesma mensagem
+		mac.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature compara em tempo constante a assinatura recebida com a esperada
+func VerifySignature(secret, signature string, parts ...string) bool {
+	return hmac.Equal([]byte(Sign(secret, parts...)), []byte(signature))
+}
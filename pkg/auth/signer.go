@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Signer assina e verifica JWTs com uma única chave, identificada pelo kid no cabeçalho.
+// Chaves assimétricas (RS256, ES256, ES384, ES512, EdDSA) têm a chave pública publicada no JWKS;
+// chaves HMAC (HS256) são segredos compartilhados e nunca são publicadas.
+type Signer struct {
+	kid        string
+	method     jwt.SigningMethod
+	signingKey interface{}
+	verifyKey  interface{}
+	jwk        *JWK
+}
+
+// NewSigner cria um Signer para uma chave privada RSA, ECDSA (P-256, P-384 ou P-521) ou Ed25519.
+// O algoritmo é definido pelo tipo da chave e o kid é o thumbprint da chave pública (RFC 7638).
+func NewSigner(key crypto.Signer) (*Signer, error) {
+	var (
+		method jwt.SigningMethod
+		jwk    JWK
+	)
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		method = jwt.SigningMethodRS256
+		jwk = JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(k.PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.PublicKey.E)).Bytes()),
+		}
+
+	case *ecdsa.PrivateKey:
+		params := k.Curve.Params()
+		switch params.Name {
+		case "P-256":
+			method = jwt.SigningMethodES256
+		case "P-384":
+			method = jwt.SigningMethodES384
+		case "P-521":
+			method = jwt.SigningMethodES512
+		default:
+			return nil, fmt.Errorf("curva não suportada: %s", params.Name)
+		}
+		// As coordenadas têm o tamanho fixo da curva (RFC 7518, seção 6.2.1.2)
+		size := (params.BitSize + 7) / 8
+		jwk = JWK{
+			Kty: "EC",
+			Crv: params.Name,
+			X:   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+		}
+
+	case ed25519.PrivateKey:
+		method = jwt.SigningMethodEdDSA
+		jwk = JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k.Public().(ed25519.PublicKey)),
+		}
+
+	default:
+		return nil, fmt.Errorf("tipo de chave não suportado: %T", key)
+	}
+
+	kid, err := jwkThumbprint(jwk)
+	if err != nil {
+		return nil, err
+	}
+	jwk.Kid = kid
+	jwk.Use = "sig"
+	jwk.Alg = method.Alg()
+
+	verifyKey := key.Public()
+	if k, ok := key.(ed25519.PrivateKey); ok {
+		verifyKey = k.Public().(ed25519.PublicKey)
+	}
+
+	return &Signer{
+		kid:        kid,
+		method:     method,
+		signingKey: key,
+		verifyKey:  verifyKey,
+		jwk:        &jwk,
+	}, nil
+}
+
+// NewHMACSigner cria um Signer HS256 com um segredo compartilhado. O kid é derivado do hash do segredo,
+// o que não expõe mais do que qualquer token assinado com ele já expõe.
+func NewHMACSigner(secret string) *Signer {
+	sum := sha256.Sum256([]byte(secret))
+	return &Signer{
+		kid:        "hs256-" + base64.RawURLEncoding.EncodeToString(sum[:9]),
+		method:     jwt.SigningMethodHS256,
+		signingKey: []byte(secret),
+		verifyKey:  []byte(secret),
+	}
+}
+
+// jwkThumbprint calcula o SHA-256 dos membros obrigatórios da chave em ordem lexicográfica, sem espaços
+func jwkThumbprint(jwk JWK) (string, error) {
+	var members interface{}
+	switch jwk.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{jwk.Crv, jwk.Kty, jwk.X, jwk.Y}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", fmt.Errorf("erro ao calcular thumbprint da chave: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// LoadPrivateKey lê uma chave privada em PEM: RSA (PKCS#1), EC (SEC 1) ou qualquer uma delas,
+// inclusive Ed25519, em PKCS#8
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler chave privada: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("chave privada em %s não está em PEM", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar chave privada: %w", err)
+	}
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("tipo de chave não suportado em %s", path)
+	}
+	return key, nil
+}
+
+// GenerateSigner cria uma chave nova para o algoritmo: RS256 (RSA 2048), ES256 (P-256), EdDSA (Ed25519)
+// ou HS256 (segredo aleatório de 256 bits)
+func GenerateSigner(algorithm string) (*Signer, error) {
+	var (
+		key crypto.Signer
+		err error
+	)
+	switch algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		secret, err := GenerateRandomToken(32)
+		if err != nil {
+			return nil, err
+		}
+		return NewHMACSigner(secret), nil
+	case jwt.SigningMethodRS256.Alg():
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case jwt.SigningMethodES256.Alg():
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("algoritmo não suportado: %s", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gerar chave: %w", err)
+	}
+	return NewSigner(key)
+}
+
+// EncodePrivateKey serializa a chave para armazenamento: o segredo, para chaves HMAC, ou o PEM PKCS#8
+func (s *Signer) EncodePrivateKey() (string, error) {
+	if secret, ok := s.signingKey.([]byte); ok {
+		return string(secret), nil
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(s.signingKey)
+	if err != nil {
+		return "", fmt.Errorf("erro ao serializar chave privada: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// DecodeSigner reconstrói uma chave serializada por EncodePrivateKey
+func DecodeSigner(algorithm, encoded string) (*Signer, error) {
+	if algorithm == jwt.SigningMethodHS256.Alg() {
+		return NewHMACSigner(encoded), nil
+	}
+
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, fmt.Errorf("chave privada não está em PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao decodificar chave privada: %w", err)
+	}
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("tipo de chave não suportado: %T", parsed)
+	}
+
+	signer, err := NewSigner(key)
+	if err != nil {
+		return nil, err
+	}
+	if signer.Algorithm() != algorithm {
+		return nil, fmt.Errorf("a chave usa %s, esperado %s", signer.Algorithm(), algorithm)
+	}
+	return signer, nil
+}
+
+// Kid retorna o identificador da chave, enviado no cabeçalho dos tokens
+func (s *Signer) Kid() string {
+	return s.kid
+}
+
+// Algorithm retorna o algoritmo JWS da chave (ex: RS256)
+func (s *Signer) Algorithm() string {
+	return s.method.Alg()
+}
+
+// Sign assina as claims incluindo o kid no cabeçalho
+func (s *Signer) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.method, claims)
+	token.Header["kid"] = s.kid
+	return token.SignedString(s.signingKey)
+}
+
+// Parse verifica a assinatura e decodifica as claims. Tokens com outro algoritmo ou outro kid são recusados.
+// Os erros de validação das claims (ex: expiração) são retornados como *jwt.ValidationError.
+func (s *Signer) Parse(tokenString string, claims jwt.Claims) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if kid, _ := token.Header["kid"].(string); kid != s.kid {
+			return nil, fmt.Errorf("kid desconhecido: %v", token.Header["kid"])
+		}
+		return s.verificationKey(token)
+	})
+	return err
+}
+
+// verificationKey retorna a chave que verifica o token, exigindo o algoritmo da chave para impedir
+// a troca do algoritmo pelo emissor do token (ex: HS256 com a chave pública RSA como segredo)
+func (s *Signer) verificationKey(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() != s.method.Alg() {
+		return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
+	}
+	return s.verifyKey, nil
+}
+
+// JWKS retorna o documento com a chave pública para publicação em jwks_uri; vazio para chaves HMAC
+func (s *Signer) JWKS() JWKSet {
+	if s.jwk == nil {
+		return JWKSet{Keys: []JWK{}}
+	}
+	return JWKSet{Keys: []JWK{*s.jwk}}
+}
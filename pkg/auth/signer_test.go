@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignerRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for alg, key := range map[string]crypto.Signer{"RS256": rsaKey, "ES256": ecKey, "EdDSA": edKey} {
+		t.Run(alg, func(t *testing.T) {
+			signer, err := NewSigner(key)
+			require.NoError(t, err)
+			assert.Equal(t, alg, signer.Algorithm())
+
+			token, err := signer.Sign(jwt.StandardClaims{
+				Subject:   "42",
+				ExpiresAt: time.Now().Add(time.Minute).Unix(),
+			})
+			require.NoError(t, err)
+
+			var claims jwt.StandardClaims
+			require.NoError(t, signer.Parse(token, &claims))
+			assert.Equal(t, "42", claims.Subject)
+
+			// A chave publicada no JWKS verifica a assinatura
+			jwk, ok := signer.JWKS().Key(signer.Kid())
+			require.True(t, ok)
+			publicKey, err := jwk.PublicKey()
+			require.NoError(t, err)
+			parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return publicKey, nil })
+			require.NoError(t, err)
+			assert.Equal(t, signer.Kid(), parsed.Header["kid"])
+		})
+	}
+
+	// Um token assinado por outra chave é recusado
+	signer, _ := NewSigner(rsaKey)
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	other, _ := NewSigner(otherKey)
+	forged, _ := other.Sign(jwt.StandardClaims{Subject: "42"})
+	assert.Error(t, signer.Parse(forged, &jwt.StandardClaims{}))
+}
+
+func TestSignerEncoding(t *testing.T) {
+	for _, alg := range []string{"HS256", "RS256", "ES256", "EdDSA"} {
+		signer, err := GenerateSigner(alg)
+		require.NoError(t, err)
+
+		encoded, err := signer.EncodePrivateKey()
+		require.NoError(t, err)
+		decoded, err := DecodeSigner(alg, encoded)
+		require.NoError(t, err)
+		assert.Equal(t, signer.Kid(), decoded.Kid(), alg)
+
+		token, err := signer.Sign(jwt.StandardClaims{Subject: "42"})
+		require.NoError(t, err)
+		assert.NoError(t, decoded.Parse(token, &jwt.StandardClaims{}), alg)
+	}
+}
+
+func TestHMACSigner(t *testing.T) {
+	signer := NewHMACSigner("segredo")
+	assert.Equal(t, "HS256", signer.Algorithm())
+	assert.Empty(t, signer.JWKS().Keys)
+
+	token, err := signer.Sign(jwt.StandardClaims{Subject: "42"})
+	require.NoError(t, err)
+	require.NoError(t, signer.Parse(token, &jwt.StandardClaims{}))
+
+	// Um token HS256 não é aceito por uma chave assimétrica, mesmo com o mesmo kid
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rsaSigner, _ := NewSigner(key)
+	assert.Error(t, rsaSigner.Parse(token, &jwt.StandardClaims{}))
+	assert.Error(t, NewHMACSigner("outro").Parse(token, &jwt.StandardClaims{}))
+}
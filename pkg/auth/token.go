@@ -14,12 +14,45 @@ type TokenType string
 const (
 	TokenTypeAccess  TokenType = "access"
 	TokenTypeRefresh TokenType = "refresh"
+	// TokenTypeAPIKey não é emitido como JWT; marca as claims das requisições autenticadas por chave de API
+	TokenTypeAPIKey TokenType = "api_key"
+)
+
+// Métodos de autenticação registrados na claim amr (RFC 8176). "email" e "fed" não constam do registro
+// da RFC, mas são usados por outros provedores para login por link e por provedor externo.
+const (
+	AMRPassword    = "pwd"
+	AMROTP         = "otp"
+	AMRHardwareKey = "hwk"
+	AMRMultiFactor = "mfa"
+	AMREmail       = "email"
+	AMRFederated   = "fed"
 )
 
 // Claims representa os dados armazenados no token JWT
 type Claims struct {
 	UserID string    `json:"user_id"`
 	Type   TokenType `json:"type"`
+	// AuthTime e AMR registram quando e como o usuário se autenticou; são preservados na renovação
+	AuthTime int64    `json:"auth_time,omitempty"`
+	AMR      []string `json:"amr,omitempty"`
+	// ClientID e Scope são preenchidos nos tokens emitidos pelo servidor OAuth para clientes terceiros
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// FamilyID agrupa os refresh tokens obtidos por rotação a partir do mesmo login; o jti (Id) identifica
+	// cada membro da família
+	FamilyID string `json:"family_id,omitempty"`
+	// SessionID identifica a sessão de primeira parte registrada no login (access e refresh tokens)
+	SessionID string `json:"sid,omitempty"`
+	// TenantID é a organização selecionada na sessão; vazio quando o usuário não participa de nenhuma
+	TenantID string `json:"tenant_id,omitempty"`
+	// Roles e Permissions são os papéis do usuário e a união das suas permissões no momento da emissão
+	// do access token; alterações nos papéis valem a partir da próxima renovação
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// IssuedAtMs é o instante da emissão em milissegundos; o iat, em segundos, não basta para ordenar o token em
+	// relação a uma revogação feita no mesmo segundo
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -27,18 +60,20 @@ func (c *Claims) Valid() error {
 	return c.StandardClaims.Valid()
 }
 
-// TokenManager gerencia a geração e validação de tokens JWT
+// TokenManager gerencia a geração e validação de tokens JWT. Cada tipo de token tem seu keyring: os access
+// tokens podem ser assinados com chaves assimétricas, verificáveis por outros serviços pelo JWKS; os refresh
+// tokens só são lidos por este serviço e usam segredos HMAC.
 type TokenManager struct {
-	accessSecret    string
-	refreshSecret   string
+	accessKeys      *Keyring
+	refreshKeys     *Keyring
 	accessTokenTTL  time.Duration
 	refreshTokenTTL time.Duration
 }
 
-func NewTokenManager(accessSecret, refreshSecret string, accessTokenTTL, refreshTokenTTL time.Duration) *TokenManager {
+func NewTokenManager(accessKeys, refreshKeys *Keyring, accessTokenTTL, refreshTokenTTL time.Duration) *TokenManager {
 	return &TokenManager{
-		accessSecret:    accessSecret,
-		refreshSecret:   refreshSecret,
+		accessKeys:      accessKeys,
+		refreshKeys:     refreshKeys,
 		accessTokenTTL:  accessTokenTTL,
 		refreshTokenTTL: refreshTokenTTL,
 	}
@@ -46,61 +81,86 @@ func NewTokenManager(accessSecret, refreshSecret string, accessTokenTTL, refresh
 
 // GenerateToken gera um novo token JWT do tipo especificado
 func (m *TokenManager) GenerateToken(userID string, tokenType TokenType) (string, error) {
+	return m.GenerateTokenWithClaims(&Claims{
+		UserID: userID,
+		Type:   tokenType,
+	})
+}
+
+// tokenIDBytes é o tamanho do jti gerado para os tokens que não trazem um
+const tokenIDBytes = 16
+
+// GenerateTokenWithClaims assina as claims informadas, preenchendo emissão e expiração conforme o tipo do token
+// e o jti, quando não informado
+func (m *TokenManager) GenerateTokenWithClaims(claims *Claims) (string, error) {
 	var duration time.Duration
-	var secret string
+	var keys *Keyring
 
-	switch tokenType {
+	switch claims.Type {
 	case TokenTypeAccess:
 		duration = m.accessTokenTTL
-		secret = m.accessSecret
+		keys = m.accessKeys
 	case TokenTypeRefresh:
 		duration = m.refreshTokenTTL
-		secret = m.refreshSecret
+		keys = m.refreshKeys
 	default:
-		return "", fmt.Errorf("tipo de token inválido: %s", tokenType)
+		return "", fmt.Errorf("tipo de token inválido: %s", claims.Type)
 	}
 
-	claims := &Claims{
-		UserID: userID,
-		Type:   tokenType,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(duration).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
+	if claims.Id == "" {
+		id, err := GenerateRandomToken(tokenIDBytes)
+		if err != nil {
+			return "", err
+		}
+		claims.Id = id
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	now := time.Now()
+	claims.ExpiresAt = now.Add(duration).Unix()
+	claims.IssuedAt = now.Unix()
+	claims.IssuedAtMs = now.UnixMilli()
+
+	return keys.Sign(claims)
+}
+
+// TTL retorna a validade configurada para o tipo de token
+func (m *TokenManager) TTL(tokenType TokenType) time.Duration {
+	if tokenType == TokenTypeRefresh {
+		return m.refreshTokenTTL
+	}
+	return m.accessTokenTTL
+}
+
+// Keys retorna o keyring do tipo de token
+func (m *TokenManager) Keys(tokenType TokenType) *Keyring {
+	if tokenType == TokenTypeRefresh {
+		return m.refreshKeys
+	}
+	return m.accessKeys
+}
+
+// JWKS retorna as chaves públicas que verificam os access tokens; vazio no modo HS256
+func (m *TokenManager) JWKS() JWKSet {
+	return m.accessKeys.JWKS()
 }
 
 // ValidateToken valida um token JWT e retorna suas claims
 func (m *TokenManager) ValidateToken(tokenString string, expectedType TokenType) (*Claims, error) {
-	var secret string
+	var keys *Keyring
 	switch expectedType {
 	case TokenTypeAccess:
-		secret = m.accessSecret
+		keys = m.accessKeys
 	case TokenTypeRefresh:
-		secret = m.refreshSecret
+		keys = m.refreshKeys
 	default:
 		return nil, fmt.Errorf("tipo de token inválido: %s", expectedType)
 	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("método de assinatura inesperado: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
-
-	if err != nil {
+	claims := &Claims{}
+	if err := keys.Parse(tokenString, claims); err != nil {
 		return nil, fmt.Errorf("erro ao validar token: %w", err)
 	}
 
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		return nil, fmt.Errorf("token inválido")
-	}
-
 	if claims.Type != expectedType {
 		return nil, fmt.Errorf("tipo de token inválido: esperado %s, recebido %s", expectedType, claims.Type)
 	}
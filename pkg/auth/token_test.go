@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenManagerAsymmetricAccessTokens(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	accessSigner, err := NewSigner(key)
+	require.NoError(t, err)
+	manager := NewTokenManager(NewKeyring(accessSigner), NewKeyring(NewHMACSigner("refresh")), time.Minute, time.Hour)
+
+	accessToken, err := manager.GenerateToken("42", TokenTypeAccess)
+	require.NoError(t, err)
+	refreshToken, err := manager.GenerateToken("42", TokenTypeRefresh)
+	require.NoError(t, err)
+
+	claims, err := manager.ValidateToken(accessToken, TokenTypeAccess)
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims.UserID)
+	assert.Equal(t, claims.IssuedAt, claims.IssuedAtMs/1000)
+	_, err = manager.ValidateToken(refreshToken, TokenTypeRefresh)
+	require.NoError(t, err)
+
+	// Um serviço externo verifica o access token apenas com o JWKS
+	parsed, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
+		jwk, ok := manager.JWKS().Key(token.Header["kid"].(string))
+		require.True(t, ok)
+		return jwk.PublicKey()
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", parsed.Method.Alg())
+
+	// Refresh tokens não são aceitos como access tokens e vice-versa
+	_, err = manager.ValidateToken(refreshToken, TokenTypeAccess)
+	assert.Error(t, err)
+	_, err = manager.ValidateToken(accessToken, TokenTypeRefresh)
+	assert.Error(t, err)
+}
+
+func TestTokenManagerKeyRotation(t *testing.T) {
+	oldKey, err := GenerateSigner("EdDSA")
+	require.NoError(t, err)
+	accessKeys := NewKeyring(oldKey)
+	manager := NewTokenManager(accessKeys, NewKeyring(NewHMACSigner("refresh")), time.Minute, time.Hour)
+
+	oldToken, err := manager.GenerateToken("42", TokenTypeAccess)
+	require.NoError(t, err)
+
+	// A nova chave passa a assinar; a anterior continua verificando os tokens em circulação
+	newKey, err := GenerateSigner("EdDSA")
+	require.NoError(t, err)
+	accessKeys.Replace(newKey, oldKey)
+	assert.Len(t, manager.JWKS().Keys, 2)
+	assert.Equal(t, newKey.Kid(), manager.JWKS().Keys[0].Kid)
+
+	newToken, err := manager.GenerateToken("42", TokenTypeAccess)
+	require.NoError(t, err)
+	parsed, _, err := new(jwt.Parser).ParseUnverified(newToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, newKey.Kid(), parsed.Header["kid"])
+
+	_, err = manager.ValidateToken(oldToken, TokenTypeAccess)
+	assert.NoError(t, err)
+
+	// Aposentada a chave anterior, seus tokens deixam de ser aceitos
+	accessKeys.Replace(newKey)
+	_, err = manager.ValidateToken(oldToken, TokenTypeAccess)
+	assert.Error(t, err)
+	_, err = manager.ValidateToken(newToken, TokenTypeAccess)
+	assert.NoError(t, err)
+}
+
+func TestTokenManagerLegacyTokens(t *testing.T) {
+	refreshKeys := NewKeyring(NewHMACSigner("refresh-keyring"))
+	manager := NewTokenManager(NewKeyring(NewHMACSigner("access-keyring")), refreshKeys, time.Minute, time.Hour)
+	require.NoError(t, refreshKeys.SetLegacy(NewHMACSigner("refresh-env"), time.Now().Add(time.Hour)))
+
+	// Token emitido antes do keyring: HS256 com o segredo de ambiente e sem kid
+	legacyToken := func(method jwt.SigningMethod, key interface{}) string {
+		token, err := jwt.NewWithClaims(method, &Claims{
+			UserID: "42",
+			Type:   TokenTypeRefresh,
+			StandardClaims: jwt.StandardClaims{
+				ExpiresAt: time.Now().Add(time.Hour).Unix(),
+				IssuedAt:  time.Now().Unix(),
+			},
+		}).SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+
+	claims, err := manager.ValidateToken(legacyToken(jwt.SigningMethodHS256, []byte("refresh-env")), TokenTypeRefresh)
+	require.NoError(t, err)
+	assert.Equal(t, "42", claims.UserID)
+
+	// Outro segredo ou outro algoritmo não são aceitos sem kid
+	_, err = manager.ValidateToken(legacyToken(jwt.SigningMethodHS256, []byte("outro")), TokenTypeRefresh)
+	assert.Error(t, err)
+	_, err = manager.ValidateToken(legacyToken(jwt.SigningMethodHS512, []byte("refresh-env")), TokenTypeRefresh)
+	assert.Error(t, err)
+
+	// Encerrada a janela, nem o token legítimo é aceito
+	refreshKeys.LimitLegacy(time.Now().Add(-time.Second))
+	_, err = manager.ValidateToken(legacyToken(jwt.SigningMethodHS256, []byte("refresh-env")), TokenTypeRefresh)
+	assert.Error(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err := NewSigner(key)
+	require.NoError(t, err)
+	assert.Error(t, refreshKeys.SetLegacy(signer, time.Now().Add(time.Hour)))
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateTOTPCode(t *testing.T) {
+	// Vetores da RFC 6238 (SHA1), truncados para 6 dígitos
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range cases {
+		code, err := GenerateTOTPCode(secret, TOTPStep(time.Unix(unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code, "instante %d", unix)
+	}
+}
+
+func TestValidateTOTPCode(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	previous, _ := GenerateTOTPCode(secret, TOTPStep(now)-1)
+
+	step, ok := ValidateTOTPCode(secret, previous, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now)-1, step)
+
+	_, ok = ValidateTOTPCode(secret, previous, now, 0)
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTPCode(secret, "12345", now, 1)
+	assert.False(t, ok)
+}
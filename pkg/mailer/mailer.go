@@ -0,0 +1,78 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"auth-template/internal/config"
+	"auth-template/pkg/logger"
+)
+
+// Mailer envia emails transacionais (verificação, recuperação de senha, etc.)
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// NewMailer retorna um SMTPMailer quando MAIL_HOST está configurado,
+// caso contrário um LogMailer que apenas registra as mensagens (útil em desenvolvimento)
+func NewMailer(cfg *config.Config, log *logger.Logger) Mailer {
+	if cfg.Mail.Host == "" {
+		return NewLogMailer(log)
+	}
+	return NewSMTPMailer(&cfg.Mail)
+}
+
+// SMTPMailer envia emails através de um servidor SMTP
+type SMTPMailer struct {
+	cfg *config.MailConfig
+}
+
+func NewSMTPMailer(cfg *config.MailConfig) *SMTPMailer {
+	return &SMTPMailer{
+		cfg: cfg,
+	}
+}
+
+// Send envia uma mensagem em texto puro para o destinatário
+func (m *SMTPMailer) Send(ctx context.Context, to, subject, body string) error {
+	addr := fmt.Sprintf("%s:%d", m.cfg.Host, m.cfg.Port)
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + m.cfg.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		body,
+	}, "\r\n")
+
+	if err := smtp.SendMail(addr, auth, m.cfg.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("erro ao enviar email: %w", err)
+	}
+	return nil
+}
+
+// LogMailer registra os emails no log em vez de enviá-los
+type LogMailer struct {
+	log *logger.Logger
+}
+
+func NewLogMailer(log *logger.Logger) *LogMailer {
+	return &LogMailer{
+		log: log,
+	}
+}
+
+// Send registra a mensagem no log
+func (m *LogMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.log.Info("Email para %s: %s\n%s", to, subject, body)
+	return nil
+}
@@ -0,0 +1,65 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Audience aceita a claim aud tanto como string quanto como lista (RFC 7519, seção 4.1.3)
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("claim aud inválida: %w", err)
+	}
+	*a = list
+	return nil
+}
+
+// Contains informa se o valor está entre os destinatários do token
+func (a Audience) Contains(value string) bool {
+	for _, aud := range a {
+		if aud == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IDTokenClaims contém as claims do ID token usadas para identificar o usuário
+type IDTokenClaims struct {
+	Issuer          string   `json:"iss"`
+	Subject         string   `json:"sub"`
+	Audience        Audience `json:"aud"`
+	AuthorizedParty string   `json:"azp,omitempty"`
+	ExpiresAt       int64    `json:"exp"`
+	IssuedAt        int64    `json:"iat"`
+	NotBefore       int64    `json:"nbf,omitempty"`
+	Nonce           string   `json:"nonce,omitempty"`
+	Email           string   `json:"email,omitempty"`
+	EmailVerified   bool     `json:"email_verified,omitempty"`
+	Name            string   `json:"name,omitempty"`
+}
+
+// Valid verifica os campos temporais tolerando uma pequena diferença de relógio com o provedor
+func (c *IDTokenClaims) Valid() error {
+	now := time.Now()
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(clockSkew)) {
+		return fmt.Errorf("token expirado")
+	}
+	if c.IssuedAt != 0 && now.Add(clockSkew).Before(time.Unix(c.IssuedAt, 0)) {
+		return fmt.Errorf("token emitido no futuro")
+	}
+	if c.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(c.NotBefore, 0)) {
+		return fmt.Errorf("token ainda não é válido")
+	}
+	return nil
+}
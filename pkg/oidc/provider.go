@@ -0,0 +1,313 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"auth-template/pkg/auth"
+)
+
+const (
+	metadataTTL = time.Hour
+	jwksTTL     = time.Hour
+	// Intervalo mínimo entre recargas do JWKS provocadas por kid desconhecido
+	jwksRefreshInterval = time.Minute
+	clockSkew           = time.Minute
+)
+
+// ErrInvalidIDToken indica que o ID token não passou na validação
+var ErrInvalidIDToken = errors.New("id token inválido")
+
+// Config descreve um provedor OIDC externo e as credenciais do cliente registrado nele
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Metadata contém os campos usados do documento de discovery (/.well-known/openid-configuration)
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// TokenResponse é a resposta do token endpoint para o grant authorization_code
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Provider é um relying party OIDC: monta a URL de autorização, troca o código e valida o ID token.
+// O discovery e o JWKS são carregados sob demanda e mantidos em cache.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu            sync.Mutex
+	metadata      *Metadata
+	metadataUntil time.Time
+	keys          auth.JWKSet
+	keysUntil     time.Time
+	keysFetchedAt time.Time
+}
+
+func NewProvider(config Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &Provider{
+		config: config,
+		client: client,
+	}
+}
+
+// AuthCodeURL monta a URL de autorização com state, nonce e code_challenge (PKCE S256)
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.config.ClientID)
+	params.Set("redirect_uri", p.config.RedirectURL)
+	params.Set("scope", strings.Join(p.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", auth.PKCEMethodS256)
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return metadata.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange troca o código de autorização pelos tokens do provedor
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*TokenResponse, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.config.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar requisição de token: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao trocar código de autorização: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler resposta de token: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		json.Unmarshal(body, &oauthErr)
+		return nil, fmt.Errorf("provedor recusou o código (%d): %s %s", resp.StatusCode, oauthErr.Error, oauthErr.ErrorDescription)
+	}
+
+	var token TokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("resposta de token inválida: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("resposta de token sem id_token")
+	}
+
+	return &token, nil
+}
+
+// VerifyIDToken valida assinatura (JWKS do provedor), issuer, audience, expiração e nonce do ID token
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &IDTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.publicKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		// O algoritmo do cabeçalho precisa corresponder ao tipo da chave; HMAC e "none" nunca são aceitos
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			if _, ok := key.(*rsa.PublicKey); ok {
+				return key, nil
+			}
+		case *jwt.SigningMethodECDSA:
+			if _, ok := key.(*ecdsa.PublicKey); ok {
+				return key, nil
+			}
+		case *jwt.SigningMethodEd25519:
+			if _, ok := key.(ed25519.PublicKey); ok {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("algoritmo de assinatura inesperado: %v", token.Header["alg"])
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Issuer != metadata.Issuer {
+		return nil, fmt.Errorf("%w: issuer inesperado %q", ErrInvalidIDToken, claims.Issuer)
+	}
+	if !claims.Audience.Contains(p.config.ClientID) {
+		return nil, fmt.Errorf("%w: audience não contém o client_id", ErrInvalidIDToken)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != "" && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("%w: azp inesperado", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: sub ausente", ErrInvalidIDToken)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce não confere", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// Metadata retorna o documento de discovery do provedor, buscando-o quando o cache expira
+func (p *Provider) Metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil && time.Now().Before(p.metadataUntil) {
+		return p.metadata, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	var metadata Metadata
+	if err := p.getJSON(ctx, discoveryURL, &metadata); err != nil {
+		return nil, fmt.Errorf("erro no discovery do provedor: %w", err)
+	}
+
+	// O issuer anunciado deve ser exatamente o configurado (OIDC Discovery, seção 4.3)
+	if metadata.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("issuer do discovery (%s) difere do configurado (%s)", metadata.Issuer, p.config.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("documento de discovery incompleto")
+	}
+
+	p.metadata = &metadata
+	p.metadataUntil = time.Now().Add(metadataTTL)
+	return p.metadata, nil
+}
+
+// publicKey busca a chave do kid informado, recarregando o JWKS se ela ainda não for conhecida
+// (o provedor pode ter rotacionado as chaves)
+func (p *Provider) publicKey(ctx context.Context, kid string) (interface{}, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	key, found := p.findKey(kid)
+	expired := now.After(p.keysUntil)
+	if (!found && now.Sub(p.keysFetchedAt) > jwksRefreshInterval) || expired {
+		var keys auth.JWKSet
+		if err := p.getJSON(ctx, metadata.JWKSURI, &keys); err != nil {
+			return nil, fmt.Errorf("erro ao buscar JWKS: %w", err)
+		}
+		p.keys = keys
+		p.keysFetchedAt = now
+		p.keysUntil = now.Add(jwksTTL)
+		key, found = p.findKey(kid)
+	}
+	if !found {
+		return nil, fmt.Errorf("chave %q não encontrada no JWKS", kid)
+	}
+
+	return key.PublicKey()
+}
+
+func (p *Provider) findKey(kid string) (auth.JWK, bool) {
+	// Sem kid, só é possível escolher a chave quando o provedor publica uma única chave de assinatura
+	if kid == "" {
+		var candidates []auth.JWK
+		for _, key := range p.keys.Keys {
+			if key.Use == "" || key.Use == "sig" {
+				candidates = append(candidates, key)
+			}
+		}
+		if len(candidates) == 1 {
+			return candidates[0], true
+		}
+		return auth.JWK{}, false
+	}
+	return p.keys.Key(kid)
+}
+
+func (p *Provider) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status inesperado %d em %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,117 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"auth-template/pkg/auth"
+	"auth-template/pkg/oidc"
+	"auth-template/pkg/oidc/oidctest"
+)
+
+func newTestProvider(t *testing.T) (*oidctest.Server, *oidc.Provider) {
+	server, err := oidctest.NewServer("client-id", "client-secret")
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       server.Issuer(),
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:3000/oidc/callback/test",
+	}, nil)
+
+	return server, provider
+}
+
+func TestProviderAuthorizationCodeFlow(t *testing.T) {
+	server, provider := newTestProvider(t)
+	server.SetIdentity(oidctest.Identity{
+		Subject:       "user-123",
+		Email:         "user@example.com",
+		EmailVerified: true,
+	})
+	ctx := context.Background()
+
+	verifier, err := auth.GeneratePKCEVerifier()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", auth.PKCEChallenge(verifier))
+	require.NoError(t, err)
+
+	code, state, err := server.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state-1", state)
+
+	t.Run("PKCE_incorreto", func(t *testing.T) {
+		wrongVerifier, _ := auth.GeneratePKCEVerifier()
+		_, err := provider.Exchange(ctx, code, wrongVerifier)
+		assert.Error(t, err)
+	})
+
+	// O código anterior foi consumido pela tentativa com PKCE incorreto
+	authURL, _ = provider.AuthCodeURL(ctx, "state-2", "nonce-2", auth.PKCEChallenge(verifier))
+	code, _, err = server.Authorize(authURL)
+	require.NoError(t, err)
+
+	token, err := provider.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	t.Run("Nonce_incorreto", func(t *testing.T) {
+		_, err := provider.VerifyIDToken(ctx, token.IDToken, "outro-nonce")
+		assert.True(t, errors.Is(err, oidc.ErrInvalidIDToken))
+	})
+
+	t.Run("ID_token_válido", func(t *testing.T) {
+		claims, err := provider.VerifyIDToken(ctx, token.IDToken, "nonce-2")
+		require.NoError(t, err)
+		assert.Equal(t, "user-123", claims.Subject)
+		assert.Equal(t, "user@example.com", claims.Email)
+		assert.True(t, claims.EmailVerified)
+	})
+}
+
+func TestProviderRejectsForeignAudience(t *testing.T) {
+	server, _ := newTestProvider(t)
+	ctx := context.Background()
+
+	// Um cliente diferente do provedor recebe o token; o relying party não pode aceitá-lo
+	other := oidc.NewProvider(oidc.Config{
+		Issuer:       server.Issuer(),
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:3000/oidc/callback/test",
+	}, nil)
+	foreign := oidc.NewProvider(oidc.Config{
+		Issuer:      server.Issuer(),
+		ClientID:    "outro-cliente",
+		RedirectURL: "http://localhost:3000/oidc/callback/test",
+	}, nil)
+
+	verifier, _ := auth.GeneratePKCEVerifier()
+	authURL, err := other.AuthCodeURL(ctx, "state", "nonce", auth.PKCEChallenge(verifier))
+	require.NoError(t, err)
+	code, _, err := server.Authorize(authURL)
+	require.NoError(t, err)
+	token, err := other.Exchange(ctx, code, verifier)
+	require.NoError(t, err)
+
+	_, err = foreign.VerifyIDToken(ctx, token.IDToken, "nonce")
+	assert.True(t, errors.Is(err, oidc.ErrInvalidIDToken))
+}
+
+func TestProviderRejectsUnknownIssuer(t *testing.T) {
+	server, _ := newTestProvider(t)
+
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:   server.Issuer() + "/outro",
+		ClientID: "client-id",
+	}, nil)
+
+	_, err := provider.Metadata(context.Background())
+	assert.Error(t, err)
+}
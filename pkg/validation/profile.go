@@ -0,0 +1,103 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	_ "time/tzdata" // fusos horários embutidos, sem depender do tzdata do sistema
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/language"
+
+	apperrors "auth-template/internal/errors"
+)
+
+// Limites dos campos do perfil
+const (
+	MaxDisplayNameLength = 100
+	MaxAvatarURLLength   = 2048
+	MaxMetadataSize      = 16 * 1024
+	MaxMetadataKeyLength = 64
+)
+
+// ValidateDisplayName remove os espaços das pontas e recusa nomes longos ou com caracteres de controle
+func ValidateDisplayName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > MaxDisplayNameLength {
+		return "", apperrors.NewValidationError(
+			fmt.Sprintf("display_name deve ter no máximo %d caracteres", MaxDisplayNameLength))
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return "", apperrors.NewValidationError("display_name contém caracteres inválidos")
+	}
+	return name, nil
+}
+
+// ValidateAvatarURL aceita apenas URLs https absolutas; vazio remove o avatar
+func ValidateAvatarURL(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", nil
+	}
+	if len(rawURL) > MaxAvatarURLLength {
+		return "", apperrors.NewValidationError(
+			fmt.Sprintf("avatar_url deve ter no máximo %d caracteres", MaxAvatarURLLength))
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" || parsed.User != nil {
+		return "", apperrors.NewValidationError("avatar_url deve ser uma URL https válida")
+	}
+	return parsed.String(), nil
+}
+
+// ValidateLocale valida uma tag de idioma BCP 47 (ex: pt-BR) e retorna a forma canônica; vazio remove o idioma
+func ValidateLocale(locale string) (string, error) {
+	locale = strings.TrimSpace(locale)
+	if locale == "" {
+		return "", nil
+	}
+
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", apperrors.NewValidationError("locale deve ser uma tag de idioma válida (ex: pt-BR)")
+	}
+	return tag.String(), nil
+}
+
+// ValidateTimezone valida um fuso horário da base IANA (ex: America/Sao_Paulo); vazio remove o fuso
+func ValidateTimezone(timezone string) (string, error) {
+	timezone = strings.TrimSpace(timezone)
+	if timezone == "" {
+		return "", nil
+	}
+
+	// "Local" depende do servidor e não identifica um fuso
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return "", apperrors.NewValidationError("timezone deve ser um fuso horário IANA válido (ex: America/Sao_Paulo)")
+	}
+	return timezone, nil
+}
+
+// ValidateMetadata limita o tamanho das chaves e do objeto serializado
+func ValidateMetadata(metadata map[string]interface{}) error {
+	for key := range metadata {
+		if key == "" || utf8.RuneCountInString(key) > MaxMetadataKeyLength {
+			return apperrors.NewValidationError(
+				fmt.Sprintf("metadata: as chaves devem ter entre 1 e %d caracteres", MaxMetadataKeyLength))
+		}
+	}
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return apperrors.NewValidationError("metadata inválido")
+	}
+	if len(data) > MaxMetadataSize {
+		return apperrors.NewValidationError(
+			fmt.Sprintf("metadata deve ter no máximo %d bytes", MaxMetadataSize))
+	}
+	return nil
+}
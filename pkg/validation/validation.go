@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	apperrors "auth-template/internal/errors"
 )
@@ -163,3 +164,22 @@ func SanitizeString(input string) string {
 
 	return input
 }
+
+// Truncate limita a string a maxBytes bytes sem cortar um caractere UTF-8 ao meio. Sequências inválidas são
+// removidas antes, pois não podem ser gravadas em colunas de texto do Postgres.
+func Truncate(input string, maxBytes int) string {
+	input = strings.ToValidUTF8(input, "")
+	if len(input) <= maxBytes {
+		return input
+	}
+
+	end := 0
+	for end < len(input) {
+		_, size := utf8.DecodeRuneInString(input[end:])
+		if end+size > maxBytes {
+			break
+		}
+		end += size
+	}
+	return input[:end]
+}
@@ -0,0 +1,27 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "Chrome", Truncate("Chrome", 100))
+	assert.Equal(t, "Chr", Truncate("Chrome", 3))
+
+	// "ã" ocupa 2 bytes: o corte não pode parti-lo ao meio
+	userAgent := strings.Repeat("a", 511) + "ão"
+	truncated := Truncate(userAgent, 512)
+	assert.True(t, utf8.ValidString(truncated))
+	assert.Equal(t, strings.Repeat("a", 511), truncated)
+
+	// Emoji de 4 bytes no limite
+	assert.Equal(t, "ab", Truncate("ab😀", 5))
+	assert.Equal(t, "ab😀", Truncate("ab😀", 6))
+
+	// Bytes inválidos são removidos
+	assert.Equal(t, "ab", Truncate("a\xffb", 10))
+}